@@ -0,0 +1,139 @@
+// Package capture parses capture-device inputs such as device:v4l2:/dev/video0, which FFmpeg
+// opens through a platform input format (-f v4l2 -i /dev/video0) rather than as a file or URL.
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Prefix marks an input as a capture device
+const Prefix = "device:"
+
+// formatPlatforms lists the supported capture input formats and the OS each one runs on
+var formatPlatforms = map[string]string{
+	"v4l2":         "linux",
+	"x11grab":      "linux",
+	"kmsgrab":      "linux",
+	"alsa":         "linux",
+	"pulse":        "linux",
+	"gdigrab":      "windows",
+	"dshow":        "windows",
+	"avfoundation": "darwin",
+}
+
+// Device is a capture source: the FFmpeg input format and the device it opens
+type Device struct {
+	Format string
+	Name   string
+}
+
+// IsDevice reports whether the input names a capture device
+func IsDevice(input string) bool {
+	return strings.HasPrefix(input, Prefix)
+}
+
+// Parse splits a device input into its format and device. Besides device:<format>:<device>,
+// it accepts device:screen and device:camera for the platform's screen and default camera,
+// and device:/dev/videoN for a V4L2 device.
+func Parse(input string) (Device, error) {
+	spec, ok := strings.CutPrefix(input, Prefix)
+	if !ok {
+		return Device{}, fmt.Errorf("%s is not a capture device input", input)
+	}
+
+	switch spec {
+	case "screen":
+		return screen(runtime.GOOS)
+	case "camera":
+		return camera(runtime.GOOS)
+	}
+
+	if format, name, found := strings.Cut(spec, ":"); found {
+		if _, known := formatPlatforms[format]; known {
+			if name == "" {
+				return Device{}, fmt.Errorf("capture input %s is missing the device after %s:", input, format)
+			}
+			return Device{Format: format, Name: name}, nil
+		}
+	}
+	if strings.HasPrefix(spec, "/dev/video") {
+		return Device{Format: "v4l2", Name: spec}, nil
+	}
+	return Device{}, fmt.Errorf("unrecognised capture input %s: use device:<format>:<device> with one of %s, device:screen or device:camera",
+		input, strings.Join(Formats(), ", "))
+}
+
+// Formats returns the supported capture input formats, sorted
+func Formats() []string {
+	formats := make([]string, 0, len(formatPlatforms))
+	for format := range formatPlatforms {
+		formats = append(formats, format)
+	}
+	slices.Sort(formats)
+	return formats
+}
+
+// screen returns the whole-screen capture device of the platform
+func screen(goos string) (Device, error) {
+	switch goos {
+	case "linux":
+		display := os.Getenv("DISPLAY")
+		if display == "" {
+			return Device{}, errors.New("device:screen needs an X11 session ($DISPLAY is not set); use device:kmsgrab:- on a console")
+		}
+		return Device{Format: "x11grab", Name: display}, nil
+	case "windows":
+		return Device{Format: "gdigrab", Name: "desktop"}, nil
+	case "darwin":
+		return Device{Format: "avfoundation", Name: "Capture screen 0"}, nil
+	}
+	return Device{}, fmt.Errorf("screen capture is not supported on %s", goos)
+}
+
+// camera returns the default camera of the platform
+func camera(goos string) (Device, error) {
+	switch goos {
+	case "linux":
+		return Device{Format: "v4l2", Name: "/dev/video0"}, nil
+	case "darwin":
+		return Device{Format: "avfoundation", Name: "0"}, nil
+	case "windows":
+		// DirectShow has no default device; list them with: ffmpeg -list_devices true -f dshow -i dummy
+		return Device{}, errors.New("DirectShow cameras must be named: use device:dshow:video=<camera name>")
+	}
+	return Device{}, fmt.Errorf("camera capture is not supported on %s", goos)
+}
+
+// Args returns the FFmpeg input options that open the device
+func (d Device) Args() []string {
+	return []string{"-f", d.Format, "-i", d.Name}
+}
+
+// Check verifies the device can be opened on this system: the format must belong to the
+// running OS and device nodes must exist. Devices FFmpeg resolves by name (DirectShow,
+// AVFoundation, ALSA) are left for FFmpeg to report.
+func (d Device) Check() error {
+	if goos := formatPlatforms[d.Format]; goos != runtime.GOOS {
+		return fmt.Errorf("%s capture is only available on %s", d.Format, goos)
+	}
+	if d.Format != "v4l2" {
+		return nil
+	}
+
+	info, err := os.Stat(d.Name)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("capture device %s does not exist; list cameras with: v4l2-ctl --list-devices", d.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot access capture device %s: %w", d.Name, err)
+	}
+	if info.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("%s is not a video device", d.Name)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package capture
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Device
+		wantErr bool
+	}{
+		{input: "device:v4l2:/dev/video0", want: Device{Format: "v4l2", Name: "/dev/video0"}},
+		{input: "device:/dev/video2", want: Device{Format: "v4l2", Name: "/dev/video2"}},
+		{input: "device:x11grab::0.0+100,200", want: Device{Format: "x11grab", Name: ":0.0+100,200"}},
+		{input: "device:gdigrab:desktop", want: Device{Format: "gdigrab", Name: "desktop"}},
+		{input: "device:dshow:video=USB Camera:audio=Microphone", want: Device{Format: "dshow", Name: "video=USB Camera:audio=Microphone"}},
+		{input: "device:avfoundation:1:0", want: Device{Format: "avfoundation", Name: "1:0"}},
+		{input: "device:v4l2:", wantErr: true},
+		{input: "device:webcam:/dev/video0", wantErr: true},
+		{input: "/dev/video0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlatformDevices(t *testing.T) {
+	t.Setenv("DISPLAY", ":1")
+	if got, _ := screen("linux"); got != (Device{Format: "x11grab", Name: ":1"}) {
+		t.Errorf("screen(linux) = %+v", got)
+	}
+	if got, _ := screen("windows"); got != (Device{Format: "gdigrab", Name: "desktop"}) {
+		t.Errorf("screen(windows) = %+v", got)
+	}
+	if got, _ := camera("darwin"); got != (Device{Format: "avfoundation", Name: "0"}) {
+		t.Errorf("camera(darwin) = %+v", got)
+	}
+	if _, err := camera("windows"); err == nil {
+		t.Error("camera(windows) expected an error asking for the DirectShow device name")
+	}
+
+	t.Setenv("DISPLAY", "")
+	if _, err := screen("linux"); err == nil {
+		t.Error("screen(linux) without $DISPLAY expected an error")
+	}
+
+	want := []string{"-f", "v4l2", "-i", "/dev/video0"}
+	if got := (Device{Format: "v4l2", Name: "/dev/video0"}).Args(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Args() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseBitrate parses FFmpeg-style bitrates such as "192k", "4.5M" or "800000" into bits per second
+func ParseBitrate(s string) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	multiplier := 1.0
+	switch s[len(s)-1] {
+	case 'k', 'K':
+		multiplier = 1e3
+	case 'm', 'M':
+		multiplier = 1e6
+	case 'g', 'G':
+		multiplier = 1e9
+	}
+	if multiplier != 1 {
+		s = s[:len(s)-1]
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v * multiplier
+}
+
+// FormatBitrate formats bits per second as an FFmpeg bitrate in kbit/s, e.g. "6000k"
+func FormatBitrate(bps float64) string {
+	return fmt.Sprintf("%.0fk", bps/1e3)
+}
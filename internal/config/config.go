@@ -1,5 +1,15 @@
 package config
 
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
 // ProcessingConfig holds all configuration for video processing
 type ProcessingConfig struct {
 	Acceleration string
@@ -8,21 +18,721 @@ type ProcessingConfig struct {
 	Preset       string
 	InputPath    string
 	OutputPath   string
+
+	// InputHeaders ("Key: Value") and InputCookies (Set-Cookie values such as
+	// "session=abc; path=/; domain=cdn.example.com") are sent with HTTP(S) input requests
+	InputHeaders []string
+	InputCookies []string
+
+	// OutputDir names the output after the input inside this directory, with the default
+	// output's extension, unless an output was given (OUTPUT_PATH or at the prompt).
+	// OutputFromEnv records that OutputPath came from OUTPUT_PATH.
+	OutputDir     string
+	OutputFromEnv bool
+
+	// QualityLevel is an encoder-independent quality from 0 (smallest) to 100 (best), translated
+	// into Quality on the chosen encoder's native scale; nil keeps Quality as given
+	QualityLevel *int
+
+	// Lossless encodes mathematically lossless video, in place of Quality. PixFmt forces the
+	// encoder's pixel format (-pix_fmt); empty lets FFmpeg pick.
+	Lossless bool
+	PixFmt   string
+
+	// CopyVideo stream-copies the video and only re-encodes audio
+	CopyVideo    bool
+	AudioCodec   string
+	AudioBitrate string
+
+	// FFmpegPath is the ffmpeg binary to run; ffprobe is expected alongside it
+	FFmpegPath string
+
+	// HWAccel overrides the auto-selected acceleration method; empty means auto
+	HWAccel string
+
+	// AMDEncoder chooses the encoder for AMD GPUs on Linux: VAAPI through Mesa (the default),
+	// AMD's AMF runtime, or AMF when it is installed and VAAPI otherwise
+	AMDEncoder string
+
+	// CodecOverride is the user's choice of video encoder, taking precedence over the one
+	// picked for the detected GPU; empty means auto
+	CodecOverride string
+
+	// GPUDevice selects which GPU the hardware path uses (CUDA/NVENC index); empty means the default device
+	GPUDevice string
+
+	// RenderNode is the DRM render node VAAPI and QSV open, e.g. /dev/dri/renderD129; empty
+	// means the first one (renderD128)
+	RenderNode string
+
+	// GPUID selects the GPU by PCI address (01:00.0) or NVIDIA UUID, which stay stable across reboots
+	GPUID string
+
+	// SoftwareDecode decodes on the CPU while keeping the hardware encoder (-sw-decode, or
+	// set automatically when hardware decoding fails)
+	SoftwareDecode bool
+
+	// StartTime and Duration limit the encode to a time range of the input (FFmpeg time syntax)
+	StartTime string
+	Duration  string
+	// FrameAt is the input position, in seconds, of the frame -frame-at saves as an image.
+	// FrameSeek picks accurate seeking (the exact frame) or fast (the keyframe before it).
+	FrameAt   string
+	FrameSeek string
+	// MaxDuration caps the length of an encode from a live input, which has no end of its own,
+	// in seconds; "" leaves it unlimited. File inputs aren't affected.
+	MaxDuration string
+
+	// SegmentTime splits a file output into standalone numbered files of this many seconds
+	// (out_000.mp4, out_001.mp4, ...). SegmentSize, in bytes, derives SegmentTime from the
+	// predicted bitrate instead.
+	SegmentTime string
+	SegmentSize int64
+
+	// NoAudio drops audio streams from the output
+	NoAudio bool
+
+	// AudioLang and SubLang select input streams by language tag (e.g. "jpn"); empty keeps FFmpeg's default
+	AudioLang string
+	SubLang   string
+
+	// Maps are explicit -map stream specifiers, resolved by the processor; empty uses FFmpeg's default selection
+	Maps []string
+
+	// SubtitleCodec is the subtitle encoder used when subtitles are mapped (e.g. "copy", "mov_text")
+	SubtitleCodec string
+
+	// MaxRate caps the bitrate of a CRF/CQ encode (capped CRF); BufSize is the VBV buffer,
+	// defaulting to twice MaxRate
+	MaxRate string
+	BufSize string
+
+	// LimitRate is the total bandwidth a network stream may use, e.g. 3M; the input is read in
+	// real time and the video capped to fit. Empty leaves the stream unpaced.
+	LimitRate string
+
+	// CheckStream tests a streaming output before the encode, so an unreachable endpoint
+	// fails fast: RTMP with a handshake, SRT and RTSP by publishing a short test pattern
+	CheckStream bool
+
+	// HQTuning enables the hardware encoder's slower, higher-quality options (NVENC multipass,
+	// spatial/temporal AQ and lookahead; QSV look-ahead)
+	HQTuning bool
+	// NVENCTune is NVENC's tuning mode (hq, ll, ull or lossless); empty picks it from the
+	// output: hq for files, ull for streams, lossless with -lossless
+	NVENCTune string
+
+	// RTSPListen makes FFmpeg act as an RTSP server for the input, waiting for a camera or
+	// encoder to publish to InputPath, instead of connecting to it as a client
+	RTSPListen bool
+
+	// FFmpegMajor is the detected FFmpeg major version, used to pick renamed options; 0 means unknown
+	FFmpegMajor int
+
+	// Record captures a live network input to a crash-tolerant file, copying streams where possible
+	Record bool
+
+	// MultiGPU splits the input into segments encoded concurrently on all capable GPUs
+	MultiGPU bool
+
+	// Speed trades quality for encode speed on the software encoders (slow, balanced, fast)
+	Speed string
+
+	// SoftwarePreset is the x264/x265 preset of software encodes and the software fallbacks;
+	// auto picks one from the CPU's cores and AVX support and from Speed
+	SoftwarePreset string
+
+	// PlaybackSpeed speeds the output up (2 plays twice as fast) or slows it down (0.5),
+	// retiming video and audio together; 1 leaves the timing unchanged
+	PlaybackSpeed float64
+
+	// Crop encodes only this region of the source, scaled to OutputWidth x OutputHeight when
+	// set (which also resizes on its own). With Zoom the frame instead animates from the full
+	// source to the region over the clip, Ken Burns style; ZoomFrames and ZoomFPS are filled
+	// in from the probed input.
+	Crop         *Region
+	OutputWidth  int
+	OutputHeight int
+	Zoom         bool
+	ZoomFrames   int
+	ZoomFPS      string
+
+	// CodecFamily selects H.264 or HEVC output (h264, hevc, auto). Auto picks HEVC when the
+	// source's shorter side is at least HEVCMinHeight, so 4K sources compress better while
+	// 1080p and below stay H.264 for compatibility.
+	CodecFamily   string
+	HEVCMinHeight int
+
+	// Threads caps FFmpeg's CPU threads for software encoding and filtering; 0 lets FFmpeg decide.
+	// Hardware encoders largely ignore it since the work happens on the GPU.
+	Threads int
+
+	// ABRLadder produces one HLS variant per rendition plus a master playlist
+	ABRLadder []Rendition
+	// Renditions expands one input into a separately encoded file per height, named after
+	// the output with the rendition appended (movie.mp4 -> movie_720p.mp4)
+	Renditions []OutputRendition
+
+	// ProbeSize and AnalyzeDuration override FFmpeg's input probing; empty means
+	// minimal probing for live inputs and FFmpeg's defaults for files
+	ProbeSize       string
+	AnalyzeDuration string
+
+	// InPlace allows the output to replace the input, via a temporary file renamed over it on success
+	InPlace bool
+
+	// KeepOnFailure keeps a failed encode's partial output instead of removing it when it's
+	// empty or clearly truncated
+	KeepOnFailure bool
+
+	// OverwritePolicy controls what happens when the output file already exists
+	OverwritePolicy string
+	// SkipExisting skips the encode when the output is newer than the input, so re-running
+	// over a library only encodes new or changed sources
+	SkipExisting bool
+
+	// Metadata holds key=value tags to set on the output; StripMetadata drops all input metadata first
+	Metadata      []string
+	StripMetadata bool
+
+	// MPEG-TS muxer options for SRT/UDP/TS outputs; empty values keep FFmpeg's defaults.
+	// PCRPeriod is in milliseconds; FlushPackets writes each packet immediately for low latency.
+	TSFlags         string
+	PCRPeriod       int
+	ServiceName     string
+	ServiceProvider string
+	FlushPackets    bool
+
+	// DisableFastStart skips the faststart moov relocation pass on MP4/MOV file outputs
+	DisableFastStart bool
+
+	// DisableAtomicWrite writes file outputs under their final name as they encode, instead
+	// of to <output>.tmp renamed into place on success
+	DisableAtomicWrite bool
+
+	// Shortest ends the output with its shortest input or stream (-shortest). Modes that mix in
+	// a second input, such as an overlay or replacement audio, default to it unless NoShortest
+	// is set by -shortest=false.
+	Shortest   bool
+	NoShortest bool
+
+	// FragmentedMP4 writes fragmented MP4 instead of relocating the moov atom with faststart
+	FragmentedMP4 bool
+
+	// StreamTitles set per-track titles as "a:0=Commentary"; DefaultTracks mark one track per
+	// kind as the default ("a:1"). The processor expands DefaultTracks into Dispositions
+	// ("a:0=0", "a:1=default") once it knows how many tracks of each kind the output has.
+	StreamTitles  []string
+	DefaultTracks []string
+	Dispositions  []string
+
+	// CopyChapters and CopyMetadata carry chapter markers and global tags from the input.
+	// FFmpeg does so by default; they matter when an extra input could supply them instead.
+	CopyChapters bool
+	CopyMetadata bool
+
+	// AudioChannels downmixes (or upmixes) the audio to this many channels; 0 keeps the
+	// source layout. AudioPan is a custom pan filter mix (e.g. "stereo|FL<FL+0.7*FC|FR<FR+0.7*FC")
+	// used instead.
+	AudioChannels int
+	AudioPan      string
+
+	// AudioFile replaces the input's audio with an external file, or is mixed with it (amix) when AudioMix is set
+	AudioFile string
+	AudioMix  bool
+
+	// SilentAudio adds a silent audio track (anullsrc) when the input has none, for players
+	// and platforms that reject video-only files. AddSilence is set by the processor once the
+	// probe confirms the input has no audio.
+	SilentAudio bool
+	AddSilence  bool
+
+	// Watermark burns this image into the video at WatermarkPosition, sized by WatermarkSize
+	// against the output frame (after scaling and cropping)
+	Watermark         string
+	WatermarkPosition string
+	WatermarkSize     WatermarkSize
+
+	// CopyData maps and copies the input's data streams (e.g. KLV or timed metadata) into the output
+	CopyData bool
+
+	// HLSTime is the HLS segment length in seconds. GOPSize, when set, is the keyframe interval
+	// in frames (-g); for HLS the processor derives it from the source frame rate, unless given,
+	// so GOPs line up with segment boundaries. NoSceneCut stops encoders adding keyframes at
+	// scene changes, so keyframes only fall at the GOP interval. ClosedGOP also keeps each GOP
+	// self-contained (no references across its start), as broadcast and SCTE-35 splicing need.
+	HLSTime    int
+	GOPSize    int
+	NoSceneCut bool
+	ClosedGOP  bool
+
+	// FilterComplex is a raw FFmpeg -filter_complex graph used in place of the generated
+	// video filters. Its final output, unlabeled or with a trailing [label], becomes the video.
+	FilterComplex string
+
+	// FrameRate is the output frame rate (-r) in any form ParseFrameRate accepts; empty keeps the
+	// source rate. CFR makes FFmpeg drop or duplicate frames for a strictly constant rate.
+	FrameRate string
+	CFR       bool
+
+	// HLSSegmentType is the HLS segment container, mpegts or fmp4 (CMAF); empty is mpegts.
+	// HLSSingleFile writes each playlist's segments into one file addressed by byte ranges,
+	// and HLSVOD marks playlists as complete VOD playlists.
+	HLSSegmentType string
+	HLSSingleFile  bool
+	HLSVOD         bool
+
+	// ScaleHeight downscales the video to at most this height (keeping aspect); 0 keeps the source size.
+	// OOMDownscaleHeight is the height a hardware encode retries at after running out of GPU memory.
+	ScaleHeight        int
+	OOMDownscaleHeight int
+
+	// SAR and DAR force the sample or display aspect ratio (e.g. "1:1", "16:9"); empty passes through
+	SAR string
+	DAR string
+
+	// Tonemap converts HDR sources to SDR Rec.709 using a CPU filter chain
+	Tonemap bool
+
+	// ColorRange is the output color range: tv (limited), pc (full), or auto to keep the
+	// source's range; empty leaves range handling to FFmpeg. SourceColorRange is the input's
+	// range, resolved by the processor from a probe.
+	ColorRange       string
+	SourceColorRange string
+
+	// GPUCacheTTL reuses a previous GPU detection for this long; 0 disables caching
+	GPUCacheTTL time.Duration
+
+	// InitRetries is how many times a hardware encode that failed to initialize transiently
+	// (e.g. NVENC right after boot or a driver reload) is retried, InitRetryDelay apart
+	InitRetries    int
+	InitRetryDelay time.Duration
+
+	// NoCrossVendor skips retrying a failed hardware encode on the other detected GPU
+	// vendors' encoders, going straight to software
+	NoCrossVendor bool
+
+	// StallTimeout stops an encode whose progress hasn't advanced for this long; 0 disables the watchdog
+	StallTimeout time.Duration
+
+	// EncoderWait is how long to wait for a busy NVENC encoder to free up before encoding anyway
+	EncoderWait time.Duration
+
+	// ProgressJSON emits machine-readable progress as JSON lines to file descriptor ProgressFD
+	ProgressJSON bool
+	ProgressFD   int
+
+	// ErrorJSON writes a failure as a JSON object (class, message, exit codes, FFmpeg's
+	// stderr) to file descriptor ErrorFD, in addition to the usual error line
+	ErrorJSON bool
+	ErrorFD   int
+
+	// ReportPath, when set, receives a JSON summary of the encode's outcome
+	ReportPath string
+
+	// Notify is run when the encode finishes (a shell command) or POSTed to (an http(s)://
+	// webhook URL), with the outcome as JSON
+	Notify string
+
+	// SaveCommand appends the FFmpeg command of each encode to a .cmd sidecar next to the output
+	SaveCommand bool
+
+	// PreserveTimestamps copies the input's modification time onto the output
+	PreserveTimestamps bool
+
+	// Preview encodes and plays this much of the input before the full encode, which only
+	// starts once confirmed; 0 disables the preview
+	Preview time.Duration
+
+	// ResumePlayback makes the player continue each file from where its playback last stopped
+	ResumePlayback bool
+
+	// PlayDetached launches the player and returns without waiting for it to exit
+	PlayDetached bool
+
+	// ASCII replaces emoji in console output with plain markers such as [OK] and [ERROR]
+	ASCII bool
 }
 
 // NewDefault creates a new config with default values
 func NewDefault() *ProcessingConfig {
 	return &ProcessingConfig{
-		Quality:    23, // Default CRF/QP value
-		OutputPath: "output.mp4",
+		Quality:            23, // Default CRF/QP value, on x264's scale until an encoder is chosen
+		InputPath:          os.Getenv("INPUT_PATH"),
+		OutputPath:         defaultOutputPath(),
+		OutputFromEnv:      os.Getenv("OUTPUT_PATH") != "",
+		OverwritePolicy:    OverwriteAlways,
+		AMDEncoder:         AMDEncoderVAAPI,
+		FrameSeek:          FrameSeekAccurate,
+		FFmpegPath:         defaultFFmpegPath(),
+		ProgressFD:         1,
+		ErrorFD:            2,
+		Speed:              SpeedBalanced,
+		SoftwarePreset:     SoftwarePresetAuto,
+		PlaybackSpeed:      1,
+		WatermarkPosition:  WatermarkBottomRight,
+		CodecFamily:        CodecFamilyH264,
+		HEVCMinHeight:      2160,
+		HLSTime:            DefaultHLSTime,
+		StallTimeout:       60 * time.Second,
+		OOMDownscaleHeight: 1080,
+		InitRetries:        2,
+		InitRetryDelay:     2 * time.Second,
+	}
+}
+
+// defaultFFmpegPath honours the FFMPEG_PATH environment variable, falling back to "ffmpeg" on PATH
+func defaultFFmpegPath() string {
+	if path := os.Getenv("FFMPEG_PATH"); path != "" {
+		return path
+	}
+	return "ffmpeg"
+}
+
+// defaultOutputPath honours the OUTPUT_PATH environment variable, falling back to output.mp4
+func defaultOutputPath() string {
+	if path := os.Getenv("OUTPUT_PATH"); path != "" {
+		return path
+	}
+	return "output.mp4"
+}
+
+// FFprobePath returns the ffprobe binary that accompanies FFmpegPath. A bare name
+// resolves via PATH; a path with a directory looks for ffprobe next to it.
+func (c *ProcessingConfig) FFprobePath() string {
+	dir := filepath.Dir(c.FFmpegPath)
+	if dir == "." && !strings.ContainsRune(c.FFmpegPath, filepath.Separator) {
+		return "ffprobe"
+	}
+	name := "ffprobe"
+	if strings.EqualFold(filepath.Ext(c.FFmpegPath), ".exe") {
+		name += ".exe"
+	}
+	return filepath.Join(dir, name)
+}
+
+// HLS segment containers for HLSSegmentType
+const (
+	HLSSegmentMPEGTS = "mpegts"
+	HLSSegmentFMP4   = "fmp4"
+)
+
+// HLSSegmentTypes lists the valid non-empty HLSSegmentType values
+var HLSSegmentTypes = []string{HLSSegmentMPEGTS, HLSSegmentFMP4}
+
+// DefaultHLSTime is the HLS segment length in seconds when -hls-time isn't given
+const DefaultHLSTime = 10
+
+// Overwrite policies for an existing output file
+const (
+	OverwriteAlways = "overwrite" // replace it (-y)
+	OverwriteSkip   = "skip"      // leave it and skip the encode (-n)
+	OverwriteBackup = "backup"    // rename it to <output>.bak, then write
+	OverwriteError  = "error"     // refuse to run
+)
+
+// OverwritePolicies lists the valid OverwritePolicy values
+var OverwritePolicies = []string{OverwriteAlways, OverwriteSkip, OverwriteBackup, OverwriteError}
+
+// Seek modes for -frame-at
+const (
+	FrameSeekAccurate = "accurate" // decode from the preceding keyframe up to the exact frame
+	FrameSeekFast     = "fast"     // take the keyframe at or before the position
+)
+
+// FrameSeekModes lists the valid FrameSeek values
+var FrameSeekModes = []string{FrameSeekAccurate, FrameSeekFast}
+
+// NVENCTunes lists the valid NVENCTune values
+var NVENCTunes = []string{"hq", "ll", "ull", "lossless"}
+
+// AMD encoders on Linux
+const (
+	AMDEncoderVAAPI = "vaapi" // h264_vaapi through Mesa
+	AMDEncoderAMF   = "amf"   // h264_amf through AMD's AMF runtime
+	AMDEncoderAuto  = "auto"  // AMF when installed, else VAAPI
+)
+
+// AMDEncoders lists the valid AMDEncoder values
+var AMDEncoders = []string{AMDEncoderVAAPI, AMDEncoderAMF, AMDEncoderAuto}
+
+// Speed levels for the software encoders
+const (
+	SpeedSlow     = "slow"
+	SpeedBalanced = "balanced"
+	SpeedFast     = "fast"
+)
+
+// SoftwarePresetAuto picks the x264/x265 preset from the CPU and Speed
+const SoftwarePresetAuto = "auto"
+
+// SoftwarePresets lists the valid SoftwarePreset values, fastest first
+var SoftwarePresets = []string{SoftwarePresetAuto, "ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow"}
+
+// PlaybackSpeed limits: beyond them video frames are mostly dropped or repeated and the
+// audio is unintelligible
+const (
+	MinPlaybackSpeed = 0.1
+	MaxPlaybackSpeed = 10.0
+)
+
+// SpeedLevels lists the valid Speed values
+var SpeedLevels = []string{SpeedSlow, SpeedBalanced, SpeedFast}
+
+// Codec families the video encoder is chosen from
+const (
+	CodecFamilyH264 = "h264"
+	CodecFamilyHEVC = "hevc"
+	CodecFamilyAuto = "auto"
+)
+
+// CodecFamilies lists the valid CodecFamily values
+var CodecFamilies = []string{CodecFamilyH264, CodecFamilyHEVC, CodecFamilyAuto}
+
+// Color ranges for ColorRange
+const (
+	ColorRangeTV   = "tv"
+	ColorRangePC   = "pc"
+	ColorRangeAuto = "auto"
+)
+
+// ColorRanges lists the valid non-empty ColorRange values
+var ColorRanges = []string{ColorRangeTV, ColorRangePC, ColorRangeAuto}
+
+// VideoCodecs lists the video encoders that can be selected explicitly with -codec
+var VideoCodecs = []string{
+	"libx264", "libx265", "libvpx-vp9", "libaom-av1", "libsvtav1",
+	"h264_nvenc", "hevc_nvenc", "h264_qsv", "hevc_qsv", "h264_vaapi", "hevc_vaapi",
+	"h264_videotoolbox", "hevc_videotoolbox", "h264_amf", "hevc_amf",
+}
+
+// AccelerationMethods lists the acceleration methods that can be selected explicitly
+var AccelerationMethods = []string{"none", "cuda", "qsv", "vaapi", "videotoolbox", "d3d11va", "dxva2", "d3d12va"}
+
+// aspectRatioPattern matches N:M, N/M or decimal aspect ratios
+var aspectRatioPattern = regexp.MustCompile(`^\d+([:/]\d+|\.\d+)?$`)
+
+// Validate checks for option combinations that cannot work together
+func (c *ProcessingConfig) Validate() error {
+	if c.HWAccel != "" && !slices.Contains(AccelerationMethods, c.HWAccel) {
+		return fmt.Errorf("unknown -hwaccel %q (supported: %s)", c.HWAccel, strings.Join(AccelerationMethods, ", "))
+	}
+	if !slices.Contains(AMDEncoders, c.AMDEncoder) {
+		return fmt.Errorf("unknown -amd-encoder %q (supported: %s)", c.AMDEncoder, strings.Join(AMDEncoders, ", "))
+	}
+	if c.NVENCTune != "" && !slices.Contains(NVENCTunes, c.NVENCTune) {
+		return fmt.Errorf("unknown -nvenc-tune %q (supported: %s)", c.NVENCTune, strings.Join(NVENCTunes, ", "))
+	}
+	if c.Lossless && c.NVENCTune != "" && c.NVENCTune != "lossless" {
+		return fmt.Errorf("-lossless encodes with NVENC's lossless tuning and cannot be combined with -nvenc-tune %s", c.NVENCTune)
+	}
+	if !slices.Contains(FrameSeekModes, c.FrameSeek) {
+		return fmt.Errorf("unknown -frame-seek %q (supported: %s)", c.FrameSeek, strings.Join(FrameSeekModes, ", "))
+	}
+	if c.FrameAt != "" && (c.Record || len(c.Renditions) > 0) {
+		return fmt.Errorf("-frame-at saves a single image and cannot be combined with -record or -renditions")
+	}
+	if !slices.Contains(OverwritePolicies, c.OverwritePolicy) {
+		return fmt.Errorf("unknown -overwrite-policy %q (supported: %s)", c.OverwritePolicy, strings.Join(OverwritePolicies, ", "))
+	}
+	if !slices.Contains(SpeedLevels, c.Speed) {
+		return fmt.Errorf("unknown -speed %q (supported: %s)", c.Speed, strings.Join(SpeedLevels, ", "))
+	}
+	if !slices.Contains(SoftwarePresets, c.SoftwarePreset) {
+		return fmt.Errorf("unknown -software-preset %q (supported: %s)", c.SoftwarePreset, strings.Join(SoftwarePresets, ", "))
+	}
+	if c.CodecOverride != "" && !slices.Contains(VideoCodecs, c.CodecOverride) {
+		return fmt.Errorf("unknown -codec %q (supported: %s)", c.CodecOverride, strings.Join(VideoCodecs, ", "))
+	}
+	if c.CodecOverride != "" && (c.CopyVideo || c.MultiGPU || c.CodecFamily != CodecFamilyH264) {
+		return fmt.Errorf("-codec cannot be combined with -copy-video, -multi-gpu or -codec-family")
+	}
+	if !slices.Contains(CodecFamilies, c.CodecFamily) {
+		return fmt.Errorf("unknown -codec-family %q (supported: %s)", c.CodecFamily, strings.Join(CodecFamilies, ", "))
+	}
+	if c.ColorRange != "" && !slices.Contains(ColorRanges, c.ColorRange) {
+		return fmt.Errorf("unknown -color-range %q (supported: %s)", c.ColorRange, strings.Join(ColorRanges, ", "))
+	}
+	if c.SoftwareDecode && c.CopyVideo {
+		return fmt.Errorf("-sw-decode has no effect with -copy-video, which doesn't decode the video")
+	}
+	if c.ColorRange != "" && c.CopyVideo {
+		return fmt.Errorf("-color-range requires re-encoding the video and cannot be combined with -copy-video")
+	}
+	if c.Lossless && (c.CopyVideo || c.MaxRate != "" || c.QualityLevel != nil || len(c.ABRLadder) > 0) {
+		return fmt.Errorf("-lossless cannot be combined with -copy-video, -maxrate, -quality-level or -abr-ladder")
+	}
+	if c.GOPSize < 0 {
+		return fmt.Errorf("-gop must be 0 (encoder default) or a positive number of frames, got %d", c.GOPSize)
+	}
+	if c.FilterComplex != "" && (c.CopyVideo || len(c.ABRLadder) > 0 || c.AudioMix) {
+		return fmt.Errorf("-filter-complex cannot be combined with -copy-video, -abr-ladder or -audio-mix, which need their own filter graphs")
+	}
+	if (c.FrameRate != "" || c.CFR) && c.CopyVideo {
+		return fmt.Errorf("-fps and -cfr require re-encoding the video and cannot be combined with -copy-video")
+	}
+	if (c.GOPSize > 0 || c.NoSceneCut || c.ClosedGOP) && c.CopyVideo {
+		return fmt.Errorf("-gop, -no-scenecut and -closed-gop require re-encoding the video and cannot be combined with -copy-video")
+	}
+	if c.InitRetries < 0 || c.InitRetryDelay < 0 {
+		return fmt.Errorf("-init-retries and -init-retry-delay must not be negative")
+	}
+	if c.ErrorJSON && c.ErrorFD < 1 {
+		return fmt.Errorf("-error-fd must be 1 (stdout), 2 (stderr) or another open descriptor, got %d", c.ErrorFD)
+	}
+	if c.PlaybackSpeed < MinPlaybackSpeed || c.PlaybackSpeed > MaxPlaybackSpeed {
+		return fmt.Errorf("-playback-speed must be between %g and %g, got %g", MinPlaybackSpeed, MaxPlaybackSpeed, c.PlaybackSpeed)
+	}
+	if c.PlaybackSpeed != 1 && (c.CopyVideo || c.FilterComplex != "" || c.AudioMix || len(c.ABRLadder) > 0 || c.MultiGPU) {
+		return fmt.Errorf("-playback-speed re-times the video and audio filters and cannot be combined with -copy-video, -filter-complex, -audio-mix, -abr-ladder or -multi-gpu")
 	}
+	if c.StallTimeout < 0 {
+		return fmt.Errorf("-stall-timeout must be 0 (disabled) or a positive duration, got %v", c.StallTimeout)
+	}
+	if c.Preview < 0 {
+		return fmt.Errorf("-preview must be 0 (disabled) or a positive duration, got %v", c.Preview)
+	}
+	if c.Preview > 0 && (c.MultiGPU || c.Record || len(c.ABRLadder) > 0) {
+		return fmt.Errorf("-preview cannot be combined with -multi-gpu, -record or -abr-ladder")
+	}
+	if c.HLSSegmentType != "" && !slices.Contains(HLSSegmentTypes, c.HLSSegmentType) {
+		return fmt.Errorf("unknown -hls-segment-type %q (supported: %s)", c.HLSSegmentType, strings.Join(HLSSegmentTypes, ", "))
+	}
+	if c.HLSTime <= 0 {
+		return fmt.Errorf("-hls-time must be a positive number of seconds, got %d", c.HLSTime)
+	}
+	if c.HEVCMinHeight <= 0 {
+		return fmt.Errorf("-hevc-min-height must be a positive height, got %d", c.HEVCMinHeight)
+	}
+	if c.ScaleHeight < 0 || c.ScaleHeight%2 != 0 {
+		return fmt.Errorf("-scale-height must be 0 (source size) or an even height, got %d", c.ScaleHeight)
+	}
+	if (c.Crop != nil || c.OutputWidth > 0) && c.CopyVideo {
+		return fmt.Errorf("-crop and -output-size require re-encoding the video and cannot be combined with -copy-video")
+	}
+	if c.Zoom && c.Crop == nil {
+		return fmt.Errorf("-zoom needs -crop to set the region to zoom into")
+	}
+	if c.ScaleHeight > 0 && c.CopyVideo {
+		return fmt.Errorf("-scale-height requires re-encoding the video and cannot be combined with -copy-video")
+	}
+	if c.SkipExisting && c.InPlace {
+		return fmt.Errorf("-skip-existing compares the output with the input and cannot be combined with -in-place")
+	}
+	if len(c.Renditions) > 0 && (c.CopyVideo || c.ScaleHeight > 0 || len(c.ABRLadder) > 0 || c.MultiGPU || c.Record || c.InPlace || c.Preview > 0) {
+		return fmt.Errorf("-renditions sets the scale of each output and cannot be combined with -copy-video, -scale-height, -abr-ladder, -multi-gpu, -record, -in-place or -preview")
+	}
+	if c.Lossless && slices.ContainsFunc(c.Renditions, func(r OutputRendition) bool { return r.MaxRate != "" }) {
+		return fmt.Errorf("-lossless cannot be combined with rendition bitrates")
+	}
+	if c.OOMDownscaleHeight < 0 || c.OOMDownscaleHeight%2 != 0 {
+		return fmt.Errorf("-oom-downscale must be 0 (disabled) or an even height, got %d", c.OOMDownscaleHeight)
+	}
+	if c.PCRPeriod < 0 {
+		return fmt.Errorf("-pcr-period must be a positive number of milliseconds, got %d", c.PCRPeriod)
+	}
+	if c.Threads < 0 {
+		return fmt.Errorf("-threads must be 0 (auto) or a positive number, got %d", c.Threads)
+	}
+	if c.SAR != "" && c.DAR != "" {
+		return fmt.Errorf("-setsar and -setdar cannot be used together")
+	}
+	for flagName, ratio := range map[string]string{"-setsar": c.SAR, "-setdar": c.DAR} {
+		if ratio != "" && !aspectRatioPattern.MatchString(ratio) {
+			return fmt.Errorf("invalid %s %q: expected a ratio like 16:9, 16/9 or 1.777", flagName, ratio)
+		}
+	}
+	if c.SAR != "" && c.CopyVideo {
+		return fmt.Errorf("-setsar requires re-encoding the video; use -setdar with -copy-video")
+	}
+	if (len(c.StreamTitles) > 0 || len(c.DefaultTracks) > 0) && (c.AudioFile != "" || len(c.ABRLadder) > 0 || c.MultiGPU) {
+		return fmt.Errorf("-stream-title and -default-track cannot be combined with -audio-file, -abr-ladder or -multi-gpu")
+	}
+	defaultKinds := map[string]bool{}
+	for _, track := range c.DefaultTracks {
+		kind, _, _ := ParseStreamSpecifier(track)
+		if defaultKinds[kind] {
+			return fmt.Errorf("-default-track is given twice for %s streams; only one track of each kind can be the default", kind)
+		}
+		defaultKinds[kind] = true
+	}
+	if c.StripMetadata && c.CopyMetadata {
+		return fmt.Errorf("-strip-metadata and -copy-metadata cannot be used together")
+	}
+	if len(c.ABRLadder) > 0 && c.CopyVideo {
+		return fmt.Errorf("-abr-ladder requires re-encoding the video and cannot be combined with -copy-video")
+	}
+	if c.Tonemap && c.CopyVideo {
+		return fmt.Errorf("-tonemap requires re-encoding the video and cannot be combined with -copy-video")
+	}
+	if c.BufSize != "" && c.MaxRate == "" {
+		return fmt.Errorf("-bufsize requires -maxrate")
+	}
+	if c.MaxRate != "" && c.CopyVideo {
+		return fmt.Errorf("-maxrate requires re-encoding the video and cannot be combined with -copy-video")
+	}
+	if c.LimitRate != "" && (c.Lossless || c.Record || c.MultiGPU || len(c.ABRLadder) > 0) {
+		return fmt.Errorf("-limit-rate cannot be combined with -lossless, -record, -multi-gpu or -abr-ladder")
+	}
+	if c.Record && (c.MultiGPU || len(c.ABRLadder) > 0) {
+		return fmt.Errorf("-record cannot be combined with -multi-gpu or -abr-ladder")
+	}
+	if c.AudioFile != "" && c.AudioLang != "" {
+		return fmt.Errorf("-audio-file cannot be combined with -audio-lang")
+	}
+	if c.AudioFile != "" && (c.MultiGPU || len(c.ABRLadder) > 0 || c.Record) {
+		return fmt.Errorf("-audio-file cannot be combined with -multi-gpu, -abr-ladder or -record")
+	}
+	if c.SilentAudio && (c.NoAudio || c.AudioFile != "" || c.AudioCodec == "copy") {
+		return fmt.Errorf("-replace-audio-silence cannot be combined with -no-audio, -audio-file or -audio-codec copy")
+	}
+	if c.SilentAudio && (c.FilterComplex != "" || c.MultiGPU || len(c.ABRLadder) > 0 || c.Record) {
+		return fmt.Errorf("-replace-audio-silence cannot be combined with -filter-complex, -multi-gpu, -abr-ladder or -record")
+	}
+	if c.SilentAudio && c.NoShortest {
+		return fmt.Errorf("-replace-audio-silence ends the silence with the video and cannot be combined with -shortest=false")
+	}
+	if !slices.Contains(WatermarkPositions, c.WatermarkPosition) {
+		return fmt.Errorf("unknown -watermark-position %q (supported: %s)", c.WatermarkPosition, strings.Join(WatermarkPositions, ", "))
+	}
+	if c.Watermark == "" && !c.WatermarkSize.IsZero() {
+		return fmt.Errorf("-watermark-size requires -watermark")
+	}
+	if c.Watermark != "" && (c.CopyVideo || c.FilterComplex != "" || len(c.ABRLadder) > 0) {
+		return fmt.Errorf("-watermark needs its own filter graph and cannot be combined with -copy-video, -filter-complex or -abr-ladder")
+	}
+	if c.AudioMix && c.AudioFile == "" {
+		return fmt.Errorf("-audio-mix needs -audio-file to set the audio to mix in")
+	}
+	if c.AudioChannels != 0 && !slices.Contains(AudioChannelCounts, c.AudioChannels) {
+		return fmt.Errorf("unsupported -audio-channels %d (supported: 1 mono, 2 stereo, 6 for 5.1, 8 for 7.1)", c.AudioChannels)
+	}
+	if c.AudioChannels != 0 && c.AudioPan != "" {
+		return fmt.Errorf("-audio-channels and -audio-pan cannot be used together; the pan layout sets the channel count")
+	}
+	if (c.AudioChannels != 0 || c.AudioPan != "") && c.AudioCodec == "copy" {
+		return fmt.Errorf("-audio-channels and -audio-pan re-encode the audio and cannot be combined with -audio-codec copy")
+	}
+	if c.SegmentTime != "" && c.SegmentSize > 0 {
+		return fmt.Errorf("-segment-time and -segment-size cannot be used together")
+	}
+	if (c.SegmentTime != "" || c.SegmentSize > 0) && (c.MultiGPU || len(c.ABRLadder) > 0 || c.Record || c.InPlace) {
+		return fmt.Errorf("segmented output cannot be combined with -multi-gpu, -abr-ladder, -record or -in-place")
+	}
+	return nil
 }
 
+// AudioChannelCounts are the channel counts -audio-channels accepts
+var AudioChannelCounts = []int{1, 2, 6, 8}
+
 // SetSoftwareEncoding configures the config for software encoding
 func (c *ProcessingConfig) SetSoftwareEncoding() {
 	c.Acceleration = "none"
 	c.Codec = "libx264"
 	c.Preset = "medium"
+	if c.SoftwarePreset != "" && c.SoftwarePreset != SoftwarePresetAuto {
+		c.Preset = c.SoftwarePreset
+	}
 }
 
 // SetHardwareEncoding configures the config for hardware encoding
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration parses a time limit given as seconds ("600"), a Go duration ("10m", "1h30m")
+// or FFmpeg's [HH:]MM:SS[.ms] syntax, returning the number of seconds
+func ParseDuration(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+
+	var seconds float64
+	var err error
+	switch {
+	case strings.Contains(s, ":"):
+		seconds, err = parseClock(s)
+	case strings.IndexFunc(s, func(r rune) bool { return r >= 'a' && r <= 'z' }) >= 0:
+		var d time.Duration
+		d, err = time.ParseDuration(s)
+		seconds = d.Seconds()
+	default:
+		seconds, err = strconv.ParseFloat(s, 64)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: expected seconds, 10m or HH:MM:SS", s)
+	}
+	if seconds <= 0 {
+		return 0, fmt.Errorf("duration must be positive, got %q", s)
+	}
+	return seconds, nil
+}
+
+// parseClock parses [HH:]MM:SS[.ms]
+func parseClock(s string) (float64, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("too many fields")
+	}
+
+	var total float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid field %q", part)
+		}
+		if i < len(parts)-1 && v != float64(int(v)) {
+			return 0, fmt.Errorf("only seconds may be fractional")
+		}
+		total = total*60 + v
+	}
+	return total, nil
+}
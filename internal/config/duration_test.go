@@ -0,0 +1,36 @@
+package config
+
+import "testing"
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "600", want: 600},
+		{in: "1.5", want: 1.5},
+		{in: "10m", want: 600},
+		{in: "1h30m", want: 5400},
+		{in: "10:00", want: 600},
+		{in: "01:00:00.5", want: 3600.5},
+		{in: "0", wantErr: true},
+		{in: "-5", wantErr: true},
+		{in: "1:2:3:4", wantErr: true},
+		{in: "1.5:00", wantErr: true},
+		{in: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDuration(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RegisterFlags binds command-line flags to the config fields
+func (c *ProcessingConfig) RegisterFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.CopyVideo, "copy-video", c.CopyVideo,
+		"copy the video stream unchanged and only re-encode audio")
+	fs.Func("quality-level", "encoder-independent quality from 0 (smallest) to 100 (best), translated to the encoder's CRF/CQ/QP scale (55 matches x264 CRF 23)", func(s string) error {
+		level, err := strconv.Atoi(s)
+		if err != nil || level < 0 || level > 100 {
+			return fmt.Errorf("invalid quality level %q: expected a whole number from 0 to 100", s)
+		}
+		c.QualityLevel = &level
+		return nil
+	})
+	fs.BoolVar(&c.Lossless, "lossless", c.Lossless,
+		"encode mathematically lossless video for masters and intermediates (switches to a software encoder if the GPU has no lossless mode)")
+	fs.StringVar(&c.AudioCodec, "audio-codec", c.AudioCodec,
+		"audio encoder to use, e.g. aac or libopus (default: copy)")
+	fs.StringVar(&c.AudioBitrate, "audio-bitrate", c.AudioBitrate,
+		"audio bitrate when re-encoding audio, e.g. 192k")
+	fs.IntVar(&c.AudioChannels, "audio-channels", c.AudioChannels,
+		"downmix the audio to this many channels: 1, 2, 6 or 8, e.g. 2 for stereo from 5.1 (default: keep the source layout)")
+	fs.StringVar(&c.AudioPan, "audio-pan", c.AudioPan,
+		"custom channel mix as a pan filter, e.g. \"stereo|FL<FL+0.7*FC+0.5*BL|FR<FR+0.7*FC+0.5*BR\"")
+	fs.StringVar(&c.AudioFile, "audio-file", c.AudioFile,
+		"replace the input's audio with this file, e.g. music.mp3 (the output ends with the shorter of the two)")
+	fs.BoolVar(&c.AudioMix, "audio-mix", c.AudioMix,
+		"mix -audio-file into the input's audio instead of replacing it")
+	fs.BoolVar(&c.SilentAudio, "replace-audio-silence", c.SilentAudio,
+		"add a silent audio track (AAC, Opus for WebM) as long as the video when the input has no audio, for players that need one")
+	fs.StringVar(&c.Watermark, "watermark", c.Watermark,
+		"burn this image (e.g. a PNG logo with transparency) into the video")
+	fs.StringVar(&c.WatermarkPosition, "watermark-position", c.WatermarkPosition,
+		"where to place the -watermark: top-left, top-right, bottom-left, bottom-right or center")
+	fs.Func("watermark-size", "-watermark width as a percentage of the output width (10%) or in pixels (200); default: the image's own size", func(s string) error {
+		size, err := ParseWatermarkSize(s)
+		if err != nil {
+			return err
+		}
+		c.WatermarkSize = size
+		return nil
+	})
+	fs.StringVar(&c.FFmpegPath, "ffmpeg-path", c.FFmpegPath,
+		"ffmpeg binary to use, e.g. /usr/lib/jellyfin-ffmpeg/ffmpeg (env: FFMPEG_PATH)")
+	fs.StringVar(&c.HWAccel, "hwaccel", c.HWAccel,
+		"acceleration method: none, cuda, qsv, vaapi, videotoolbox, d3d11va, dxva2, d3d12va (default: auto)")
+	fs.Func("header", `HTTP header for an http(s):// input, e.g. "Authorization: Bearer TOKEN" (repeatable; values are redacted in printed commands)`, func(s string) error {
+		name, value, ok := strings.Cut(s, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") || strings.TrimSpace(value) == "" {
+			return fmt.Errorf(`expected "Key: Value", got %q`, s)
+		}
+		if strings.ContainsAny(s, "\r\n") {
+			return fmt.Errorf("header must be a single line")
+		}
+		c.InputHeaders = append(c.InputHeaders, name+": "+strings.TrimSpace(value))
+		return nil
+	})
+	fs.Func("cookie", `cookie for an http(s):// input, e.g. "session=abc; path=/; domain=cdn.example.com" (repeatable)`, func(s string) error {
+		name, _, ok := strings.Cut(s, "=")
+		if !ok || strings.TrimSpace(name) == "" || strings.ContainsAny(s, "\r\n") {
+			return fmt.Errorf(`expected "name=value; path=/; domain=host", got %q`, s)
+		}
+		c.InputCookies = append(c.InputCookies, strings.TrimSpace(s))
+		return nil
+	})
+	fs.StringVar(&c.OutputDir, "output-dir", c.OutputDir,
+		"write the output to this directory, named after the input (movie.mkv -> DIR/movie.mp4); created if missing. An explicit output wins")
+	fs.StringVar(&c.AMDEncoder, "amd-encoder", c.AMDEncoder,
+		"encoder for AMD GPUs on Linux: vaapi (Mesa), amf (AMD's AMF runtime) or auto (AMF when installed)")
+	fs.BoolVar(&c.SoftwareDecode, "sw-decode", c.SoftwareDecode,
+		"decode on the CPU and only encode on the GPU, for inputs that crash or corrupt the hardware decoder")
+	fs.StringVar(&c.GPUID, "gpu-id", c.GPUID,
+		"GPU to use, by PCI address (01:00.0) or NVIDIA UUID (GPU-...); a plain number is a CUDA device index")
+	fs.BoolVar(&c.RTSPListen, "rtsp-listen", c.RTSPListen,
+		"wait for a client to publish to the rtsp:// input URL instead of connecting to it (RTSP outputs always publish as a client)")
+	fs.BoolVar(&c.Record, "record", c.Record,
+		"capture a live network input (RTSP, RTMP, SRT, HTTP) to a crash-tolerant file, copying streams where possible")
+	fs.Func("start", "start encoding at this input position, as seconds (90), a Go duration (1m30s) or HH:MM:SS", func(s string) error {
+		seconds, err := ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		c.StartTime = strconv.FormatFloat(seconds, 'f', -1, 64)
+		return nil
+	})
+	fs.Func("duration", "stop after this much input, as seconds (600), a Go duration (10m) or HH:MM:SS", func(s string) error {
+		seconds, err := ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		c.Duration = strconv.FormatFloat(seconds, 'f', -1, 64)
+		return nil
+	})
+	fs.Func("frame-at", "save the frame at this input position as an image instead of encoding, e.g. 1:23.5 (output: .png, .jpg, .webp)", func(s string) error {
+		seconds, err := ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		c.FrameAt = strconv.FormatFloat(seconds, 'f', -1, 64)
+		return nil
+	})
+	fs.StringVar(&c.FrameSeek, "frame-seek", c.FrameSeek,
+		"-frame-at seeking: accurate (the exact frame) or fast (the nearest keyframe before it)")
+	fs.Func("max-duration", "stop live inputs (streams, capture devices, -record) after at most this long, e.g. 2h, so the output can't fill the disk", func(s string) error {
+		seconds, err := ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		c.MaxDuration = strconv.FormatFloat(seconds, 'f', -1, 64)
+		return nil
+	})
+	fs.Func("segment-time", "split the output into standalone files of this length (out_000.mp4, out_001.mp4, ...), e.g. 600 or 10m", func(s string) error {
+		seconds, err := ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		c.SegmentTime = strconv.FormatFloat(seconds, 'f', -1, 64)
+		return nil
+	})
+	fs.Func("segment-size", "split the output into standalone files of roughly this size, e.g. 500M or 2G (approximate; based on the predicted bitrate)", func(s string) error {
+		size, err := ParseSize(s)
+		if err != nil {
+			return err
+		}
+		c.SegmentSize = size
+		return nil
+	})
+	fs.DurationVar(&c.GPUCacheTTL, "gpu-cache-ttl", c.GPUCacheTTL,
+		"reuse GPU detection results for this long, e.g. 24h (0 disables; re-detects when GPUs are added or removed)")
+	fs.DurationVar(&c.StallTimeout, "stall-timeout", c.StallTimeout,
+		"stop an encode that reports no progress for this long, e.g. a dead network input (0 disables)")
+	fs.DurationVar(&c.Preview, "preview", c.Preview,
+		"encode and play this much of the input first, e.g. 10s, then confirm or adjust the quality before the full encode")
+	fs.DurationVar(&c.EncoderWait, "wait-for-encoder", c.EncoderWait,
+		"when other processes use up the GPU's NVENC sessions, wait this long (e.g. 5m) for one to free up instead of only warning")
+	fs.BoolVar(&c.MultiGPU, "multi-gpu", c.MultiGPU,
+		"split the input into segments and encode them concurrently on all NVIDIA GPUs (needs at least two)")
+	fs.StringVar(&c.Speed, "speed", c.Speed,
+		"software encoder speed: slow, balanced or fast (maps to -cpu-used, the SVT-AV1 preset, and with -software-preset auto the x264/x265 preset)")
+	fs.StringVar(&c.SoftwarePreset, "software-preset", c.SoftwarePreset,
+		"x264/x265 preset for software encodes and fallbacks, ultrafast to veryslow; auto picks one from the CPU's cores and AVX support and -speed")
+	fs.Float64Var(&c.PlaybackSpeed, "playback-speed", c.PlaybackSpeed,
+		"play the output faster (2 = twice as fast) or in slow motion (0.5), from 0.1 to 10; video and audio stay in sync and the audio is re-encoded")
+	fs.StringVar(&c.CodecOverride, "codec", c.CodecOverride,
+		"video encoder to use instead of the one picked for the detected GPU, e.g. h264_qsv or libx265 (acceleration and preset follow it)")
+	fs.StringVar(&c.CodecFamily, "codec-family", c.CodecFamily,
+		"video codec family: h264, hevc, or auto (HEVC for sources at least -hevc-min-height, H.264 below)")
+	fs.StringVar(&c.ColorRange, "color-range", c.ColorRange,
+		"output color range: tv (limited), pc (full) or auto (keep the source's); converts and tags the output, e.g. to fix washed-out VAAPI encodes")
+	fs.IntVar(&c.HEVCMinHeight, "hevc-min-height", c.HEVCMinHeight,
+		"with -codec-family auto, use HEVC when the source's shorter side is at least this many pixels (2160 = 4K)")
+	fs.Func("crop", "encode only this region of the source, as WIDTH:HEIGHT:X:Y in source pixels", func(s string) error {
+		region, err := ParseRegion(s)
+		if err != nil {
+			return err
+		}
+		c.Crop = &region
+		return nil
+	})
+	fs.Func("output-size", "scale the video to exactly WIDTHxHEIGHT, e.g. 1920x1080 (after -crop when given)", func(s string) error {
+		width, height, err := ParseFrameSize(s)
+		if err != nil {
+			return err
+		}
+		c.OutputWidth, c.OutputHeight = width, height
+		return nil
+	})
+	fs.BoolVar(&c.Zoom, "zoom", c.Zoom,
+		"with -crop, zoom gradually from the full frame into the region over the clip instead of cropping throughout")
+	fs.IntVar(&c.ScaleHeight, "scale-height", c.ScaleHeight,
+		"downscale to at most this height, keeping the aspect ratio (uses scale_cuda on CUDA pipelines)")
+	fs.IntVar(&c.OOMDownscaleHeight, "oom-downscale", c.OOMDownscaleHeight,
+		"when a hardware encode runs out of GPU memory, retry scaled down to this height before using software (0 disables)")
+	fs.IntVar(&c.InitRetries, "init-retries", c.InitRetries,
+		"retry a hardware encode this many times when the GPU fails to initialize transiently, e.g. right after boot (0 disables)")
+	fs.DurationVar(&c.InitRetryDelay, "init-retry-delay", c.InitRetryDelay,
+		"wait between -init-retries attempts")
+	fs.BoolVar(&c.NoCrossVendor, "no-cross-vendor", c.NoCrossVendor,
+		"when a hardware encode fails, go straight to software instead of first trying the other detected GPUs' encoders (e.g. QSV after NVENC)")
+	fs.IntVar(&c.Threads, "threads", c.Threads,
+		"limit CPU threads for software encoding and filters (0 = FFmpeg auto; hardware encoders largely ignore this)")
+	fs.Func("abr-ladder", "HLS adaptive bitrate renditions, e.g. 1920x1080:5000k,1280x720:2800k,854x480:1400k", func(s string) error {
+		ladder, err := ParseLadder(s)
+		if err != nil {
+			return err
+		}
+		c.ABRLadder = ladder
+		return nil
+	})
+	fs.Func("renditions", "encode one file per height, e.g. 1080p,720p, each optionally with its own bitrate cap (1080p:6M,720p:3M)", func(s string) error {
+		renditions, err := ParseRenditions(s)
+		if err != nil {
+			return err
+		}
+		c.Renditions = renditions
+		return nil
+	})
+	fs.Func("maxrate", "cap the bitrate of the quality-targeted encode, e.g. 6M (libx264, NVENC, QSV)", bitrateFlag(&c.MaxRate))
+	fs.Func("bufsize", "rate-control buffer for -maxrate, e.g. 12M (default: twice -maxrate)", bitrateFlag(&c.BufSize))
+	fs.BoolVar(&c.HQTuning, "hq", c.HQTuning,
+		"trade speed for quality on hardware encoders: NVENC multipass, spatial/temporal AQ and lookahead; QSV look-ahead (h264_qsv)")
+	fs.StringVar(&c.NVENCTune, "nvenc-tune", c.NVENCTune,
+		"NVENC tuning: hq, ll (low latency), ull (ultra-low latency) or lossless (default: hq for files, ull for streams)")
+	fs.BoolVar(&c.CheckStream, "check-stream", c.CheckStream,
+		"before encoding to an rtmp://, srt:// or rtsp:// output, test it to catch a wrong URL: an RTMP handshake, or for SRT and RTSP a 1-second test pattern that viewers may briefly see")
+	fs.Func("limit-rate", "pace a network stream to a total bandwidth, e.g. 3M: read the input in real time and cap the video to fit", bitrateFlag(&c.LimitRate))
+	fs.StringVar(&c.ProbeSize, "probesize", c.ProbeSize,
+		"input probe size in bytes (default: 32 for live streams, FFmpeg default for files)")
+	fs.StringVar(&c.AnalyzeDuration, "analyzeduration", c.AnalyzeDuration,
+		"input analyze duration in microseconds (default: 0 for live streams, FFmpeg default for files)")
+	fs.BoolVar(&c.InPlace, "in-place", c.InPlace,
+		"allow the output to be the input file: encode to a temporary file and replace the input on success")
+	fs.BoolVar(&c.KeepOnFailure, "keep-on-failure", c.KeepOnFailure,
+		"keep the partial output of a failed encode or capture (default: remove it when empty or clearly truncated)")
+	fs.StringVar(&c.OverwritePolicy, "overwrite-policy", c.OverwritePolicy,
+		"when the output exists: overwrite, skip, backup (rename to .bak) or error")
+	fs.BoolVar(&c.SkipExisting, "skip-existing", c.SkipExisting,
+		"skip the encode when the output exists and is not older than the input; older outputs follow -overwrite-policy")
+	fs.BoolFunc("faststart", "move the MP4/MOV index to the front for web playback; -faststart=false skips the extra pass (default true)", func(s string) error {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		c.DisableFastStart = !v
+		return nil
+	})
+	fs.BoolFunc("atomic", "encode file outputs to <output>.tmp and rename on success so a partial file never appears under the output name; -atomic=false writes directly; live and capture inputs always are (default true)", func(s string) error {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		c.DisableAtomicWrite = !v
+		return nil
+	})
+	fs.BoolFunc("shortest", "end the output with the shortest input or stream (default on when mixing in an overlay or replacement audio; -shortest=false keeps the longest)", func(s string) error {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		c.Shortest, c.NoShortest = v, !v
+		return nil
+	})
+	fs.StringVar(&c.TSFlags, "mpegts-flags", c.TSFlags,
+		"MPEG-TS muxer flags for TS/SRT/UDP outputs, e.g. +resend_headers+initial_discontinuity")
+	fs.IntVar(&c.PCRPeriod, "pcr-period", c.PCRPeriod,
+		"MPEG-TS PCR interval in milliseconds, e.g. 20 for broadcast contribution (default: FFmpeg's)")
+	fs.StringVar(&c.ServiceName, "service-name", c.ServiceName,
+		"MPEG-TS service name (SDT)")
+	fs.StringVar(&c.ServiceProvider, "service-provider", c.ServiceProvider,
+		"MPEG-TS service provider (SDT)")
+	fs.BoolVar(&c.FlushPackets, "flush-packets", c.FlushPackets,
+		"write MPEG-TS packets immediately instead of buffering (lower latency)")
+	fs.BoolVar(&c.FragmentedMP4, "fragmented-mp4", c.FragmentedMP4,
+		"write fragmented MP4 (automatic for pipe and HTTP MP4 outputs)")
+	fs.StringVar(&c.AudioLang, "audio-lang", c.AudioLang,
+		"keep only the audio stream with this language tag, e.g. jpn (falls back to the first audio stream)")
+	fs.StringVar(&c.SubLang, "sub-lang", c.SubLang,
+		"include the subtitle stream with this language tag, e.g. eng")
+	fs.BoolVar(&c.CopyChapters, "copy-chapters", c.CopyChapters,
+		"preserve chapter markers from the input (MKV/MP4/MOV)")
+	fs.BoolVar(&c.CopyMetadata, "copy-metadata", c.CopyMetadata,
+		"carry over global metadata such as title and tags from the input")
+	fs.IntVar(&c.HLSTime, "hls-time", c.HLSTime,
+		"HLS segment length in seconds; keyframes are aligned to segment boundaries")
+	fs.StringVar(&c.HLSSegmentType, "hls-segment-type", c.HLSSegmentType,
+		"HLS segment container: mpegts (default) or fmp4")
+	fs.BoolVar(&c.HLSSingleFile, "hls-single-file", c.HLSSingleFile,
+		"write each HLS playlist's segments into a single file addressed by byte ranges")
+	fs.BoolVar(&c.HLSVOD, "hls-vod", c.HLSVOD,
+		"mark HLS playlists as complete VOD playlists (EXT-X-PLAYLIST-TYPE:VOD)")
+	fs.IntVar(&c.GOPSize, "gop", c.GOPSize,
+		"keyframe interval in frames (default: encoder default, or one HLS segment's worth for HLS output)")
+	fs.BoolVar(&c.NoSceneCut, "no-scenecut", c.NoSceneCut,
+		"don't add keyframes at scene changes, so GOPs are exactly -gop frames; HLS boundaries are keyframes either way, this also keeps every GOP the same length")
+	fs.BoolVar(&c.ClosedGOP, "closed-gop", c.ClosedGOP,
+		"broadcast closed GOPs: -flags +cgop (strict GOPs on NVENC, IDR at every I-frame on QSV) with scene cuts off, every -gop frames; for spec-compliant TS use a .ts output with -gop, -fps and -cfr, e.g. -gop 50 -fps 25 -cfr")
+	fs.Func("filter-complex", "raw FFmpeg filter graph replacing the generated video filters (scale, crop, tonemap...); leave the final output unlabeled or end with [label]. Frames reach it in system memory, so end with format=nv12,hwupload for VAAPI", func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("empty filter graph")
+		}
+		c.FilterComplex = s
+		return nil
+	})
+	fs.Func("fps", "output frame rate, e.g. 30, 29.97, 30000/1001 or ntsc (default: keep the source rate)", func(s string) error {
+		if _, err := ParseFrameRate(s); err != nil {
+			return err
+		}
+		c.FrameRate = s
+		return nil
+	})
+	fs.BoolVar(&c.CFR, "cfr", c.CFR,
+		"force a strictly constant frame rate (at -fps, or the source's nominal rate) by dropping or duplicating frames, for editors and players that break on VFR")
+	fs.BoolVar(&c.CopyData, "copy-data", c.CopyData,
+		"copy data streams such as KLV or timed metadata from the input (MPEG-TS output)")
+	fs.StringVar(&c.SAR, "setsar", c.SAR,
+		"force the sample aspect ratio, e.g. 1:1 to make pixels square (default: passthrough)")
+	fs.StringVar(&c.DAR, "setdar", c.DAR,
+		"force the display aspect ratio, e.g. 16:9 (default: passthrough)")
+	fs.Func("metadata", "set an output metadata tag as key=value, e.g. title=\"My Video\" (repeatable)", func(s string) error {
+		key, _, ok := strings.Cut(s, "=")
+		if !ok || strings.TrimSpace(key) == "" || strings.ContainsAny(key, " \t") {
+			return fmt.Errorf("expected key=value, got %q", s)
+		}
+		c.Metadata = append(c.Metadata, s)
+		return nil
+	})
+	fs.Func("stream-title", "set an output track's title as stream=title, e.g. a:1=\"Director's Commentary\" (repeatable)", func(s string) error {
+		spec, _, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("expected stream=title, e.g. a:0=English, got %q", s)
+		}
+		if _, _, err := ParseStreamSpecifier(spec); err != nil {
+			return err
+		}
+		c.StreamTitles = append(c.StreamTitles, s)
+		return nil
+	})
+	fs.Func("default-track", "mark an output track as the default of its kind, e.g. a:1 or s:0, clearing the flag on the others (repeatable)", func(s string) error {
+		if _, _, err := ParseStreamSpecifier(s); err != nil {
+			return err
+		}
+		c.DefaultTracks = append(c.DefaultTracks, strings.TrimSpace(s))
+		return nil
+	})
+	fs.BoolVar(&c.StripMetadata, "strip-metadata", c.StripMetadata,
+		"remove all metadata from the input before applying -metadata tags")
+	fs.BoolVar(&c.Tonemap, "tonemap", c.Tonemap,
+		"tone-map HDR10 input to SDR Rec.709 (CPU filter chain, requires zscale)")
+	fs.BoolVar(&c.ProgressJSON, "progress-json", c.ProgressJSON,
+		"emit one JSON progress object per update (human-readable output moves to stderr)")
+	fs.IntVar(&c.ProgressFD, "progress-fd", c.ProgressFD,
+		"file descriptor for -progress-json output")
+	fs.BoolVar(&c.ErrorJSON, "error-json", c.ErrorJSON,
+		"on failure, also write a JSON object with the error class, message, FFmpeg exit code and stderr excerpt")
+	fs.IntVar(&c.ErrorFD, "error-fd", c.ErrorFD,
+		"file descriptor for -error-json output")
+	fs.StringVar(&c.ReportPath, "report", c.ReportPath,
+		"write a JSON summary of each job (status, codec, size, fps, fallback, error) to this file")
+	fs.StringVar(&c.Notify, "notify", c.Notify,
+		"when finished, run this shell command (JSON summary on stdin, VP_STATUS/VP_INPUT/VP_OUTPUT/VP_ERROR in its environment) or POST the summary to this http(s):// webhook")
+	fs.BoolVar(&c.SaveCommand, "save-command", c.SaveCommand,
+		"write the FFmpeg command (credentials redacted) to <output>.cmd for reproducing the encode")
+	fs.BoolVar(&c.PreserveTimestamps, "preserve-timestamps", c.PreserveTimestamps,
+		"set the output's modification time to match the input (file outputs only)")
+	fs.BoolVar(&c.ResumePlayback, "resume-playback", c.ResumePlayback,
+		"remember where playback of each output stopped and resume there next time (positions are saved from ffplay)")
+	fs.BoolVar(&c.PlayDetached, "play-detached", c.PlayDetached,
+		"start the player and exit without waiting for playback to finish (previews still wait)")
+	fs.BoolVar(&c.ASCII, "ascii", c.ASCII,
+		"print plain ASCII markers instead of emoji (also enabled by NO_EMOJI or a non-UTF-8 terminal)")
+}
+
+// bitrateFlag returns a flag setter that accepts FFmpeg-style bitrates such as 6M or 4500k
+func bitrateFlag(dst *string) func(string) error {
+	return func(s string) error {
+		if ParseBitrate(s) <= 0 {
+			return fmt.Errorf("invalid bitrate %q: expected e.g. 6M or 4500k", s)
+		}
+		*dst = s
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// frameRateAbbreviations are the named rates FFmpeg accepts for -r
+var frameRateAbbreviations = map[string]float64{
+	"ntsc":      30000.0 / 1001,
+	"pal":       25,
+	"film":      24,
+	"ntsc-film": 24000.0 / 1001,
+}
+
+// ParseFrameRate parses a frame rate given as a number ("30", "29.97"), a fraction
+// ("30000/1001") or one of FFmpeg's names (ntsc, pal, film, ntsc-film), returning frames per second
+func ParseFrameRate(s string) (float64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if fps, ok := frameRateAbbreviations[s]; ok {
+		return fps, nil
+	}
+
+	num, den, isFraction := strings.Cut(s, "/")
+	fps, err := strconv.ParseFloat(num, 64)
+	if err == nil && isFraction {
+		var d float64
+		d, err = strconv.ParseFloat(den, 64)
+		if err == nil && d <= 0 {
+			err = fmt.Errorf("zero denominator")
+		}
+		fps /= d
+	}
+	if err != nil || fps <= 0 || fps > 1000 {
+		return 0, fmt.Errorf("invalid frame rate %q: expected e.g. 30, 29.97, 30000/1001 or ntsc", s)
+	}
+	return fps, nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseFrameRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{input: "30", want: 30},
+		{input: "29.97", want: 29.97},
+		{input: "30000/1001", want: 30000.0 / 1001},
+		{input: "NTSC", want: 30000.0 / 1001},
+		{input: "pal", want: 25},
+		{input: "0", wantErr: true},
+		{input: "-25", wantErr: true},
+		{input: "30/0", wantErr: true},
+		{input: "fast", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFrameRate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFrameRate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ParseFrameRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rendition is one variant of an adaptive bitrate ladder
+type Rendition struct {
+	Width   int
+	Height  int
+	Bitrate string // FFmpeg bitrate, e.g. "5000k"
+}
+
+// String formats the rendition the way it is written on the command line
+func (r Rendition) String() string {
+	return fmt.Sprintf("%dx%d:%s", r.Width, r.Height, r.Bitrate)
+}
+
+// ParseLadder parses a comma-separated ABR ladder such as
+// "1920x1080:5000k,1280x720:2800k,854x480:1400k"
+func ParseLadder(s string) ([]Rendition, error) {
+	var ladder []Rendition
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		size, bitrate, ok := strings.Cut(entry, ":")
+		if !ok || bitrate == "" {
+			return nil, fmt.Errorf("rendition %q: expected WIDTHxHEIGHT:BITRATE", entry)
+		}
+
+		w, h, ok := strings.Cut(strings.ToLower(size), "x")
+		if !ok {
+			return nil, fmt.Errorf("rendition %q: expected WIDTHxHEIGHT:BITRATE", entry)
+		}
+		width, errW := strconv.Atoi(w)
+		height, errH := strconv.Atoi(h)
+		if errW != nil || errH != nil || width <= 0 || height <= 0 {
+			return nil, fmt.Errorf("rendition %q: invalid resolution", entry)
+		}
+		if width%2 != 0 || height%2 != 0 {
+			return nil, fmt.Errorf("rendition %q: width and height must be even", entry)
+		}
+
+		ladder = append(ladder, Rendition{Width: width, Height: height, Bitrate: bitrate})
+	}
+
+	if len(ladder) == 0 {
+		return nil, fmt.Errorf("ABR ladder is empty")
+	}
+	return ladder, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Region is a rectangle of the source frame, in pixels from the top-left corner
+type Region struct {
+	Width  int
+	Height int
+	X      int
+	Y      int
+}
+
+// String formats the region in FFmpeg crop order, W:H:X:Y
+func (r Region) String() string {
+	return fmt.Sprintf("%d:%d:%d:%d", r.Width, r.Height, r.X, r.Y)
+}
+
+// ParseRegion parses a region given in FFmpeg crop order, e.g. "1280:720:640:360"
+func ParseRegion(s string) (Region, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 4 {
+		return Region{}, fmt.Errorf("invalid region %q: expected WIDTH:HEIGHT:X:Y", s)
+	}
+
+	var values [4]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || v < 0 {
+			return Region{}, fmt.Errorf("invalid region %q: expected non-negative integers WIDTH:HEIGHT:X:Y", s)
+		}
+		values[i] = v
+	}
+
+	r := Region{Width: values[0], Height: values[1], X: values[2], Y: values[3]}
+	if r.Width == 0 || r.Height == 0 {
+		return Region{}, fmt.Errorf("invalid region %q: width and height must be positive", s)
+	}
+	return r, nil
+}
+
+// ParseFrameSize parses a frame size such as "1920x1080"
+func ParseFrameSize(s string) (int, int, error) {
+	w, h, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if !ok || errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid size %q: expected WIDTHxHEIGHT", s)
+	}
+	if width%2 != 0 || height%2 != 0 {
+		return 0, 0, fmt.Errorf("invalid size %q: width and height must be even", s)
+	}
+	return width, height, nil
+}
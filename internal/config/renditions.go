@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OutputRendition is one file of a -renditions batch: the input scaled to Height, with an
+// optional bitrate cap
+type OutputRendition struct {
+	Height  int
+	MaxRate string // FFmpeg bitrate, e.g. "5M"; "" keeps the quality-targeted encode uncapped
+}
+
+// Name labels the rendition in file names and output, e.g. "720p"
+func (r OutputRendition) Name() string {
+	return fmt.Sprintf("%dp", r.Height)
+}
+
+// ParseRenditions parses a comma-separated list of output heights, each optionally with a
+// bitrate cap, such as "1080p,720p" or "1080p:6M,720p:3M"
+func ParseRenditions(s string) ([]OutputRendition, error) {
+	var renditions []OutputRendition
+	seen := map[int]bool{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		size, bitrate, hasBitrate := strings.Cut(entry, ":")
+		height, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(size), "p"))
+		if err != nil || height <= 0 {
+			return nil, fmt.Errorf("rendition %q: expected a height such as 720p, optionally with a bitrate (720p:3M)", entry)
+		}
+		if height%2 != 0 {
+			return nil, fmt.Errorf("rendition %q: height must be even", entry)
+		}
+		if hasBitrate && ParseBitrate(bitrate) <= 0 {
+			return nil, fmt.Errorf("rendition %q: invalid bitrate %q", entry, bitrate)
+		}
+		if seen[height] {
+			return nil, fmt.Errorf("rendition %dp is listed twice", height)
+		}
+		seen[height] = true
+
+		renditions = append(renditions, OutputRendition{Height: height, MaxRate: bitrate})
+	}
+
+	if len(renditions) == 0 {
+		return nil, fmt.Errorf("no renditions given")
+	}
+	return renditions, nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseRenditions(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []OutputRendition
+		wantErr bool
+	}{
+		{input: "1080p,720p", want: []OutputRendition{{Height: 1080}, {Height: 720}}},
+		{input: "1080p:6M, 720P:3000k", want: []OutputRendition{{Height: 1080, MaxRate: "6M"}, {Height: 720, MaxRate: "3000k"}}},
+		{input: "480", want: []OutputRendition{{Height: 480}}},
+		{input: "720p,", want: []OutputRendition{{Height: 720}}},
+		{input: "", wantErr: true},
+		{input: "hd", wantErr: true},
+		{input: "0p", wantErr: true},
+		{input: "721p", wantErr: true},
+		{input: "720p:fast", wantErr: true},
+		{input: "720p,720p:3M", wantErr: true},
+		{input: "1280x720", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRenditions(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRenditions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseRenditions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseSize parses a file size such as "500M", "2G", "700MB" or "1048576" into bytes.
+// Suffixes are binary (1K = 1024 bytes), matching how file managers and upload limits report sizes.
+func ParseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	number := strings.TrimSuffix(strings.TrimSuffix(upper, "B"), "I")
+
+	multiplier := 1.0
+	if number != "" {
+		switch number[len(number)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+	}
+	if multiplier != 1 {
+		number = number[:len(number)-1]
+	}
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid size %q: expected a positive size like 500M or 2G", s)
+	}
+	return int64(v * multiplier), nil
+}
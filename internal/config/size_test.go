@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "1048576", want: 1 << 20},
+		{in: "500M", want: 500 << 20},
+		{in: "700MB", want: 700 << 20},
+		{in: "2g", want: 2 << 30},
+		{in: "1.5G", want: 3 << 29},
+		{in: "64KiB", want: 64 << 10},
+		{in: "0", wantErr: true},
+		{in: "M", wantErr: true},
+		{in: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSize(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseStreamSpecifier parses an output stream specifier such as "a:1" (the second audio
+// track) into its stream kind and index within that kind
+func ParseStreamSpecifier(s string) (string, int, error) {
+	kind, index, ok := strings.Cut(strings.TrimSpace(s), ":")
+	n, err := strconv.Atoi(index)
+	if !ok || err != nil || n < 0 || (kind != "v" && kind != "a" && kind != "s") {
+		return "", 0, fmt.Errorf("invalid stream %q: expected v, a or s and an index, e.g. a:0", s)
+	}
+	return kind, n, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import "testing"
+
+func TestParseStreamSpecifier(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantKind  string
+		wantIndex int
+		wantErr   bool
+	}{
+		{input: "a:0", wantKind: "a", wantIndex: 0},
+		{input: "s:12", wantKind: "s", wantIndex: 12},
+		{input: " v:1 ", wantKind: "v", wantIndex: 1},
+		{input: "a", wantErr: true},
+		{input: "a:-1", wantErr: true},
+		{input: "d:0", wantErr: true},
+		{input: "a:first", wantErr: true},
+		{input: "0:a:1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			kind, index, err := ParseStreamSpecifier(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStreamSpecifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (kind != tt.wantKind || index != tt.wantIndex) {
+				t.Errorf("ParseStreamSpecifier() = %s:%d, want %s:%d", kind, index, tt.wantKind, tt.wantIndex)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Watermark positions: the corner or center of the output frame the image is placed in
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// WatermarkPositions lists the valid WatermarkPosition values
+var WatermarkPositions = []string{WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight, WatermarkCenter}
+
+// WatermarkSize is the width of a -watermark image, either a percentage of the output
+// frame's width or a fixed number of pixels; the height follows the image's aspect ratio.
+// The zero value keeps the image's own size.
+type WatermarkSize struct {
+	Percent float64
+	Pixels  int
+}
+
+// IsZero reports whether no size was given
+func (s WatermarkSize) IsZero() bool {
+	return s.Percent == 0 && s.Pixels == 0
+}
+
+// ParseWatermarkSize parses a watermark width: "10%" of the output width or "200" pixels
+func ParseWatermarkSize(s string) (WatermarkSize, error) {
+	s = strings.TrimSpace(s)
+	if number, ok := strings.CutSuffix(s, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return WatermarkSize{}, fmt.Errorf("invalid watermark size %q: a percentage must be above 0 and at most 100", s)
+		}
+		return WatermarkSize{Percent: percent}, nil
+	}
+
+	pixels, err := strconv.Atoi(strings.TrimSuffix(s, "px"))
+	if err != nil || pixels <= 0 {
+		return WatermarkSize{}, fmt.Errorf("invalid watermark size %q: expected a percentage of the output width like 10%% or a width in pixels like 200", s)
+	}
+	return WatermarkSize{Pixels: pixels}, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestParseWatermarkSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    WatermarkSize
+		wantErr bool
+	}{
+		{in: "10%", want: WatermarkSize{Percent: 10}},
+		{in: "12.5%", want: WatermarkSize{Percent: 12.5}},
+		{in: "200", want: WatermarkSize{Pixels: 200}},
+		{in: "200px", want: WatermarkSize{Pixels: 200}},
+		{in: "0%", wantErr: true},
+		{in: "150%", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "-20", wantErr: true},
+		{in: "wide", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseWatermarkSize(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseWatermarkSize(%q) = %+v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseWatermarkSize(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package encoder
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// buildABRCommand builds a single FFmpeg command producing every rendition of the ABR ladder
+// as its own HLS variant playlist plus a master playlist at the configured output path.
+// Scaling runs on the CPU, so decoded frames are kept in system memory (see keepFramesOnGPU).
+func (cb *CommandBuilder) buildABRCommand(config *config.ProcessingConfig) []string {
+	ladder := config.ABRLadder
+	var args []string
+
+	args = cb.addHardwareAcceleration(args, config)
+
+	args = cb.addInputProbing(args, config)
+	args = append(args, InputArgs(config)...)
+
+	args = append(args, "-filter_complex", cb.abrFilterGraph(config))
+
+	for i, r := range ladder {
+		args = append(args, "-map", fmt.Sprintf("[v%dout]", i))
+		args = append(args,
+			fmt.Sprintf("-b:v:%d", i), r.Bitrate,
+			fmt.Sprintf("-maxrate:v:%d", i), r.Bitrate,
+			fmt.Sprintf("-bufsize:v:%d", i), r.Bitrate,
+		)
+	}
+	args = append(args, "-c:v", config.Codec)
+	switch config.Codec {
+	case "h264_nvenc", "h264_qsv", "libx264", "hevc_nvenc", "hevc_qsv", "libx265":
+		args = append(args, "-preset", config.Preset)
+	}
+	args = cb.addFrameRateOptions(args, config)
+	// Every rendition gets the same keyframes so players can switch at segment boundaries
+	args = cb.addGOPOptions(args, config)
+	if config.ClosedGOP {
+		args = append(args, "-flags", closedGOPFlag)
+	}
+	args = cb.addHLSKeyframes(args, config)
+
+	streamMap := make([]string, len(ladder))
+	for i := range ladder {
+		streamMap[i] = fmt.Sprintf("v:%d", i)
+		if !config.NoAudio {
+			args = append(args, "-map", abrAudioMap(config))
+			streamMap[i] += fmt.Sprintf(",a:%d", i)
+		}
+	}
+	if !config.NoAudio {
+		args = cb.AddAudioEncoding(args, config)
+	}
+
+	dir := filepath.Dir(config.OutputPath)
+	segments := "stream_%v_%03d" + hlsSegmentExtension(config)
+	if config.HLSSingleFile {
+		segments = "stream_%v" + hlsSegmentExtension(config)
+	}
+	// The same muxer settings as a single-rendition HLS output
+	args = append(args, OutputFormatArgsFor(config)...)
+	if config.HLSSegmentType == "fmp4" {
+		// Each variant needs its own initialization segment
+		args = append(args, "-hls_fmp4_init_filename", "init_%v.mp4")
+	}
+	args = append(args,
+		"-hls_segment_filename", filepath.Join(dir, segments),
+		"-master_pl_name", filepath.Base(config.OutputPath),
+		"-var_stream_map", strings.Join(streamMap, " "),
+		"-y", filepath.Join(dir, "stream_%v.m3u8"),
+	)
+	return args
+}
+
+// abrAudioMap returns the audio stream every rendition carries: the one -audio-lang or the
+// other stream selection resolved to, otherwise the first
+func abrAudioMap(config *config.ProcessingConfig) string {
+	for _, m := range config.Maps {
+		if strings.HasPrefix(m, "0:a:") {
+			return strings.TrimSuffix(m, "?") + "?"
+		}
+	}
+	return "0:a:0?"
+}
+
+// abrFilterGraph splits the decoded video once per rendition and scales each branch
+func (cb *CommandBuilder) abrFilterGraph(config *config.ProcessingConfig) string {
+	ladder := config.ABRLadder
+
+	pre := cb.videoFilters(config)
+	pre = append(pre, fmt.Sprintf("split=%d", len(ladder)))
+
+	var graph strings.Builder
+	graph.WriteString("[0:v]" + strings.Join(pre, ","))
+	for i := range ladder {
+		fmt.Fprintf(&graph, "[v%d]", i)
+	}
+
+	for i, r := range ladder {
+		branch := fmt.Sprintf("scale=w=%d:h=%d", r.Width, r.Height)
+		if strings.HasSuffix(config.Codec, "_vaapi") {
+			branch += ",format=nv12,hwupload"
+		}
+		fmt.Fprintf(&graph, ";[v%d]%s[v%dout]", i, branch, i)
+	}
+	return graph.String()
+}
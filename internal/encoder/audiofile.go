@@ -0,0 +1,42 @@
+package encoder
+
+import (
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// addAudioFileMapping maps the source video with the -audio-file input's audio, either in
+// place of the source audio or mixed with it through amix. Other explicit selections, such
+// as subtitles or data streams, are kept.
+func (cb *CommandBuilder) addAudioFileMapping(args []string, cfg *config.ProcessingConfig) []string {
+	video := "0:v:0"
+	if cfg.Watermark != "" {
+		video = watermarkLabel
+	}
+	args = append(args, "-map", video)
+	if cfg.AudioMix {
+		// duration=first keeps the source's length; with -shortest=false the longer input wins
+		duration := "first"
+		if !EndAtShortest(cfg, true) {
+			duration = "longest"
+		}
+		graph := "[0:a:0][1:a:0]amix=inputs=2:duration=" + duration + ":dropout_transition=0"
+		if cfg.AudioPan != "" {
+			graph += ",pan=" + cfg.AudioPan
+		}
+		args = append(args,
+			"-filter_complex", graph+"[aout]",
+			"-map", "[aout]",
+		)
+	} else {
+		args = append(args, "-map", "1:a:0")
+	}
+
+	for _, m := range cfg.Maps {
+		if !strings.HasPrefix(m, "0:v") && !strings.HasPrefix(m, "0:a") {
+			args = append(args, "-map", m)
+		}
+	}
+	return args
+}
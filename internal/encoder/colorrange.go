@@ -0,0 +1,31 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// outputColorRange returns the range the output is encoded and tagged with, or "" when
+// -color-range is unset or auto couldn't be resolved
+func outputColorRange(cfg *config.ProcessingConfig) string {
+	if cfg.ColorRange == config.ColorRangeAuto {
+		return cfg.SourceColorRange
+	}
+	return cfg.ColorRange
+}
+
+// colorRangeFilter returns the scale filter converting the frames to the output range, or ""
+// when none is needed. VAAPI gets it even when the ranges match: its format=nv12 conversion
+// would otherwise squeeze full-range sources to limited range while they stay tagged full.
+func colorRangeFilter(cfg *config.ProcessingConfig) string {
+	in, out := cfg.SourceColorRange, outputColorRange(cfg)
+	if cfg.Tonemap {
+		in = config.ColorRangeTV // the tone-mapping chain outputs limited range
+	}
+	if in == "" || out == "" || (in == out && !strings.HasSuffix(cfg.Codec, "_vaapi")) {
+		return ""
+	}
+	return fmt.Sprintf("scale=in_range=%s:out_range=%s", in, out)
+}
@@ -2,8 +2,10 @@ package encoder
 
 import (
 	"fmt"
-	"path/filepath"
+	"net/url"
+	"strconv"
 	"strings"
+	"video_processing/internal/capture"
 	"video_processing/internal/config"
 )
 
@@ -17,49 +19,221 @@ func NewCommandBuilder() *CommandBuilder {
 
 // BuildFFmpegCommand builds the complete FFmpeg command arguments
 func (cb *CommandBuilder) BuildFFmpegCommand(config *config.ProcessingConfig) []string {
+	if len(config.ABRLadder) > 0 && cb.OutputFormat(config.OutputPath) == "hls" {
+		return cb.buildABRCommand(config)
+	}
+
 	var args []string
 
-	// Hardware acceleration setup
-	args = cb.addHardwareAcceleration(args, config.Acceleration)
+	// Hardware acceleration setup (not needed when the video is copied)
+	if !config.CopyVideo {
+		args = cb.addHardwareAcceleration(args, config)
+	}
 
-	// Input
-	args = append(args, "-i", config.InputPath)
+	// Inputs; -t must follow the last one to limit the output rather than an input
+	args = cb.addInputs(args, config)
+	if config.Duration != "" {
+		args = append(args, "-t", config.Duration)
+	}
+
+	// Explicit stream selection
+	if config.AudioFile != "" {
+		args = cb.addAudioFileMapping(args, config)
+	} else {
+		maps := config.Maps
+		switch {
+		case config.FilterComplex != "":
+			maps = filterComplexMaps(config)
+		case config.Watermark != "":
+			maps = graphOutputMaps(watermarkLabel, config)
+		}
+		if config.AddSilence {
+			maps = cb.silentAudioMaps(maps, config)
+		}
+		for _, m := range maps {
+			args = append(args, "-map", m)
+		}
+	}
 
 	// Video encoding
-	args = cb.addVideoEncoding(args, config)
+	if config.CopyVideo {
+		args = append(args, "-c:v", "copy")
+		if config.DAR != "" {
+			// Filters can't run on copied video, so set the aspect at the container level
+			args = append(args, "-aspect", config.DAR)
+		}
+	} else {
+		args = cb.addVideoEncoding(args, config)
+	}
 
-	// Audio (copy without re-encoding)
-	args = append(args, "-c:a", "copy")
+	// Audio (copy unless an audio codec was requested)
+	args = cb.AddAudioEncoding(args, config)
+
+	if config.SubtitleCodec != "" {
+		args = append(args, "-c:s", config.SubtitleCodec)
+	}
+	if config.CopyData {
+		args = append(args, "-c:d", "copy")
+	}
+
+	// FFmpeg already takes metadata and chapters from the source when it is the only input;
+	// with an audio file, watermark or silent track they could come from that input instead
+	multipleInputs := len(cb.extraInputs(config)) > 0 || config.AddSilence
+	if config.CopyMetadata && multipleInputs {
+		args = append(args, "-map_metadata", "0")
+	}
+	if config.StripMetadata {
+		args = append(args, "-map_metadata", "-1")
+	}
+	for _, tag := range config.Metadata {
+		args = append(args, "-metadata", tag)
+	}
+	for _, title := range config.StreamTitles {
+		spec, value, _ := strings.Cut(title, "=")
+		args = append(args, "-metadata:s:"+strings.TrimSpace(spec), "title="+value)
+	}
+	for _, disposition := range config.Dispositions {
+		spec, value, _ := strings.Cut(disposition, "=")
+		args = append(args, "-disposition:"+spec, value)
+	}
+	if config.CopyChapters && multipleInputs {
+		args = append(args, "-map_chapters", "0")
+	}
+	// The silent track is endless, so it always ends with the video
+	if EndAtShortest(config, len(cb.extraInputs(config)) > 0) || config.AddSilence {
+		args = append(args, "-shortest")
+	}
 
-	// Output format based on URL/path
-	args = cb.addOutputFormat(args, config.OutputPath)
+	// Output format based on URL/path, or numbered standalone files when segmenting
+	output := config.OutputPath
+	if config.SegmentTime != "" {
+		args = cb.addSegmentOutput(args, config)
+		output = SegmentPattern(config.OutputPath)
+	} else {
+		args = append(args, OutputFormatArgsFor(config)...)
 
-	// Output options
-	args = append(args, "-movflags", "+faststart") // Web optimization
-	args = append(args, "-bf", "0")
+		// Output options
+		if isRTSP(config.OutputPath) {
+			args = append(args, rtspOutputOptions(config)...)
+		}
+		if cb.OutputFormat(config.OutputPath) == "mpegts" {
+			args = cb.addMPEGTSOptions(args, config)
+		}
+		if movFlags := cb.movFlags(config); movFlags != "" {
+			args = append(args, "-movflags", movFlags) // Web optimization or fragmentation
+		}
+	}
+	if !config.CopyVideo {
+		args = append(args, "-bf", "0")
+	}
 	args = append(args, "-fflags", "nobuffer")
-	args = append(args, "-flags", "low_delay")
+	if config.ClosedGOP && !config.CopyVideo {
+		args = append(args, "-flags", "low_delay"+closedGOPFlag)
+	} else {
+		args = append(args, "-flags", "low_delay")
+	}
 	args = append(args, "-fflags", "+discardcorrupt")
-	args = append(args, "-analyzeduration", "0")
-	args = append(args, "-probesize", "32")
-	args = append(args, "-tune", "zerolatency")
+	if !config.CopyVideo {
+		args = cb.addTuning(args, config)
+	}
+
+	// Existing outputs are handled by the processor per the overwrite policy;
+	// -n is kept for skip as a safeguard against races
+	if config.OverwritePolicy == "skip" {
+		args = append(args, "-n")
+	} else {
+		args = append(args, "-y") // Overwrite output
+	}
+	args = append(args, output)
+
+	return args
+}
+
+// addInputs adds the source input, preceded by its probing, network and seek options, followed
+// by any extra inputs. The source is always input 0; extra inputs are numbered from 1 in
+// the order extraInputs returns them, and the silent audio source comes last.
+func (cb *CommandBuilder) addInputs(args []string, config *config.ProcessingConfig) []string {
+	// Input probing (must precede -i to apply to the input)
+	args = cb.addInputProbing(args, config)
+
+	// RTSP client/server role and timeouts, or reconnection so a recording survives network hiccups
+	if isRTSP(config.InputPath) && (config.Record || config.RTSPListen) {
+		args = append(args, rtspInputOptions(config)...)
+	} else if config.Record {
+		args = cb.addReconnectOptions(args, config.InputPath)
+	}
 
-	args = append(args, "-y") // Overwrite output
-	args = append(args, config.OutputPath)
+	// Input seeking (before -i for fast, accurate seeks when re-encoding)
+	if config.StartTime != "" {
+		args = append(args, "-ss", config.StartTime)
+	}
 
+	// Read files at their native frame rate so a rate-limited stream is paced rather than
+	// sent as fast as it encodes; live inputs already arrive in real time
+	if config.LimitRate != "" && !cb.IsLiveInput(config.InputPath) {
+		args = append(args, "-re")
+	}
+
+	args = append(args, InputArgs(config)...)
+
+	for _, input := range cb.extraInputs(config) {
+		args = append(args, "-i", input)
+	}
+	if config.AddSilence {
+		args = append(args, "-f", "lavfi", "-i", silentAudioSource)
+	}
 	return args
 }
 
-func (cb *CommandBuilder) addHardwareAcceleration(args []string, acceleration string) []string {
-	switch acceleration {
+// extraInputs returns the inputs added after the source, in input-index order
+func (cb *CommandBuilder) extraInputs(config *config.ProcessingConfig) []string {
+	var inputs []string
+	if config.AudioFile != "" {
+		inputs = append(inputs, config.AudioFile)
+	}
+	if config.Watermark != "" {
+		inputs = append(inputs, config.Watermark)
+	}
+	return inputs
+}
+
+// OutputFormat returns the FFmpeg muxer name that will be used for the output path
+func (cb *CommandBuilder) OutputFormat(outputPath string) string {
+	return OutputFormatArgs(outputPath)[1]
+}
+
+func (cb *CommandBuilder) addHardwareAcceleration(args []string, config *config.ProcessingConfig) []string {
+	gpuFrames := cb.keepFramesOnGPU(config)
+
+	if config.SoftwareDecode {
+		// Decode on the CPU; VAAPI still needs its device for hwupload before the encoder
+		if config.Acceleration == "vaapi" {
+			args = append(args, "-init_hw_device", "vaapi=va:"+renderNode(config))
+			args = append(args, "-filter_hw_device", "va")
+		}
+		return args
+	}
+
+	switch config.Acceleration {
 	case "cuda":
-		args = append(args, "-hwaccel", "cuda", "-hwaccel_output_format", "cuda")
+		args = append(args, "-hwaccel", "cuda")
+		if config.GPUDevice != "" {
+			args = append(args, "-hwaccel_device", config.GPUDevice)
+		}
+		if gpuFrames {
+			args = append(args, "-hwaccel_output_format", "cuda")
+		}
 	case "qsv":
+		if config.RenderNode != "" {
+			args = append(args, "-qsv_device", config.RenderNode)
+		}
 		args = append(args, "-hwaccel", "qsv")
 	case "vaapi":
-		args = append(args, "-init_hw_device", "vaapi=va:/dev/dri/renderD128")
+		args = append(args, "-init_hw_device", "vaapi=va:"+renderNode(config))
 		args = append(args, "-filter_hw_device", "va")
-		args = append(args, "-hwaccel_output_format", "vaapi")
+		if gpuFrames {
+			args = append(args, "-hwaccel_output_format", "vaapi")
+		}
 		// args = append(args, "-hwaccel", "vaapi")
 		// args = append(args, "-hwaccel_device", "/dev/dri/renderD128")
 		// args = append(args, "-hwaccel_output_format", "vaapi")
@@ -67,123 +241,452 @@ func (cb *CommandBuilder) addHardwareAcceleration(args []string, acceleration st
 		args = append(args, "-hwaccel", "videotoolbox")
 	case "d3d11va":
 		args = append(args, "-hwaccel", "d3d11va")
+	case "dxva2":
+		args = append(args, "-hwaccel", "dxva2")
+	case "d3d12va":
+		args = append(args, "-hwaccel", "d3d12va")
+	case "amf":
+		// AMF on Linux has no decoder of its own; frames are decoded on the CPU and the
+		// encoder uploads them
 	}
 	return args
 }
 
+// renderNode returns the DRM render node VAAPI opens: the selected GPU's, or the first one
+func renderNode(config *config.ProcessingConfig) string {
+	if config.RenderNode != "" {
+		return config.RenderNode
+	}
+	return "/dev/dri/renderD128"
+}
+
+// keepFramesOnGPU reports whether decoded frames can stay in GPU memory. CPU-only filters
+// need them in system memory, so the hardware output format is left unset. A
+// -filter-complex graph is opaque, so its frames are always kept in system memory too.
+func (cb *CommandBuilder) keepFramesOnGPU(config *config.ProcessingConfig) bool {
+	return config.FilterComplex == "" && !hasCPUFilters(config) && (config.ScaleHeight == 0 || cb.scaleOnGPU(config))
+}
+
+// hasCPUFilters reports whether the generated filters include ones that only run on frames in
+// system memory: the watermark overlay, tone-mapping, the ABR split, cropping and resizing,
+// and pixel format and color range conversion
+func hasCPUFilters(config *config.ProcessingConfig) bool {
+	return config.Watermark != "" || config.Tonemap || len(config.ABRLadder) > 0 || hasRegionFilters(config) ||
+		config.PixFmt != "" || colorRangeFilter(config) != ""
+}
+
+// hasRegionFilters reports whether -crop or -output-size add CPU crop/zoom/resize filters
+func hasRegionFilters(config *config.ProcessingConfig) bool {
+	return config.Crop != nil || config.OutputWidth > 0
+}
+
+// scaleOnGPU reports whether scaling can use scale_cuda on frames that stay in CUDA memory.
+// The CPU scale filter would instead download every frame to system memory and upload it
+// back for NVENC.
+func (cb *CommandBuilder) scaleOnGPU(config *config.ProcessingConfig) bool {
+	return config.Acceleration == "cuda" && !config.SoftwareDecode && !config.CopyVideo && !hasCPUFilters(config)
+}
+
 func (cb *CommandBuilder) addVideoEncoding(args []string, config *config.ProcessingConfig) []string {
-	switch config.Codec {
-	case "h264_nvenc":
-		args = append(args, "-c:v", config.Codec)
-		args = append(args, "-preset", config.Preset)
-		args = append(args, "-rc", "vbr", "-cq", fmt.Sprintf("%d", config.Quality))
-		args = append(args, "-b:v", "0") // Use CQ mode
-	case "h264_qsv":
-		args = append(args, "-c:v", config.Codec)
-		args = append(args, "-preset", config.Preset)
-		args = append(args, "-global_quality", fmt.Sprintf("%d", config.Quality))
-	case "h264_vaapi":
-		args = append(args, "-vf", "format=nv12,hwupload")
-		args = append(args, "-c:v", config.Codec)
-		args = append(args, "-qp", fmt.Sprintf("%d", config.Quality))
-	case "h264_videotoolbox":
-		args = append(args, "-c:v", config.Codec)
-		args = append(args, "-q:v", fmt.Sprintf("%d", config.Quality))
-	case "h264_amf":
-		args = append(args, "-c:v", config.Codec)
-		args = append(args, "-quality", config.Preset)
-		args = append(args, "-rc", "cqp")
-		args = append(args, "-qp_i", fmt.Sprintf("%d", config.Quality))
-		args = append(args, "-qp_p", fmt.Sprintf("%d", config.Quality))
-	default: // libx264
-		args = append(args, "-c:v", "libx264")
-		args = append(args, "-preset", config.Preset)
-		args = append(args, "-crf", fmt.Sprintf("%d", config.Quality))
+	if config.FilterComplex != "" {
+		// The user's graph replaces the generated filters entirely
+		if config.Threads > 0 {
+			args = append(args, "-filter_complex_threads", strconv.Itoa(config.Threads))
+		}
+		args = append(args, "-filter_complex", config.FilterComplex)
+	} else {
+		filters := cb.videoFilters(config)
+		var upload []string
+		if strings.HasSuffix(config.Codec, "_vaapi") {
+			// VAAPI encodes from GPU surfaces, so frames are uploaded after CPU filtering
+			upload = []string{"format=nv12", "hwupload"}
+		}
+		switch {
+		case config.Watermark != "":
+			// The overlay takes a second input, so the filters run as a complex graph
+			if config.Threads > 0 {
+				args = append(args, "-filter_complex_threads", strconv.Itoa(config.Threads))
+			}
+			args = append(args, "-filter_complex", cb.watermarkGraph(config, filters, upload))
+		case len(filters) > 0 || len(upload) > 0:
+			if config.Threads > 0 {
+				args = append(args, "-filter_threads", strconv.Itoa(config.Threads))
+			}
+			args = append(args, "-vf", strings.Join(append(filters, upload...), ","))
+		}
+	}
+
+	if config.Lossless {
+		args = cb.addLosslessEncoding(args, config)
+	} else {
+		switch config.Codec {
+		case "h264_nvenc", "hevc_nvenc":
+			args = append(args, "-c:v", config.Codec)
+			if config.GPUDevice != "" {
+				args = append(args, "-gpu", config.GPUDevice)
+			}
+			args = append(args, "-preset", config.Preset)
+			args = append(args, "-rc", "vbr", "-cq", fmt.Sprintf("%d", config.Quality))
+			args = append(args, "-b:v", "0") // Use CQ mode
+			args = cb.addRateCap(args, config)
+		case "h264_qsv", "hevc_qsv":
+			args = append(args, "-c:v", config.Codec)
+			args = append(args, "-preset", config.Preset)
+			args = append(args, "-global_quality", fmt.Sprintf("%d", config.Quality))
+			if config.MaxRate != "" {
+				// QSV only honours a ceiling in QVBR mode, which needs a target below the cap
+				args = append(args, "-b:v", qsvTargetBitrate(config.MaxRate))
+				args = cb.addRateCap(args, config)
+			}
+		case "h264_vaapi", "hevc_vaapi":
+			args = append(args, "-c:v", config.Codec)
+			args = append(args, "-qp", fmt.Sprintf("%d", config.Quality))
+		case "h264_videotoolbox", "hevc_videotoolbox":
+			args = append(args, "-c:v", config.Codec)
+			args = append(args, "-q:v", fmt.Sprintf("%d", config.Quality))
+		case "h264_amf", "hevc_amf":
+			args = append(args, "-c:v", config.Codec)
+			args = append(args, "-quality", config.Preset)
+			args = append(args, "-rc", "cqp")
+			args = append(args, "-qp_i", fmt.Sprintf("%d", config.Quality))
+			args = append(args, "-qp_p", fmt.Sprintf("%d", config.Quality))
+		case "libvpx-vp9", "libaom-av1", "libsvtav1":
+			args = cb.addVP9AV1Encoding(args, config)
+		case "libx265":
+			args = append(args, "-c:v", config.Codec)
+			args = append(args, "-preset", config.Preset)
+			args = append(args, "-crf", fmt.Sprintf("%d", config.Quality))
+			args = cb.addRateCap(args, config)
+		default: // libx264
+			args = append(args, "-c:v", "libx264")
+			args = append(args, "-preset", config.Preset)
+			args = append(args, "-crf", fmt.Sprintf("%d", config.Quality))
+			args = cb.addRateCap(args, config)
+		}
+		if config.HQTuning {
+			args = cb.addHQTuning(args, config)
+		}
+	}
+
+	if IsHEVC(config.Codec) {
+		format := cb.OutputFormat(config.OutputPath)
+		if format == "mp4" || format == "mov" || (format == "hls" && config.HLSSegmentType == "fmp4") {
+			// Apple players only recognise HEVC in MP4/MOV under the hvc1 tag
+			args = append(args, "-tag:v", "hvc1")
+		}
+	}
+
+	if config.Codec == "libx265" {
+		args = cb.addX265Params(args, config)
+	}
+
+	args = cb.addFrameRateOptions(args, config)
+	args = cb.addGOPOptions(args, config)
+	if cb.OutputFormat(config.OutputPath) == "hls" {
+		args = cb.addHLSKeyframes(args, config)
+	}
+
+	if config.PixFmt != "" {
+		args = append(args, "-pix_fmt", config.PixFmt)
+	}
+
+	if config.Threads > 0 {
+		args = append(args, "-threads", strconv.Itoa(config.Threads))
+	}
+
+	if config.Tonemap {
+		// Tag the output as SDR Rec.709 so players don't treat it as HDR
+		args = append(args, "-color_primaries", "bt709", "-color_trc", "bt709", "-colorspace", "bt709")
+	}
+	if colorRange := outputColorRange(config); colorRange != "" {
+		args = append(args, "-color_range", colorRange)
 	}
 	return args
 }
 
-// addOutputFormat adds the appropriate output format based on the output path/URL
-func (cb *CommandBuilder) addOutputFormat(args []string, outputPath string) []string {
-	// Check if it's a streaming URL
-	if cb.isStreamingURL(outputPath) {
-		return cb.addStreamingFormat(args, outputPath)
-	}
-
-	// For file outputs, determine format from extension
-	ext := strings.ToLower(filepath.Ext(outputPath))
-	switch ext {
-	case ".mp4":
-		args = append(args, "-f", "mp4")
-	case ".mkv":
-		args = append(args, "-f", "matroska")
-	case ".avi":
-		args = append(args, "-f", "avi")
-	case ".mov":
-		args = append(args, "-f", "mov")
-	case ".webm":
-		args = append(args, "-f", "webm")
-	case ".flv":
-		args = append(args, "-f", "flv")
-	case ".ts":
-		args = append(args, "-f", "mpegts")
-	case ".m3u8":
-		args = append(args, "-f", "hls")
-		args = append(args, "-hls_time", "10")
-		args = append(args, "-hls_list_size", "0")
-	default:
-		// Default to mp4 if extension is unknown or missing
-		args = append(args, "-f", "mp4")
+// addRateCap adds the -maxrate/-bufsize ceiling for capped CRF/CQ encodes
+func (cb *CommandBuilder) addRateCap(args []string, cfg *config.ProcessingConfig) []string {
+	if cfg.MaxRate == "" {
+		return args
+	}
+	bufSize := cfg.BufSize
+	if bufSize == "" {
+		bufSize = config.FormatBitrate(2 * config.ParseBitrate(cfg.MaxRate))
+	}
+	return append(args, "-maxrate", cfg.MaxRate, "-bufsize", bufSize)
+}
+
+// qsvTargetBitrate is the QVBR average bitrate used under a -maxrate cap
+func qsvTargetBitrate(maxRate string) string {
+	return config.FormatBitrate(0.75 * config.ParseBitrate(maxRate))
+}
+
+// videoFilters returns the CPU video filter chain for the config, in application order
+func (cb *CommandBuilder) videoFilters(config *config.ProcessingConfig) []string {
+	var filters []string
+
+	// Retime first; the filters after it don't depend on timestamps
+	if retimes(config) {
+		filters = append(filters, setptsFilter(config.PlaybackSpeed))
+	}
+
+	if config.Tonemap {
+		filters = append(filters, tonemapFilters...)
+	}
+
+	// Region of interest, before any downscale so crop coordinates are in source pixels
+	filters = append(filters, cb.regionFilters(config)...)
+
+	// Downscale only: sources already below the target keep their size
+	if config.ScaleHeight > 0 {
+		scaler := "scale"
+		if cb.scaleOnGPU(config) {
+			scaler = "scale_cuda"
+		}
+		filters = append(filters, fmt.Sprintf("%s=-2:'min(%d,ih)'", scaler, config.ScaleHeight))
+	}
+
+	if filter := colorRangeFilter(config); filter != "" {
+		filters = append(filters, filter)
+	}
+
+	// Aspect ratio goes last so it applies to the final frame geometry
+	if config.SAR != "" {
+		filters = append(filters, "setsar="+config.SAR)
+	}
+	if config.DAR != "" {
+		filters = append(filters, "setdar="+config.DAR)
+	}
+
+	return filters
+}
+
+// tonemapFilters converts HDR10 (PQ/BT.2020) to SDR BT.709: linearize, tone-map in
+// float RGB, then convert primaries, transfer and matrix to limited-range BT.709
+var tonemapFilters = []string{
+	"zscale=t=linear:npl=100",
+	"format=gbrpf32le",
+	"zscale=p=bt709",
+	"tonemap=tonemap=hable:desat=0",
+	"zscale=t=bt709:m=bt709:r=tv",
+	"format=yuv420p",
+}
+
+// fragmentedMovFlags writes a self-contained moov up front and a fragment per keyframe,
+// so the MP4 is playable while being written and never needs a second pass
+const fragmentedMovFlags = "+frag_keyframe+empty_moov+default_base_moof"
+
+// movFlags picks between faststart and fragmented MP4. Fragmentation is used when requested
+// or when the MP4 goes to a pipe or HTTP URL, where faststart's rewrite pass is impossible.
+// Other containers don't use movflags, so nothing is returned for them.
+func (cb *CommandBuilder) movFlags(config *config.ProcessingConfig) string {
+	format := cb.OutputFormat(config.OutputPath)
+	if format != "mp4" && format != "mov" {
+		return ""
+	}
+	if config.FragmentedMP4 || !cb.IsFileOutput(config.OutputPath) {
+		return fragmentedMovFlags
+	}
+	if config.DisableFastStart {
+		return ""
 	}
+	return "+faststart"
+}
 
+// OutputContainerArgs returns the muxer and movflags a single-file output is written with, for
+// commands that remux already encoded streams into it. The muxer is named explicitly since a
+// .tmp output has no extension FFmpeg recognizes.
+func (cb *CommandBuilder) OutputContainerArgs(config *config.ProcessingConfig) []string {
+	args := OutputFormatArgsFor(config)
+	if movFlags := cb.movFlags(config); movFlags != "" {
+		args = append(args, "-movflags", movFlags)
+	}
 	return args
 }
 
-// isStreamingURL checks if the output path is a streaming URL
-func (cb *CommandBuilder) isStreamingURL(outputPath string) bool {
-	lower := strings.ToLower(outputPath)
-	return strings.HasPrefix(lower, "rtmp://") ||
-		strings.HasPrefix(lower, "rtmps://") ||
-		strings.HasPrefix(lower, "rtsp://") ||
-		strings.HasPrefix(lower, "rtsps://") ||
-		strings.HasPrefix(lower, "srt://") ||
-		strings.HasPrefix(lower, "udp://") ||
-		strings.HasPrefix(lower, "tcp://") ||
-		strings.HasPrefix(lower, "http://") ||
-		strings.HasPrefix(lower, "https://")
+// Probe settings used for live inputs, where start-up latency matters more than stream detection
+const (
+	liveProbeSize       = "32"
+	liveAnalyzeDuration = "0"
+)
+
+// addInputProbing sets -probesize/-analyzeduration. Explicit config values win; otherwise live
+// inputs get minimal probing for latency and files keep FFmpeg's defaults for reliable detection.
+// Recordings keep the defaults too, since stream copy needs complete codec parameters.
+func (cb *CommandBuilder) addInputProbing(args []string, config *config.ProcessingConfig) []string {
+	probeSize, analyzeDuration := config.ProbeSize, config.AnalyzeDuration
+	if cb.IsLiveInput(config.InputPath) && !config.Record {
+		if probeSize == "" {
+			probeSize = liveProbeSize
+		}
+		if analyzeDuration == "" {
+			analyzeDuration = liveAnalyzeDuration
+		}
+	}
+
+	if analyzeDuration != "" {
+		args = append(args, "-analyzeduration", analyzeDuration)
+	}
+	if probeSize != "" {
+		args = append(args, "-probesize", probeSize)
+	}
+	return args
 }
 
-// addStreamingFormat adds the appropriate format for streaming URLs
-func (cb *CommandBuilder) addStreamingFormat(args []string, outputPath string) []string {
-	lower := strings.ToLower(outputPath)
+// ioTimeout is the socket timeout, in microseconds, after which a stalled recording input gives up
+const ioTimeout = "5000000"
 
+// addReconnectOptions adds the protocol's reconnect and timeout options for a non-RTSP network input
+func (cb *CommandBuilder) addReconnectOptions(args []string, inputPath string) []string {
+	lower := strings.ToLower(inputPath)
 	switch {
-	case strings.HasPrefix(lower, "rtmp://") || strings.HasPrefix(lower, "rtmps://"):
-		args = append(args, "-f", "flv")
-	case strings.HasPrefix(lower, "rtsp://") || strings.HasPrefix(lower, "rtsps://"):
-		args = append(args, "-f", "rtsp")
-	case strings.HasPrefix(lower, "srt://"):
-		args = append(args, "-f", "mpegts")
-	case strings.HasPrefix(lower, "udp://"):
-		args = append(args, "-f", "mpegts")
-	case strings.HasPrefix(lower, "tcp://"):
-		args = append(args, "-f", "mpegts")
 	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
-		// For HTTP streaming, check if it's HLS or DASH
-		if strings.Contains(lower, ".m3u8") {
-			args = append(args, "-f", "hls")
-			args = append(args, "-hls_time", "10")
-			args = append(args, "-hls_list_size", "0")
-		} else if strings.Contains(lower, ".mpd") {
-			args = append(args, "-f", "dash")
-		} else {
-			// Default HTTP streaming format
-			args = append(args, "-f", "mpegts")
-		}
-	default:
-		// Fallback to mpegts for unknown streaming protocols
-		args = append(args, "-f", "mpegts")
+		return append(args,
+			"-reconnect", "1",
+			"-reconnect_streamed", "1",
+			"-reconnect_on_network_error", "1",
+			"-reconnect_delay_max", "5",
+		)
+	case strings.Contains(lower, "://"):
+		return append(args, "-rw_timeout", ioTimeout)
+	}
+	return args
+}
+
+// InputArgs returns the options that open the source: -i for files and URLs, preceded by the
+// capture format for device inputs (device:v4l2:/dev/video0 -> -f v4l2 -i /dev/video0) and
+// by the request headers and cookies for HTTP inputs
+func InputArgs(cfg *config.ProcessingConfig) []string {
+	if capture.IsDevice(cfg.InputPath) {
+		if device, err := capture.Parse(cfg.InputPath); err == nil {
+			return device.Args()
+		}
 	}
+	var args []string
+	if IsHTTPInput(cfg.InputPath) {
+		args = HTTPInputOptions(cfg)
+	}
+	return append(args, "-i", cfg.InputPath)
+}
+
+// IsHTTPInput reports whether the input is fetched over HTTP(S)
+func IsHTTPInput(inputPath string) bool {
+	lower := strings.ToLower(inputPath)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// HTTPInputOptions returns FFmpeg's HTTP protocol options for -header and -cookie: -headers
+// takes CRLF-terminated "Key: Value" lines and -cookies newline-separated Set-Cookie values
+func HTTPInputOptions(cfg *config.ProcessingConfig) []string {
+	var args []string
+	if len(cfg.InputHeaders) > 0 {
+		args = append(args, "-headers", strings.Join(cfg.InputHeaders, "\r\n")+"\r\n")
+	}
+	if len(cfg.InputCookies) > 0 {
+		cookies := make([]string, len(cfg.InputCookies))
+		for i, cookie := range cfg.InputCookies {
+			cookies[i] = scopeCookie(cookie, cfg.InputPath)
+		}
+		args = append(args, "-cookies", strings.Join(cookies, "\n"))
+	}
+	return args
+}
+
+// scopeCookie adds the input's host and a root path to a cookie that doesn't name them,
+// since FFmpeg only sends cookies whose domain and path match the request
+func scopeCookie(cookie, inputPath string) string {
+	lower := strings.ToLower(cookie)
+	if !strings.Contains(lower, "domain=") {
+		if u, err := url.Parse(inputPath); err == nil && u.Hostname() != "" {
+			cookie += "; domain=" + u.Hostname()
+		}
+	}
+	if !strings.Contains(lower, "path=") {
+		cookie += "; path=/"
+	}
+	return cookie
+}
+
+// IsLiveInput reports whether the input is a real-time network stream or capture device
+func (cb *CommandBuilder) IsLiveInput(inputPath string) bool {
+	if capture.IsDevice(inputPath) {
+		return true
+	}
+	lower := strings.ToLower(inputPath)
+	for _, prefix := range []string{"rtmp://", "rtmps://", "rtsp://", "rtsps://", "srt://", "udp://", "tcp://"} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
 
+// AddAudioEncoding appends the audio codec options (copy, re-encode or drop)
+func (cb *CommandBuilder) AddAudioEncoding(args []string, config *config.ProcessingConfig) []string {
+	if config.NoAudio {
+		return append(args, "-an")
+	}
+	if config.AudioCodec == "" || config.AudioCodec == "copy" {
+		return append(args, "-c:a", "copy")
+	}
+
+	args = append(args, "-c:a", config.AudioCodec)
+	if config.AudioBitrate != "" {
+		args = append(args, "-b:a", config.AudioBitrate)
+	}
+	var filters []string
+	// A mixed -audio-file already runs through -filter_complex, which carries the pan instead
+	if config.AudioPan != "" && !(config.AudioFile != "" && config.AudioMix) {
+		filters = append(filters, "pan="+config.AudioPan)
+	}
+	if retimes(config) {
+		filters = append(filters, AtempoFilters(config.PlaybackSpeed)...)
+	}
+	if len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+	if config.AudioChannels > 0 {
+		args = append(args, "-ac", strconv.Itoa(config.AudioChannels))
+	}
+	return args
+}
+
+// EndAtShortest reports whether the output should stop at its shortest input. mixesInputs marks
+// commands that combine a second input with the source, which default to it so a short or
+// looping extra input can't stretch or cut the video unexpectedly.
+func EndAtShortest(cfg *config.ProcessingConfig, mixesInputs bool) bool {
+	return cfg.Shortest || (mixesInputs && !cfg.NoShortest)
+}
+
+// addMPEGTSOptions adds the configured MPEG-TS muxer options; unset options are omitted
+func (cb *CommandBuilder) addMPEGTSOptions(args []string, config *config.ProcessingConfig) []string {
+	if config.TSFlags != "" {
+		args = append(args, "-mpegts_flags", config.TSFlags)
+	}
+	if config.PCRPeriod > 0 {
+		args = append(args, "-pcr_period", strconv.Itoa(config.PCRPeriod))
+	}
+	if config.ServiceName != "" {
+		args = append(args, "-metadata", "service_name="+config.ServiceName)
+	}
+	if config.ServiceProvider != "" {
+		args = append(args, "-metadata", "service_provider="+config.ServiceProvider)
+	}
+	if config.FlushPackets {
+		args = append(args, "-flush_packets", "1")
+	}
+	// A constant mux rate smooths the bursts of keyframes on a rate-limited stream; copied
+	// video isn't capped and could exceed it
+	if config.LimitRate != "" && !config.CopyVideo {
+		args = append(args, "-muxrate", config.LimitRate)
+	}
 	return args
 }
+
+// IsFileOutput reports whether the output path is a regular file rather than a stream or pipe
+func (cb *CommandBuilder) IsFileOutput(outputPath string) bool {
+	if outputPath == "-" || strings.HasPrefix(outputPath, "pipe:") {
+		return false
+	}
+	return !IsStreamingURL(outputPath)
+}
@@ -0,0 +1,1252 @@
+package encoder
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"video_processing/internal/config"
+)
+
+// faststart is added for MP4/MOV file outputs
+var faststart = []string{"-movflags", "+faststart"}
+
+// outputOptions are the trailing options BuildFFmpegCommand appends to every command
+var outputOptions = []string{
+	"-bf", "0",
+	"-fflags", "nobuffer",
+	"-flags", "low_delay",
+	"-fflags", "+discardcorrupt",
+	"-tune", "zerolatency",
+	"-y",
+}
+
+// nvencOutputOptions are outputOptions for an NVENC file encode, tuned for quality
+var nvencOutputOptions = withTune(outputOptions, "hq")
+
+// losslessNVENCOutputOptions are outputOptions for a -lossless NVENC encode
+var losslessNVENCOutputOptions = withTune(outputOptions, "lossless")
+
+// withTune returns the trailing options with -tune set to tune
+func withTune(options []string, tune string) []string {
+	out := append([]string{}, options...)
+	for i := range out {
+		if out[i] == "-tune" {
+			out[i+1] = tune
+		}
+	}
+	return out
+}
+
+func concat(parts ...[]string) []string {
+	var out []string
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func TestBuildFFmpegCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProcessingConfig
+		want []string
+	}{
+		{
+			name: "nvenc to mp4",
+			cfg: config.ProcessingConfig{
+				Acceleration: "cuda", Codec: "h264_nvenc", Preset: "medium", Quality: 23,
+				InputPath: "in.mkv", OutputPath: "out.mp4",
+			},
+			want: concat(
+				[]string{"-hwaccel", "cuda", "-hwaccel_output_format", "cuda"},
+				[]string{"-i", "in.mkv"},
+				[]string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "mp4"},
+				faststart,
+				nvencOutputOptions,
+				[]string{"out.mp4"},
+			),
+		},
+		{
+			name: "watermark overlays the downscaled cuda frame on the cpu",
+			cfg: config.ProcessingConfig{
+				Acceleration: "cuda", Codec: "h264_nvenc", Preset: "medium", Quality: 23,
+				InputPath: "in.mkv", OutputPath: "out.mp4", ScaleHeight: 720,
+				Watermark: "logo.png", WatermarkPosition: config.WatermarkBottomRight, WatermarkSize: config.WatermarkSize{Percent: 10},
+			},
+			want: concat(
+				[]string{"-hwaccel", "cuda"},
+				[]string{"-i", "in.mkv", "-i", "logo.png"},
+				[]string{"-map", "[vout]", "-map", "0:a?"},
+				[]string{"-filter_complex", "[0:v:0]scale=-2:'min(720,ih)'[base];" +
+					"[1:v][base]scale2ref=w=main_w*10/100:h=ow/a[wm][ref];" +
+					"[ref][wm]overlay=x=main_w-overlay_w-10:y=main_h-overlay_h-10[vout]"},
+				[]string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0"},
+				[]string{"-c:a", "copy", "-shortest"},
+				[]string{"-f", "mp4"},
+				faststart,
+				nvencOutputOptions,
+				[]string{"out.mp4"},
+			),
+		},
+		{
+			name: "silent audio for a video-only input",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				InputPath: "in.mkv", OutputPath: "out.mp4", SilentAudio: true, AddSilence: true, AudioCodec: "aac",
+			},
+			want: concat(
+				[]string{"-i", "in.mkv", "-f", "lavfi", "-i", "anullsrc=channel_layout=stereo:sample_rate=48000"},
+				[]string{"-map", "0:v:0", "-map", "1:a:0"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "aac", "-shortest"},
+				[]string{"-f", "mp4"},
+				faststart,
+				outputOptions,
+				[]string{"out.mp4"},
+			),
+		},
+		{
+			name: "silent audio follows the watermark input",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				InputPath: "in.mkv", OutputPath: "out.mkv", SilentAudio: true, AddSilence: true, AudioCodec: "aac",
+				Watermark: "logo.png", WatermarkPosition: config.WatermarkTopLeft,
+			},
+			want: concat(
+				[]string{"-i", "in.mkv", "-i", "logo.png", "-f", "lavfi", "-i", "anullsrc=channel_layout=stereo:sample_rate=48000"},
+				[]string{"-map", "[vout]", "-map", "2:a:0"},
+				[]string{"-filter_complex", "[0:v:0]null[base];[base][1:v]overlay=x=10:y=10[vout]"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "aac", "-shortest"},
+				[]string{"-f", "matroska"},
+				outputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "hevc nvenc to mp4 gets the hvc1 tag",
+			cfg: config.ProcessingConfig{
+				Acceleration: "cuda", Codec: "hevc_nvenc", Preset: "medium", Quality: 26,
+				InputPath: "in.mkv", OutputPath: "out.mp4",
+			},
+			want: concat(
+				[]string{"-hwaccel", "cuda", "-hwaccel_output_format", "cuda"},
+				[]string{"-i", "in.mkv"},
+				[]string{"-c:v", "hevc_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "26", "-b:v", "0"},
+				[]string{"-tag:v", "hvc1"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "mp4"},
+				faststart,
+				nvencOutputOptions,
+				[]string{"out.mp4"},
+			),
+		},
+		{
+			name: "libx265 to mkv",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx265", Preset: "medium", Quality: 28,
+				InputPath: "in.mp4", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-i", "in.mp4"},
+				[]string{"-c:v", "libx265", "-preset", "medium", "-crf", "28"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				outputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "vaapi to mkv",
+			cfg: config.ProcessingConfig{
+				Acceleration: "vaapi", Codec: "h264_vaapi", Preset: "ultrafast", Quality: 25,
+				InputPath: "in.mp4", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-init_hw_device", "vaapi=va:/dev/dri/renderD128", "-filter_hw_device", "va", "-hwaccel_output_format", "vaapi"},
+				[]string{"-i", "in.mp4"},
+				[]string{"-vf", "format=nv12,hwupload", "-c:v", "h264_vaapi", "-qp", "25"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				outputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "vaapi on the selected gpu's render node",
+			cfg: config.ProcessingConfig{
+				Acceleration: "vaapi", Codec: "h264_vaapi", Preset: "ultrafast", Quality: 25,
+				RenderNode: "/dev/dri/renderD129", InputPath: "in.mp4", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-init_hw_device", "vaapi=va:/dev/dri/renderD129", "-filter_hw_device", "va", "-hwaccel_output_format", "vaapi"},
+				[]string{"-i", "in.mp4"},
+				[]string{"-vf", "format=nv12,hwupload", "-c:v", "h264_vaapi", "-qp", "25"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				outputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "qsv on the selected gpu's render node",
+			cfg: config.ProcessingConfig{
+				Acceleration: "qsv", Codec: "h264_qsv", Preset: "medium", Quality: 20,
+				RenderNode: "/dev/dri/renderD129", InputPath: "in.mp4", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-qsv_device", "/dev/dri/renderD129", "-hwaccel", "qsv"},
+				[]string{"-i", "in.mp4"},
+				[]string{"-c:v", "h264_qsv", "-preset", "medium", "-global_quality", "20"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				outputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "software decode keeps nvenc",
+			cfg: config.ProcessingConfig{
+				Acceleration: "cuda", Codec: "h264_nvenc", Preset: "medium", Quality: 23, SoftwareDecode: true,
+				GPUDevice: "1", InputPath: "in.mkv", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-i", "in.mkv"},
+				[]string{"-c:v", "h264_nvenc", "-gpu", "1", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				nvencOutputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "software decode uploads to vaapi",
+			cfg: config.ProcessingConfig{
+				Acceleration: "vaapi", Codec: "h264_vaapi", Preset: "ultrafast", Quality: 25, SoftwareDecode: true,
+				InputPath: "in.mp4", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-init_hw_device", "vaapi=va:/dev/dri/renderD128", "-filter_hw_device", "va"},
+				[]string{"-i", "in.mp4"},
+				[]string{"-vf", "format=nv12,hwupload", "-c:v", "h264_vaapi", "-qp", "25"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				outputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "libx264 to rtmp",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				InputPath: "in.mp4", OutputPath: "rtmp://live.example.com/app/key",
+			},
+			want: concat(
+				[]string{"-i", "in.mp4"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "flv"},
+				outputOptions,
+				[]string{"rtmp://live.example.com/app/key"},
+			),
+		},
+		{
+			name: "qsv to hls",
+			cfg: config.ProcessingConfig{
+				Acceleration: "qsv", Codec: "h264_qsv", Preset: "medium", Quality: 20,
+				InputPath: "in.mp4", OutputPath: "stream/index.m3u8",
+			},
+			want: concat(
+				[]string{"-hwaccel", "qsv"},
+				[]string{"-i", "in.mp4"},
+				[]string{"-c:v", "h264_qsv", "-preset", "medium", "-global_quality", "20"},
+				[]string{"-force_key_frames", "expr:gte(t,n_forced*10)"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "hls", "-hls_time", "10", "-hls_list_size", "0", "-hls_flags", "independent_segments"},
+				outputOptions,
+				[]string{"stream/index.m3u8"},
+			),
+		},
+		{
+			name: "nvenc to hls with GOP aligned to segments",
+			cfg: config.ProcessingConfig{
+				Acceleration: "cuda", Codec: "h264_nvenc", Preset: "medium", Quality: 23,
+				HLSTime: 6, GOPSize: 144,
+				InputPath: "in.mp4", OutputPath: "stream/index.m3u8",
+			},
+			want: concat(
+				[]string{"-hwaccel", "cuda", "-hwaccel_output_format", "cuda"},
+				[]string{"-i", "in.mp4"},
+				[]string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0"},
+				[]string{"-g", "144", "-force_key_frames", "expr:gte(t,n_forced*6)", "-forced-idr", "1"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "hls", "-hls_time", "6", "-hls_list_size", "0", "-hls_flags", "independent_segments"},
+				nvencOutputOptions,
+				[]string{"stream/index.m3u8"},
+			),
+		},
+		{
+			name: "hevc to single-file fmp4 hls vod",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx265", Preset: "medium", Quality: 28,
+				HLSSegmentType: "fmp4", HLSSingleFile: true, HLSVOD: true,
+				InputPath: "in.mp4", OutputPath: "vod/index.m3u8",
+			},
+			want: concat(
+				[]string{"-i", "in.mp4"},
+				[]string{"-c:v", "libx265", "-preset", "medium", "-crf", "28", "-tag:v", "hvc1"},
+				[]string{"-force_key_frames", "expr:gte(t,n_forced*10)"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "hls", "-hls_time", "10", "-hls_list_size", "0"},
+				[]string{"-hls_segment_type", "fmp4", "-hls_playlist_type", "vod", "-hls_flags", "independent_segments+single_file"},
+				outputOptions,
+				[]string{"vod/index.m3u8"},
+			),
+		},
+		{
+			name: "software to srt",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 28,
+				InputPath: "in.mp4", OutputPath: "srt://10.0.0.1:9000",
+			},
+			want: concat(
+				[]string{"-i", "in.mp4"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "28"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "mpegts"},
+				outputOptions,
+				[]string{"srt://10.0.0.1:9000"},
+			),
+		},
+		{
+			name: "srt with mpegts muxer options",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 28,
+				TSFlags: "+resend_headers", PCRPeriod: 20, ServiceName: "Studio A", ServiceProvider: "Acme", FlushPackets: true,
+				InputPath: "in.mp4", OutputPath: "srt://10.0.0.1:9000",
+			},
+			want: concat(
+				[]string{"-i", "in.mp4"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "28"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "mpegts"},
+				[]string{"-mpegts_flags", "+resend_headers", "-pcr_period", "20"},
+				[]string{"-metadata", "service_name=Studio A", "-metadata", "service_provider=Acme"},
+				[]string{"-flush_packets", "1"},
+				outputOptions,
+				[]string{"srt://10.0.0.1:9000"},
+			),
+		},
+		{
+			name: "rate-limited srt stream of a file",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 28,
+				LimitRate: "3M", MaxRate: "2722k", BufSize: "2722k",
+				InputPath: "in.mp4", OutputPath: "srt://10.0.0.1:9000",
+			},
+			want: concat(
+				[]string{"-re", "-i", "in.mp4"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "28", "-maxrate", "2722k", "-bufsize", "2722k"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "mpegts", "-muxrate", "3M"},
+				outputOptions,
+				[]string{"srt://10.0.0.1:9000"},
+			),
+		},
+		{
+			name: "rate-limited relay of a live input isn't re-paced",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 28,
+				LimitRate: "3M", MaxRate: "2722k", BufSize: "2722k",
+				InputPath: "udp://0.0.0.0:5000", OutputPath: "rtmp://live.example.com/app/key",
+			},
+			want: concat(
+				[]string{"-analyzeduration", "0", "-probesize", "32", "-i", "udp://0.0.0.0:5000"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "28", "-maxrate", "2722k", "-bufsize", "2722k"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "flv"},
+				outputOptions,
+				[]string{"rtmp://live.example.com/app/key"},
+			),
+		},
+		{
+			name: "v4l2 capture restreamed to rtmp",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				InputPath: "device:v4l2:/dev/video0", OutputPath: "rtmp://live.example.com/app/key",
+			},
+			want: concat(
+				[]string{"-analyzeduration", "0", "-probesize", "32", "-f", "v4l2", "-i", "/dev/video0"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "flv"},
+				outputOptions,
+				[]string{"rtmp://live.example.com/app/key"},
+			),
+		},
+		{
+			name: "custom filter graph replaces the generated filters",
+			cfg: config.ProcessingConfig{
+				Acceleration: "cuda", Codec: "h264_nvenc", Preset: "medium", Quality: 23, ScaleHeight: 720,
+				FilterComplex: "[0:v]split[a][b];[b]hflip[c];[a][c]hstack[v]",
+				InputPath:     "in.mkv", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-hwaccel", "cuda"},
+				[]string{"-i", "in.mkv"},
+				[]string{"-map", "[v]", "-map", "0:a?"},
+				[]string{"-filter_complex", "[0:v]split[a][b];[b]hflip[c];[a][c]hstack[v]"},
+				[]string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				nvencOutputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "unlabeled custom graph keeps non-video maps",
+			cfg: config.ProcessingConfig{
+				Acceleration: "vaapi", Codec: "h264_vaapi", Preset: "ultrafast", Quality: 25,
+				FilterComplex: "[0:v]transpose=1,format=nv12,hwupload", Maps: []string{"0:v:0?", "0:a:1"},
+				InputPath: "in.mp4", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-init_hw_device", "vaapi=va:/dev/dri/renderD128", "-filter_hw_device", "va"},
+				[]string{"-i", "in.mp4"},
+				[]string{"-map", "0:a:1"},
+				[]string{"-filter_complex", "[0:v]transpose=1,format=nv12,hwupload", "-c:v", "h264_vaapi", "-qp", "25"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				outputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "track titles and default flags",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23, CopyVideo: true,
+				Maps: []string{"0:v:0?", "0:a?", "0:s?"}, SubtitleCodec: "copy",
+				StreamTitles: []string{"a:1=Director's Commentary"},
+				Dispositions: []string{"a:0=0", "a:1=default", "s:0=default"},
+				InputPath:    "in.mkv", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-i", "in.mkv"},
+				[]string{"-map", "0:v:0?", "-map", "0:a?", "-map", "0:s?"},
+				[]string{"-c:v", "copy", "-c:a", "copy", "-c:s", "copy"},
+				[]string{"-metadata:s:a:1", "title=Director's Commentary"},
+				[]string{"-disposition:a:0", "0", "-disposition:a:1", "default", "-disposition:s:0", "default"},
+				[]string{"-f", "matroska"},
+				[]string{"-fflags", "nobuffer", "-flags", "low_delay", "-fflags", "+discardcorrupt"},
+				[]string{"-y", "out.mkv"},
+			),
+		},
+		{
+			name: "rtsp publish on ffmpeg 4",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23, FFmpegMajor: 4,
+				InputPath: "in.mp4", OutputPath: "rtsp://media.local:8554/live",
+			},
+			want: concat(
+				[]string{"-i", "in.mp4"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "rtsp", "-rtsp_transport", "tcp", "-stimeout", "5000000"},
+				outputOptions,
+				[]string{"rtsp://media.local:8554/live"},
+			),
+		},
+		{
+			name: "rtsp listen input",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23, RTSPListen: true, FFmpegMajor: 6,
+				InputPath: "rtsp://0.0.0.0:8554/cam", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-analyzeduration", "0", "-probesize", "32"},
+				[]string{"-rtsp_flags", "listen"},
+				[]string{"-i", "rtsp://0.0.0.0:8554/cam"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				outputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "copy video, re-encode audio",
+			cfg: config.ProcessingConfig{
+				Acceleration: "cuda", Codec: "h264_nvenc", Preset: "medium", Quality: 23,
+				CopyVideo: true, AudioCodec: "aac", AudioBitrate: "192k",
+				InputPath: "in.mkv", OutputPath: "out.mp4",
+			},
+			want: []string{
+				"-i", "in.mkv",
+				"-c:v", "copy",
+				"-c:a", "aac", "-b:a", "192k",
+				"-f", "mp4",
+				"-movflags", "+faststart",
+				"-fflags", "nobuffer",
+				"-flags", "low_delay",
+				"-fflags", "+discardcorrupt",
+				"-y", "out.mp4",
+			},
+		},
+		{
+			name: "nvenc with hdr tone-mapping",
+			cfg: config.ProcessingConfig{
+				Acceleration: "cuda", Codec: "h264_nvenc", Preset: "medium", Quality: 23,
+				Tonemap:   true,
+				InputPath: "hdr.mkv", OutputPath: "sdr.mp4",
+			},
+			want: concat(
+				[]string{"-hwaccel", "cuda"},
+				[]string{"-i", "hdr.mkv"},
+				[]string{"-vf", "zscale=t=linear:npl=100,format=gbrpf32le,zscale=p=bt709,tonemap=tonemap=hable:desat=0,zscale=t=bt709:m=bt709:r=tv,format=yuv420p"},
+				[]string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0"},
+				[]string{"-color_primaries", "bt709", "-color_trc", "bt709", "-colorspace", "bt709"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "mp4"},
+				faststart,
+				nvencOutputOptions,
+				[]string{"sdr.mp4"},
+			),
+		},
+		{
+			name: "nvenc with gpu scaling",
+			cfg: config.ProcessingConfig{
+				Acceleration: "cuda", Codec: "h264_nvenc", Preset: "medium", Quality: 23, ScaleHeight: 1080,
+				InputPath: "in.mkv", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-hwaccel", "cuda", "-hwaccel_output_format", "cuda"},
+				[]string{"-i", "in.mkv"},
+				[]string{"-vf", "scale_cuda=-2:'min(1080,ih)'"},
+				[]string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				nvencOutputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "live input gets minimal probing",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				InputPath: "rtsp://camera.local/stream", OutputPath: "out.mp4",
+			},
+			want: concat(
+				[]string{"-analyzeduration", "0", "-probesize", "32"},
+				[]string{"-i", "rtsp://camera.local/stream"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "mp4"},
+				faststart,
+				outputOptions,
+				[]string{"out.mp4"},
+			),
+		},
+		{
+			name: "explicit probing overrides file defaults",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				ProbeSize: "10M", AnalyzeDuration: "5000000",
+				InputPath: "in.ts", OutputPath: "out.mp4",
+			},
+			want: concat(
+				[]string{"-analyzeduration", "5000000", "-probesize", "10M"},
+				[]string{"-i", "in.ts"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "mp4"},
+				faststart,
+				outputOptions,
+				[]string{"out.mp4"},
+			),
+		},
+		{
+			name: "rtsp recording",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				Record: true, CopyVideo: true, FragmentedMP4: true, Duration: "600",
+				InputPath: "rtsp://camera.local/stream", OutputPath: "rec.mp4",
+			},
+			want: []string{
+				"-rtsp_transport", "tcp", "-timeout", "5000000",
+				"-i", "rtsp://camera.local/stream",
+				"-t", "600",
+				"-c:v", "copy",
+				"-c:a", "copy",
+				"-f", "mp4",
+				"-movflags", "+frag_keyframe+empty_moov+default_base_moof",
+				"-fflags", "nobuffer",
+				"-flags", "low_delay",
+				"-fflags", "+discardcorrupt",
+				"-y", "rec.mp4",
+			},
+		},
+		{
+			name: "language-selected audio and subtitles",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				Maps: []string{"0:v:0?", "0:a:1", "0:s:0"}, SubtitleCodec: "mov_text",
+				InputPath: "in.mkv", OutputPath: "out.mp4",
+			},
+			want: concat(
+				[]string{"-i", "in.mkv"},
+				[]string{"-map", "0:v:0?", "-map", "0:a:1", "-map", "0:s:0"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "copy"},
+				[]string{"-c:s", "mov_text"},
+				[]string{"-f", "mp4"},
+				faststart,
+				outputOptions,
+				[]string{"out.mp4"},
+			),
+		},
+		{
+			name: "data streams copied into mpegts",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				Maps: []string{"0:v:0?", "0:a:0?", "0:d?"}, CopyData: true,
+				InputPath: "in.ts", OutputPath: "out.ts",
+			},
+			want: concat(
+				[]string{"-i", "in.ts"},
+				[]string{"-map", "0:v:0?", "-map", "0:a:0?", "-map", "0:d?"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "copy"},
+				[]string{"-c:d", "copy"},
+				[]string{"-f", "mpegts"},
+				outputOptions,
+				[]string{"out.ts"},
+			),
+		},
+		{
+			name: "audio replaced by an external file",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				AudioFile: "music.mp3", Duration: "30",
+				InputPath: "in.mkv", OutputPath: "out.mp4",
+			},
+			want: concat(
+				[]string{"-i", "in.mkv", "-i", "music.mp3", "-t", "30"},
+				[]string{"-map", "0:v:0", "-map", "1:a:0"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "copy"},
+				[]string{"-shortest"},
+				[]string{"-f", "mp4"},
+				faststart,
+				outputOptions,
+				[]string{"out.mp4"},
+			),
+		},
+		{
+			name: "external audio mixed in, keeping the longest input",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				AudioFile: "music.mp3", AudioMix: true, AudioCodec: "aac", NoShortest: true,
+				Maps:      []string{"0:v:0?", "0:a:0?", "0:s:0"},
+				InputPath: "in.mkv", OutputPath: "out.mkv",
+			},
+			want: concat(
+				[]string{"-i", "in.mkv", "-i", "music.mp3"},
+				[]string{"-map", "0:v:0", "-filter_complex", "[0:a:0][1:a:0]amix=inputs=2:duration=longest:dropout_transition=0[aout]", "-map", "[aout]", "-map", "0:s:0"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "aac"},
+				[]string{"-f", "matroska"},
+				outputOptions,
+				[]string{"out.mkv"},
+			),
+		},
+		{
+			name: "lossless x264 keeping 4:4:4 chroma",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				Lossless: true, PixFmt: "yuv444p",
+				InputPath: "in.mov", OutputPath: "master.mkv",
+			},
+			want: concat(
+				[]string{"-i", "in.mov"},
+				[]string{"-c:v", "libx264", "-preset", "veryslow", "-qp", "0", "-pix_fmt", "yuv444p"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				outputOptions,
+				[]string{"master.mkv"},
+			),
+		},
+		{
+			name: "lossless nvenc",
+			cfg: config.ProcessingConfig{
+				Acceleration: "cuda", Codec: "h264_nvenc", Preset: "medium", Quality: 23,
+				Lossless: true, InputPath: "in.mp4", OutputPath: "master.mkv",
+			},
+			want: concat(
+				[]string{"-hwaccel", "cuda", "-hwaccel_output_format", "cuda"},
+				[]string{"-i", "in.mp4"},
+				[]string{"-c:v", "h264_nvenc", "-preset", "lossless", "-rc", "constqp", "-qp", "0"},
+				[]string{"-c:a", "copy"},
+				[]string{"-f", "matroska"},
+				losslessNVENCOutputOptions,
+				[]string{"master.mkv"},
+			),
+		},
+		{
+			name: "fixed-duration segments",
+			cfg: config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				SegmentTime: "600", InputPath: "in.mkv", OutputPath: "out.mp4",
+			},
+			want: concat(
+				[]string{"-i", "in.mkv"},
+				[]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"},
+				[]string{"-c:a", "copy"},
+				[]string{"-force_key_frames", "expr:gte(t,n_forced*600)"},
+				[]string{"-f", "segment", "-segment_time", "600", "-segment_format", "mp4", "-reset_timestamps", "1"},
+				[]string{"-segment_format_options", "movflags=+faststart"},
+				outputOptions,
+				[]string{"out_%03d.mp4"},
+			),
+		},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cb.BuildFFmpegCommand(&tt.cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildFFmpegCommand()\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMovFlags(t *testing.T) {
+	tests := []struct {
+		output     string
+		fragmented bool
+		want       string
+	}{
+		{"out.mp4", false, "+faststart"},
+		{"out.mp4", true, "+frag_keyframe+empty_moov+default_base_moof"},
+		{"-", false, "+frag_keyframe+empty_moov+default_base_moof"},
+		{"pipe:1", false, "+frag_keyframe+empty_moov+default_base_moof"},
+		{"https://cdn.example.com/upload/video.mp4", false, "+frag_keyframe+empty_moov+default_base_moof"},
+		{"out.mkv", true, ""},
+		{"out.mkv", false, ""},
+		{"rtmp://example.com/live", false, ""},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			cfg := &config.ProcessingConfig{OutputPath: tt.output, FragmentedMP4: tt.fragmented}
+			if got := cb.movFlags(cfg); got != tt.want {
+				t.Errorf("movFlags(%q, fragmented=%v) = %q, want %q", tt.output, tt.fragmented, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMuxRate(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"srt://10.0.0.1:9000", true},
+		{"udp://239.0.0.1:1234", true},
+		{"rtmp://live.example.com/app/key", false},
+		{"rtsp://media.example.com:8554/live", false},
+		{"https://cdn.example.com/upload/video.mp4", false},
+		{"https://cdn.example.com/live/index.m3u8", false},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			cfg := &config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 28,
+				LimitRate: "3M", InputPath: "in.mp4", OutputPath: tt.output,
+			}
+			if got := slices.Contains(cb.BuildFFmpegCommand(cfg), "-muxrate"); got != tt.want {
+				t.Errorf("-muxrate for %s = %v, want %v", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddVideoEncoding(t *testing.T) {
+	tests := []struct {
+		codec  string
+		preset string
+		want   []string
+	}{
+		{"h264_nvenc", "medium", []string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0"}},
+		{"h264_qsv", "medium", []string{"-c:v", "h264_qsv", "-preset", "medium", "-global_quality", "23"}},
+		{"h264_vaapi", "ultrafast", []string{"-vf", "format=nv12,hwupload", "-c:v", "h264_vaapi", "-qp", "23"}},
+		{"h264_videotoolbox", "balanced", []string{"-c:v", "h264_videotoolbox", "-q:v", "23"}},
+		{"h264_amf", "balanced", []string{"-c:v", "h264_amf", "-quality", "balanced", "-rc", "cqp", "-qp_i", "23", "-qp_p", "23"}},
+		{"libx264", "medium", []string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"}},
+		{"libvpx-vp9", "medium", []string{"-c:v", "libvpx-vp9", "-crf", "23", "-b:v", "0", "-deadline", "good", "-cpu-used", "2", "-row-mt", "1", "-tile-columns", "2", "-frame-parallel", "0"}},
+		{"libaom-av1", "medium", []string{"-c:v", "libaom-av1", "-crf", "23", "-b:v", "0", "-cpu-used", "5", "-row-mt", "1", "-tiles", "2x2"}},
+		{"libsvtav1", "medium", []string{"-c:v", "libsvtav1", "-crf", "23", "-preset", "8"}},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			cfg := &config.ProcessingConfig{Codec: tt.codec, Preset: tt.preset, Quality: 23}
+			got := cb.addVideoEncoding(nil, cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addVideoEncoding(%s) = %q, want %q", tt.codec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddVideoEncodingHQTuning(t *testing.T) {
+	tests := []struct {
+		codec       string
+		ffmpegMajor int
+		want        []string
+	}{
+		{"h264_nvenc", 6, []string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0", "-multipass", "fullres", "-spatial-aq", "1", "-temporal-aq", "1", "-rc-lookahead", "32"}},
+		{"hevc_nvenc", 4, []string{"-c:v", "hevc_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0", "-2pass", "1", "-spatial-aq", "1", "-temporal-aq", "1", "-rc-lookahead", "32", "-tag:v", "hvc1"}},
+		{"h264_qsv", 6, []string{"-c:v", "h264_qsv", "-preset", "medium", "-global_quality", "23", "-look_ahead", "1", "-look_ahead_depth", "32"}},
+		{"hevc_qsv", 6, []string{"-c:v", "hevc_qsv", "-preset", "medium", "-global_quality", "23", "-tag:v", "hvc1"}},
+		{"libx264", 6, []string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"}},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			cfg := &config.ProcessingConfig{Codec: tt.codec, Preset: "medium", Quality: 23, HQTuning: true, FFmpegMajor: tt.ffmpegMajor}
+			got := cb.addVideoEncoding(nil, cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addVideoEncoding(%s) = %q, want %q", tt.codec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddVideoEncodingColorRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		codec  string
+		source string
+		target string
+		want   []string
+	}{
+		{"full to limited", "libx264", "pc", "tv", []string{"-vf", "scale=in_range=pc:out_range=tv", "-c:v", "libx264", "-preset", "medium", "-crf", "23", "-color_range", "tv"}},
+		{"matching range is only tagged", "libx264", "tv", "tv", []string{"-c:v", "libx264", "-preset", "medium", "-crf", "23", "-color_range", "tv"}},
+		{"vaapi keeps full range through nv12", "h264_vaapi", "pc", "auto", []string{"-vf", "scale=in_range=pc:out_range=pc,format=nv12,hwupload", "-c:v", "h264_vaapi", "-qp", "23", "-color_range", "pc"}},
+		{"unknown source is only tagged", "libx264", "", "pc", []string{"-c:v", "libx264", "-preset", "medium", "-crf", "23", "-color_range", "pc"}},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ProcessingConfig{Codec: tt.codec, Preset: "medium", Quality: 23, SourceColorRange: tt.source, ColorRange: tt.target}
+			got := cb.addVideoEncoding(nil, cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addVideoEncoding() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddVideoEncodingFixedGOP(t *testing.T) {
+	tests := []struct {
+		codec    string
+		lossless bool
+		want     []string
+	}{
+		{"h264_nvenc", false, []string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0", "-g", "48", "-no-scenecut", "1"}},
+		{"h264_qsv", false, []string{"-c:v", "h264_qsv", "-preset", "medium", "-global_quality", "23", "-g", "48", "-adaptive_i", "0"}},
+		{"libx264", false, []string{"-c:v", "libx264", "-preset", "medium", "-crf", "23", "-g", "48", "-sc_threshold", "0"}},
+		{"libx265", true, []string{"-c:v", "libx265", "-preset", "veryslow", "-tag:v", "hvc1", "-x265-params", "lossless=1:scenecut=0", "-g", "48"}},
+		{"libvpx-vp9", false, []string{"-c:v", "libvpx-vp9", "-crf", "23", "-b:v", "0", "-deadline", "good", "-cpu-used", "2", "-row-mt", "1", "-tile-columns", "2", "-frame-parallel", "0", "-g", "48", "-keyint_min", "48"}},
+		{"h264_videotoolbox", false, []string{"-c:v", "h264_videotoolbox", "-q:v", "23", "-g", "48"}},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			cfg := &config.ProcessingConfig{Codec: tt.codec, Preset: "medium", Quality: 23, Lossless: tt.lossless, GOPSize: 48, NoSceneCut: true}
+			got := cb.addVideoEncoding(nil, cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addVideoEncoding(%s) = %q, want %q", tt.codec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddVideoEncodingClosedGOP(t *testing.T) {
+	tests := []struct {
+		codec string
+		want  []string
+	}{
+		{"h264_nvenc", []string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0", "-g", "50", "-strict_gop", "1", "-forced-idr", "1", "-no-scenecut", "1"}},
+		{"h264_qsv", []string{"-c:v", "h264_qsv", "-preset", "medium", "-global_quality", "23", "-g", "50", "-idr_interval", "0", "-adaptive_i", "0"}},
+		{"libx264", []string{"-c:v", "libx264", "-preset", "medium", "-crf", "23", "-g", "50", "-forced-idr", "1", "-sc_threshold", "0"}},
+		{"libx265", []string{"-c:v", "libx265", "-preset", "medium", "-crf", "23", "-tag:v", "hvc1", "-x265-params", "scenecut=0:open-gop=0", "-g", "50"}},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			cfg := &config.ProcessingConfig{Codec: tt.codec, Preset: "medium", Quality: 23, GOPSize: 50, ClosedGOP: true}
+			got := cb.addVideoEncoding(nil, cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addVideoEncoding(%s) = %q, want %q", tt.codec, got, tt.want)
+			}
+		})
+	}
+
+	cfg := &config.ProcessingConfig{Codec: "libx264", Preset: "medium", Quality: 23, GOPSize: 50, ClosedGOP: true,
+		InputPath: "in.mkv", OutputPath: "out.ts"}
+	if args := cb.BuildFFmpegCommand(cfg); !slices.Contains(args, "low_delay+cgop") {
+		t.Errorf("BuildFFmpegCommand() with -closed-gop = %q, want -flags low_delay+cgop", args)
+	}
+}
+
+func TestAddVideoEncodingFrameRate(t *testing.T) {
+	tests := []struct {
+		name        string
+		frameRate   string
+		cfr         bool
+		ffmpegMajor int
+		want        []string
+	}{
+		{"passthrough", "", false, 0, nil},
+		{"rate only", "30", false, 6, []string{"-r", "30"}},
+		{"cfr at source rate", "", true, 7, []string{"-fps_mode", "cfr"}},
+		{"cfr on unknown version", "30000/1001", true, 0, []string{"-r", "30000/1001", "-fps_mode", "cfr"}},
+		{"cfr on ffmpeg 5", "25", true, 5, []string{"-r", "25", "-vsync", "cfr"}},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				FrameRate: tt.frameRate, CFR: tt.cfr, FFmpegMajor: tt.ffmpegMajor,
+			}
+			want := append([]string{"-c:v", "libx264", "-preset", "medium", "-crf", "23"}, tt.want...)
+			if got := cb.addVideoEncoding(nil, cfg); !reflect.DeepEqual(got, want) {
+				t.Errorf("addVideoEncoding() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestAddVideoEncodingRateCap(t *testing.T) {
+	tests := []struct {
+		codec   string
+		bufSize string
+		want    []string
+	}{
+		{"libx264", "", []string{"-c:v", "libx264", "-preset", "medium", "-crf", "23", "-maxrate", "6M", "-bufsize", "12000k"}},
+		{"libx264", "8M", []string{"-c:v", "libx264", "-preset", "medium", "-crf", "23", "-maxrate", "6M", "-bufsize", "8M"}},
+		{"h264_nvenc", "", []string{"-c:v", "h264_nvenc", "-preset", "medium", "-rc", "vbr", "-cq", "23", "-b:v", "0", "-maxrate", "6M", "-bufsize", "12000k"}},
+		{"h264_qsv", "", []string{"-c:v", "h264_qsv", "-preset", "medium", "-global_quality", "23", "-b:v", "4500k", "-maxrate", "6M", "-bufsize", "12000k"}},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.codec+tt.bufSize, func(t *testing.T) {
+			cfg := &config.ProcessingConfig{Codec: tt.codec, Preset: "medium", Quality: 23, MaxRate: "6M", BufSize: tt.bufSize}
+			got := cb.addVideoEncoding(nil, cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addVideoEncoding(%s) = %q, want %q", tt.codec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVideoFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProcessingConfig
+		want []string
+	}{
+		{"passthrough", config.ProcessingConfig{}, nil},
+		{"square pixels", config.ProcessingConfig{SAR: "1:1"}, []string{"setsar=1:1"}},
+		{"display aspect", config.ProcessingConfig{DAR: "16:9"}, []string{"setdar=16:9"}},
+		{
+			"aspect after tonemap",
+			config.ProcessingConfig{Tonemap: true, SAR: "1"},
+			append(append([]string{}, tonemapFilters...), "setsar=1"),
+		},
+		{"downscale", config.ProcessingConfig{ScaleHeight: 1080, SAR: "1:1"}, []string{"scale=-2:'min(1080,ih)'", "setsar=1:1"}},
+		{"playback speed first", config.ProcessingConfig{PlaybackSpeed: 2, ScaleHeight: 720}, []string{"setpts=PTS/2", "scale=-2:'min(720,ih)'"}},
+		{"normal speed", config.ProcessingConfig{PlaybackSpeed: 1}, nil},
+		{"downscale on cuda", config.ProcessingConfig{Acceleration: "cuda", ScaleHeight: 720}, []string{"scale_cuda=-2:'min(720,ih)'"}},
+		{"cuda downscale after tonemap", config.ProcessingConfig{Acceleration: "cuda", Tonemap: true, ScaleHeight: 720}, append(append([]string{}, tonemapFilters...), "scale=-2:'min(720,ih)'")},
+		{
+			"crop and upscale",
+			config.ProcessingConfig{Crop: &config.Region{Width: 960, Height: 540, X: 480, Y: 270}, OutputWidth: 1920, OutputHeight: 1080},
+			[]string{"crop=960:540:480:270", "scale=1920:1080:flags=lanczos"},
+		},
+		{
+			"crop on cuda stays on the cpu",
+			config.ProcessingConfig{Acceleration: "cuda", Crop: &config.Region{Width: 1280, Height: 720, X: 0, Y: 0}, ScaleHeight: 480},
+			[]string{"crop=1280:720:0:0", "scale=-2:'min(480,ih)'"},
+		},
+		{
+			"zoom into region",
+			config.ProcessingConfig{
+				Crop: &config.Region{Width: 960, Height: 540, X: 960, Y: 0}, Zoom: true,
+				ZoomFrames: 301, ZoomFPS: "30/1", OutputWidth: 1920, OutputHeight: 1080,
+			},
+			[]string{"zoompan=z='1+(min(iw/960,ih/540)-1)*min(on/300,1)':x='iw/2+(1440-iw/2)*min(on/300,1)-iw/zoom/2':y='ih/2+(270-ih/2)*min(on/300,1)-ih/zoom/2':d=1:s=1920x1080:fps=30/1"},
+		},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cb.videoFilters(&tt.cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("videoFilters() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildABRCommand(t *testing.T) {
+	cfg := &config.ProcessingConfig{
+		Acceleration: "cuda", Codec: "h264_nvenc", Preset: "medium", Quality: 23,
+		InputPath: "in.mp4", OutputPath: "hls/master.m3u8",
+		ABRLadder: []config.Rendition{
+			{Width: 1920, Height: 1080, Bitrate: "5000k"},
+			{Width: 1280, Height: 720, Bitrate: "2800k"},
+		},
+	}
+
+	want := []string{
+		"-hwaccel", "cuda",
+		"-i", "in.mp4",
+		"-filter_complex", "[0:v]split=2[v0][v1];[v0]scale=w=1920:h=1080[v0out];[v1]scale=w=1280:h=720[v1out]",
+		"-map", "[v0out]", "-b:v:0", "5000k", "-maxrate:v:0", "5000k", "-bufsize:v:0", "5000k",
+		"-map", "[v1out]", "-b:v:1", "2800k", "-maxrate:v:1", "2800k", "-bufsize:v:1", "2800k",
+		"-c:v", "h264_nvenc", "-preset", "medium",
+		"-force_key_frames", "expr:gte(t,n_forced*10)", "-forced-idr", "1",
+		"-map", "0:a:0?", "-map", "0:a:0?",
+		"-c:a", "copy",
+		"-f", "hls",
+		"-hls_time", "10",
+		"-hls_list_size", "0",
+		"-hls_flags", "independent_segments",
+		"-hls_segment_filename", "hls/stream_%v_%03d.ts",
+		"-master_pl_name", "master.m3u8",
+		"-var_stream_map", "v:0,a:0 v:1,a:1",
+		"-y", "hls/stream_%v.m3u8",
+	}
+
+	got := NewCommandBuilder().BuildFFmpegCommand(cfg)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildFFmpegCommand() with ABR ladder\n got: %q\nwant: %q", got, want)
+	}
+
+	// -audio-lang resolves to an explicit audio stream, which every rendition carries
+	cfg.Maps = []string{"0:v:0?", "0:a:2"}
+	got = NewCommandBuilder().BuildFFmpegCommand(cfg)
+	if !slices.Contains(got, "0:a:2?") || slices.Contains(got, "0:a:0?") {
+		t.Errorf("BuildFFmpegCommand() with ABR ladder and -audio-lang = %q, want every rendition on 0:a:2?", got)
+	}
+}
+
+func TestSegmentPattern(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"out.mp4", "out_%03d.mp4"},
+		{"videos/talk.mkv", "videos/talk_%03d.mkv"},
+		{"part-%02d.ts", "part-%02d.ts"},
+	}
+
+	for _, tt := range tests {
+		if got := SegmentPattern(tt.output); got != tt.want {
+			t.Errorf("SegmentPattern(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+	if got := SegmentPath("out.mp4", 2); got != "out_002.mp4" {
+		t.Errorf("SegmentPath(out.mp4, 2) = %q, want out_002.mp4", got)
+	}
+}
+
+func TestAddAudioEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProcessingConfig
+		want []string
+	}{
+		{"copy", config.ProcessingConfig{}, []string{"-c:a", "copy"}},
+		{"dropped", config.ProcessingConfig{NoAudio: true, AudioChannels: 2}, []string{"-an"}},
+		{"stereo downmix", config.ProcessingConfig{AudioCodec: "aac", AudioBitrate: "160k", AudioChannels: 2},
+			[]string{"-c:a", "aac", "-b:a", "160k", "-ac", "2"}},
+		{"custom pan", config.ProcessingConfig{AudioCodec: "aac", AudioPan: "stereo|FL<FL+0.7*FC|FR<FR+0.7*FC"},
+			[]string{"-c:a", "aac", "-af", "pan=stereo|FL<FL+0.7*FC|FR<FR+0.7*FC"}},
+		{"slow motion", config.ProcessingConfig{AudioCodec: "aac", PlaybackSpeed: 0.5}, []string{"-c:a", "aac", "-af", "atempo=0.5"}},
+		{"pan before tempo", config.ProcessingConfig{AudioCodec: "aac", AudioPan: "mono|c0=FL", PlaybackSpeed: 4},
+			[]string{"-c:a", "aac", "-af", "pan=mono|c0=FL,atempo=2,atempo=2"}},
+		{"pan runs in the audio-file mix graph", config.ProcessingConfig{AudioCodec: "aac", AudioPan: "mono|c0=FL", AudioFile: "music.mp3", AudioMix: true},
+			[]string{"-c:a", "aac"}},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cb.AddAudioEncoding(nil, &tt.cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AddAudioEncoding() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndAtShortest(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.ProcessingConfig
+		mixesInputs bool
+		want        bool
+	}{
+		{"single input by default", config.ProcessingConfig{}, false, false},
+		{"single input with -shortest", config.ProcessingConfig{Shortest: true}, false, true},
+		{"mixed inputs by default", config.ProcessingConfig{}, true, true},
+		{"mixed inputs with -shortest=false", config.ProcessingConfig{NoShortest: true}, true, false},
+	}
+
+	for _, tt := range tests {
+		if got := EndAtShortest(&tt.cfg, tt.mixesInputs); got != tt.want {
+			t.Errorf("%s: EndAtShortest() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInputArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProcessingConfig
+		want []string
+	}{
+		{"file", config.ProcessingConfig{InputPath: "in.mp4"}, []string{"-i", "in.mp4"}},
+		{"headers ignored for files", config.ProcessingConfig{InputPath: "in.mp4", InputHeaders: []string{"Authorization: Bearer abc"}},
+			[]string{"-i", "in.mp4"}},
+		{"http headers", config.ProcessingConfig{InputPath: "https://cdn.example.com/live.m3u8",
+			InputHeaders: []string{"Authorization: Bearer abc", "X-Origin: edge"}},
+			[]string{"-headers", "Authorization: Bearer abc\r\nX-Origin: edge\r\n", "-i", "https://cdn.example.com/live.m3u8"}},
+		{"cookie scoped to the input host", config.ProcessingConfig{InputPath: "https://cdn.example.com/live.m3u8",
+			InputCookies: []string{"session=abc", "theme=dark; path=/live; domain=example.com"}},
+			[]string{"-cookies", "session=abc; domain=cdn.example.com; path=/\ntheme=dark; path=/live; domain=example.com",
+				"-i", "https://cdn.example.com/live.m3u8"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InputArgs(&tt.cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InputArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAtempoFilters(t *testing.T) {
+	tests := []struct {
+		speed float64
+		want  []string
+	}{
+		{1.5, []string{"atempo=1.5"}},
+		{0.5, []string{"atempo=0.5"}},
+		{2, []string{"atempo=2"}},
+		{3, []string{"atempo=2", "atempo=1.5"}},
+		{10, []string{"atempo=2", "atempo=2", "atempo=2", "atempo=1.25"}},
+		{0.3, []string{"atempo=0.5", "atempo=0.6"}},
+		{0.1, []string{"atempo=0.5", "atempo=0.5", "atempo=0.5", "atempo=0.8"}},
+	}
+
+	for _, tt := range tests {
+		if got := AtempoFilters(tt.speed); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AtempoFilters(%g) = %q, want %q", tt.speed, got, tt.want)
+		}
+	}
+}
+
+func TestWatermarkGraph(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProcessingConfig
+		want string
+	}{
+		{
+			"natural size",
+			config.ProcessingConfig{Watermark: "logo.png", WatermarkPosition: config.WatermarkTopLeft},
+			"[0:v:0]null[base];[base][1:v]overlay=x=10:y=10[vout]",
+		},
+		{
+			"percentage of the scaled width",
+			config.ProcessingConfig{Watermark: "logo.png", WatermarkPosition: config.WatermarkTopRight, ScaleHeight: 1080, WatermarkSize: config.WatermarkSize{Percent: 12.5}},
+			"[0:v:0]scale=-2:'min(1080,ih)'[base];[1:v][base]scale2ref=w=main_w*12.5/100:h=ow/a[wm][ref];[ref][wm]overlay=x=main_w-overlay_w-10:y=10[vout]",
+		},
+		{
+			"pixel width after the crop",
+			config.ProcessingConfig{Watermark: "logo.png", WatermarkPosition: config.WatermarkBottomLeft,
+				Crop: &config.Region{Width: 960, Height: 540, X: 0, Y: 0}, WatermarkSize: config.WatermarkSize{Pixels: 120}},
+			"[0:v:0]crop=960:540:0:0[base];[1:v]scale=120:-1[wm];[base][wm]overlay=x=10:y=main_h-overlay_h-10[vout]",
+		},
+		{
+			"after the audio file input, uploaded for vaapi",
+			config.ProcessingConfig{Watermark: "logo.png", WatermarkPosition: config.WatermarkCenter, AudioFile: "music.mp3", Codec: "h264_vaapi"},
+			"[0:v:0]null[base];[base][2:v]overlay=x=(main_w-overlay_w)/2:y=(main_h-overlay_h)/2,format=nv12,hwupload[vout]",
+		},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upload []string
+			if tt.cfg.Codec == "h264_vaapi" {
+				upload = []string{"format=nv12", "hwupload"}
+			}
+			if got := cb.watermarkGraph(&tt.cfg, cb.videoFilters(&tt.cfg), upload); got != tt.want {
+				t.Errorf("watermarkGraph()\n got: %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapChaptersAndMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProcessingConfig
+		want bool
+	}{
+		{"single input", config.ProcessingConfig{}, false},
+		{"audio file", config.ProcessingConfig{AudioFile: "music.mp3"}, true},
+		{"watermark", config.ProcessingConfig{Watermark: "logo.png"}, true},
+		{"silent track", config.ProcessingConfig{AddSilence: true}, true},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Acceleration, cfg.Codec, cfg.Preset, cfg.Quality = "none", "libx264", "medium", 23
+			cfg.InputPath, cfg.OutputPath = "in.mkv", "out.mkv"
+			cfg.CopyChapters, cfg.CopyMetadata = true, true
+
+			args := cb.BuildFFmpegCommand(&cfg)
+			if got := slices.Contains(args, "-map_chapters"); got != tt.want {
+				t.Errorf("-map_chapters in %q = %v, want %v", args, got, tt.want)
+			}
+			if got := slices.Contains(args, "-map_metadata"); got != tt.want {
+				t.Errorf("-map_metadata in %q = %v, want %v", args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package encoder
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/utils"
+)
+
+// crossVendorOrder is the order other GPU vendors' encoders are tried in after a hardware
+// encode fails, ahead of software; discrete GPUs come before integrated ones
+var crossVendorOrder = []string{"nvidia", "intel", "amd", "apple"}
+
+// SetGPUs gives the manager the detected GPUs, so a failed hardware encode can be retried
+// on another vendor's encoder before dropping to software
+func (fm *FallbackManager) SetGPUs(gpus []utils.GPUInfo) {
+	fm.gpus = gpus
+}
+
+// crossVendorMethods returns the failed encode rebuilt for each detected GPU of another
+// vendor, keeping the codec family and every other setting. Only H.264 and HEVC hardware
+// encodes are retried this way, each encoder is tried once even with several GPUs of the
+// same vendor, and GPUs the retry can't be pointed at are skipped.
+func (fm *FallbackManager) crossVendorMethods(cfg *config.ProcessingConfig) []FallbackMethod {
+	failed := CodecVendors(cfg.Codec)
+	if cfg.NoCrossVendor || cfg.CopyVideo || failed == nil ||
+		!strings.HasPrefix(cfg.Codec, "h264_") && !strings.HasPrefix(cfg.Codec, "hevc_") {
+		return nil
+	}
+
+	gpus := slices.Clone(fm.gpus)
+	slices.SortStableFunc(gpus, func(a, b utils.GPUInfo) int {
+		if a.Integrated != b.Integrated {
+			if a.Integrated {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(vendorRank(a.Vendor), vendorRank(b.Vendor))
+	})
+
+	enc := New()
+	cb := NewCommandBuilder()
+	var methods []FallbackMethod
+	tried := map[string]bool{cfg.Codec: true}
+	for _, gpu := range gpus {
+		if vendorRank(gpu.Vendor) == len(crossVendorOrder) || slices.Contains(failed, gpu.Vendor) {
+			continue
+		}
+		acceleration, codec, preset := enc.ConfigureForGPU(gpu)
+		if acceleration == "none" {
+			continue
+		}
+		if IsHEVC(cfg.Codec) {
+			codec = HEVCEncoder(codec)
+		}
+		if codec == "" || tried[codec] {
+			continue
+		}
+
+		retryCfg := *cfg
+		retryCfg.SetHardwareEncoding(acceleration, codec, preset)
+		retryCfg.Quality = ConvertQuality(cfg.Quality, cfg.Codec, codec)
+		if !fm.targetGPU(&retryCfg, gpu) {
+			continue
+		}
+		tried[codec] = true
+		methods = append(methods, FallbackMethod{
+			Description: fmt.Sprintf("Hardware encoding (%s) on %s %s", codec, utils.VendorName(gpu.Vendor), gpu.Model),
+			Codec:       codec,
+			Args:        cb.BuildFFmpegCommand(&retryCfg),
+		})
+	}
+	return methods
+}
+
+// targetGPU points the retry's encoder at the GPU, reporting false when it can't be. CUDA
+// takes the nvidia-smi index and VAAPI and QSV the GPU's render node; the other APIs open
+// their default device, which is only known to be this GPU when no other detected GPU
+// uses the same API. VAAPI without a render node has no device to open at all.
+func (fm *FallbackManager) targetGPU(cfg *config.ProcessingConfig, gpu utils.GPUInfo) bool {
+	cfg.GPUDevice, cfg.RenderNode = "", ""
+	switch cfg.Acceleration {
+	case "cuda":
+		cfg.GPUDevice = gpu.DeviceIndex
+	case "vaapi", "qsv":
+		cfg.RenderNode = utils.RenderNode(gpu.PCIAddress)
+	}
+	if cfg.GPUDevice != "" || cfg.RenderNode != "" {
+		return true
+	}
+	if cfg.Acceleration == "vaapi" {
+		return false
+	}
+
+	enc := New()
+	sharing := 0
+	for _, other := range fm.gpus {
+		if acceleration, _, _ := enc.ConfigureForGPU(other); acceleration == cfg.Acceleration {
+			sharing++
+		}
+	}
+	return sharing <= 1
+}
+
+// vendorRank returns the vendor's position in crossVendorOrder, or its length for vendors
+// without a hardware encoder
+func vendorRank(vendor string) int {
+	if i := slices.Index(crossVendorOrder, vendor); i >= 0 {
+		return i
+	}
+	return len(crossVendorOrder)
+}
@@ -0,0 +1,136 @@
+package encoder
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+
+	"video_processing/utils"
+)
+
+// UsedDevice is the hardware FFmpeg's log shows an encode running on
+type UsedDevice struct {
+	Vendor string // "nvidia", "intel" or "amd"; empty when the log doesn't say
+	Name   string // GPU or driver name, e.g. "NVIDIA GeForce RTX 3080" or "iHD"
+	Node   string // DRM render node, e.g. /dev/dri/renderD129
+}
+
+// String describes the device for messages and reports
+func (d UsedDevice) String() string {
+	var parts []string
+	for _, part := range []string{utils.VendorName(d.Vendor), d.Name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if d.Node != "" {
+		parts = append(parts, "("+d.Node+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// Device selection lines FFmpeg and libva log. Old FFmpeg builds let libva print the driver it
+// loads as "libva info: ..."; newer ones log it as "libva: ..." at verbose level, where the
+// VAAPI driver, render node and NVENC GPU lines are too.
+var (
+	libvaDriverLine = regexp.MustCompile(`libva(?: info)?: Trying to open \S*/(\w+)_drv_video\.so`)
+	vaapiDriverLine = regexp.MustCompile(`(?m)VAAPI driver: (.+?)\.?\s*$`)
+	vaDisplayLine   = regexp.MustCompile(`(?m)Opened VA display via DRM: (\S+?)\.?\s*$`)
+	nvencGPULine    = regexp.MustCompile(`(?m)\[ GPU #\d+ - < (.+?) > has Compute SM`)
+)
+
+// DeviceLogArgs makes FFmpeg log at verbose level, where it names the device an encode opens,
+// and tag each line with its level so a LevelFilter can hide what it wouldn't show by default
+var DeviceLogArgs = []string{"-loglevel", "level+verbose"}
+
+// levelTag matches the "[level] " tag -loglevel level+... puts after a line's context prefixes
+var levelTag = regexp.MustCompile(`^((?:\[[^\]]+ @ (?:0x)?[0-9a-fA-F]+\] )*)\[(trace|debug|verbose|info|warning|error|fatal|panic)\] `)
+
+// LevelFilter passes the lines of a DeviceLogArgs log at info level and above on to W without
+// their level tag, as FFmpeg prints them by default, and keeps the verbose lines in Verbose.
+// Untagged output, such as FFmpeg's stats line after the first, is passed on unchanged.
+type LevelFilter struct {
+	W       io.Writer
+	Verbose bytes.Buffer
+	line    []byte
+}
+
+// Write splits the log into lines, ending with a newline or, for the stats line, a carriage return
+func (f *LevelFilter) Write(b []byte) (int, error) {
+	for i, c := range b {
+		f.line = append(f.line, c)
+		if c != '\n' && c != '\r' {
+			continue
+		}
+		if err := f.flush(); err != nil {
+			return i, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush passes on a final line that has no line ending
+func (f *LevelFilter) Flush() error {
+	if len(f.line) == 0 {
+		return nil
+	}
+	return f.flush()
+}
+
+func (f *LevelFilter) flush() error {
+	line := f.line
+	f.line = f.line[:0]
+
+	m := levelTag.FindSubmatchIndex(line)
+	if m == nil {
+		_, err := f.W.Write(line)
+		return err
+	}
+	switch string(line[m[4]:m[5]]) {
+	case "trace", "debug", "verbose":
+		f.Verbose.Write(line)
+		return nil
+	}
+	shown := append(append([]byte{}, line[:m[3]]...), line[m[1]:]...)
+	_, err := f.W.Write(shown)
+	return err
+}
+
+// DeviceFromStderr finds the device FFmpeg opened for an encode in its stderr, reporting
+// false when the log names none
+func DeviceFromStderr(stderr []byte) (UsedDevice, bool) {
+	log := string(stderr)
+	var d UsedDevice
+	// NVENC names each GPU it checks; without -gpu it moves on from one that can't encode
+	if ms := nvencGPULine.FindAllStringSubmatch(log, -1); ms != nil {
+		d.Vendor, d.Name = "nvidia", ms[len(ms)-1][1]
+	}
+	if m := vaapiDriverLine.FindStringSubmatch(log); m != nil {
+		d.Name = m[1]
+	} else if ms := libvaDriverLine.FindAllStringSubmatch(log, -1); ms != nil {
+		// libva tries drivers in turn; the last one it opened is the one in use
+		d.Name = ms[len(ms)-1][1]
+	}
+	if m := vaDisplayLine.FindStringSubmatch(log); m != nil {
+		d.Node = m[1]
+	}
+	if d.Vendor == "" {
+		d.Vendor = driverVendor(d.Name)
+	}
+	return d, d.Name != "" || d.Node != ""
+}
+
+// driverVendor maps a VAAPI driver name or description to its GPU vendor
+func driverVendor(driver string) string {
+	lower := strings.ToLower(driver)
+	switch {
+	case lower == "ihd" || lower == "i965" || strings.Contains(lower, "intel"):
+		return "intel"
+	case lower == "radeonsi" || strings.Contains(lower, "amd") || strings.Contains(lower, "radeon"):
+		return "amd"
+	case lower == "nvidia" || lower == "nouveau" || strings.Contains(lower, "nvidia"):
+		return "nvidia"
+	}
+	return ""
+}
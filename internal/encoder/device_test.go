@@ -0,0 +1,89 @@
+package encoder
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeviceFromStderr(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   UsedDevice
+		found  bool
+	}{
+		{"libva intel", "libva info: VA-API version 1.20.0\nlibva info: Trying to open /usr/lib/x86_64-linux-gnu/dri/iHD_drv_video.so\nlibva info: va_openDriver() returns 0",
+			UsedDevice{Vendor: "intel", Name: "iHD"}, true},
+		{"libva driver retry", "libva info: Trying to open /usr/lib/dri/iHD_drv_video.so\nlibva info: va_openDriver() returns -1\nlibva info: Trying to open /usr/lib/dri/i965_drv_video.so",
+			UsedDevice{Vendor: "intel", Name: "i965"}, true},
+		{"libva amd", "libva info: Trying to open /usr/lib/x86_64-linux-gnu/dri/radeonsi_drv_video.so",
+			UsedDevice{Vendor: "amd", Name: "radeonsi"}, true},
+		{"verbose vaapi", "[AVHWDeviceContext @ 0x5601] Opened VA display via DRM: /dev/dri/renderD129.\n" +
+			"[AVHWDeviceContext @ 0x5601] VAAPI driver: Mesa Gallium driver 23.2.1 for AMD Radeon RX 6600 (navi23, LLVM 15.0.7).",
+			UsedDevice{Vendor: "amd", Name: "Mesa Gallium driver 23.2.1 for AMD Radeon RX 6600 (navi23, LLVM 15.0.7)", Node: "/dev/dri/renderD129"}, true},
+		{"verbose nvenc", "[h264_nvenc @ 0x55d0] [ GPU #0 - < NVIDIA GeForce RTX 3080 > has Compute SM 8.6 ]",
+			UsedDevice{Vendor: "nvidia", Name: "NVIDIA GeForce RTX 3080"}, true},
+		{"ffmpeg 6 vaapi", "[AVHWDeviceContext @ 0x5581c5a1e440] [verbose] libva: VA-API version 1.20.0\n" +
+			"[AVHWDeviceContext @ 0x5581c5a1e440] [verbose] libva: Trying to open /usr/lib/x86_64-linux-gnu/dri/iHD_drv_video.so\n" +
+			"[AVHWDeviceContext @ 0x5581c5a1e440] [verbose] libva: Found init function __vaDriverInit_1_20\n" +
+			"[AVHWDeviceContext @ 0x5581c5a1e440] [verbose] libva: va_openDriver() returns 0\n" +
+			"[AVHWDeviceContext @ 0x5581c5a1e440] [verbose] Initialised VAAPI connection: version 1.20\n" +
+			"[AVHWDeviceContext @ 0x5581c5a1e440] [verbose] VAAPI driver: Intel iHD driver for Intel(R) Gen Graphics - 24.1.0 ().\n",
+			UsedDevice{Vendor: "intel", Name: "Intel iHD driver for Intel(R) Gen Graphics - 24.1.0 ()"}, true},
+		{"ffmpeg 7 libva only", "[AVHWDeviceContext @ 0x55d0c3a0] [verbose] libva: Trying to open /usr/lib/dri/radeonsi_drv_video.so\n",
+			UsedDevice{Vendor: "amd", Name: "radeonsi"}, true},
+		{"ffmpeg 7 nvenc", "[h264_nvenc @ 0x5633b1f0e8c0] [verbose] Loaded Nvenc version 12.2\n" +
+			"[h264_nvenc @ 0x5633b1f0e8c0] [verbose] Nvenc initialized successfully\n" +
+			"[h264_nvenc @ 0x5633b1f0e8c0] [verbose] 2 CUDA capable devices found\n" +
+			"[h264_nvenc @ 0x5633b1f0e8c0] [verbose] [ GPU #0 - < NVIDIA GeForce GT 1030 > has Compute SM 6.1 ]\n" +
+			"[h264_nvenc @ 0x5633b1f0e8c0] [verbose] Codec not supported\n" +
+			"[h264_nvenc @ 0x5633b1f0e8c0] [verbose] [ GPU #1 - < NVIDIA GeForce RTX 4070 > has Compute SM 8.9 ]\n",
+			UsedDevice{Vendor: "nvidia", Name: "NVIDIA GeForce RTX 4070"}, true},
+		{"nothing named", "frame=  100 fps= 50 q=23.0 size=    1024kB time=00:00:04.00", UsedDevice{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := DeviceFromStderr([]byte(tt.stderr))
+			if got != tt.want || found != tt.found {
+				t.Errorf("DeviceFromStderr() = %+v, %v, want %+v, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestLevelFilter(t *testing.T) {
+	log := "[info] ffmpeg version 7.0.1 Copyright (c) 2000-2024 the FFmpeg developers\n" +
+		"[AVHWDeviceContext @ 0x5581c5a1e440] [verbose] libva: VA-API version 1.20.0\n" +
+		"[in#0/mov @ 0x5581c5a1f000] [verbose] Input stream #0:0 (video): 240 packets read\n" +
+		"[vost#0:0/h264_vaapi @ 0x5581c5a2b2c0] [warning] Driver does not support some wanted packed headers\n" +
+		"[info] frame=  120 fps= 60 q=-0.0 size=    1024KiB time=00:00:04.00 bitrate=2097.2kbits/s speed=2.0x    \r" +
+		"frame=  240 fps= 60 q=-0.0 size=    2048KiB time=00:00:08.00 bitrate=2097.2kbits/s speed=2.0x    \r" +
+		"[error] Conversion failed!"
+
+	var shown bytes.Buffer
+	f := &LevelFilter{W: &shown}
+	// Split mid-line, as pipe reads do
+	for _, part := range []string{log[:50], log[50:300], log[300:]} {
+		if _, err := f.Write([]byte(part)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantShown := "ffmpeg version 7.0.1 Copyright (c) 2000-2024 the FFmpeg developers\n" +
+		"[vost#0:0/h264_vaapi @ 0x5581c5a2b2c0] Driver does not support some wanted packed headers\n" +
+		"frame=  120 fps= 60 q=-0.0 size=    1024KiB time=00:00:04.00 bitrate=2097.2kbits/s speed=2.0x    \r" +
+		"frame=  240 fps= 60 q=-0.0 size=    2048KiB time=00:00:08.00 bitrate=2097.2kbits/s speed=2.0x    \r" +
+		"Conversion failed!"
+	if shown.String() != wantShown {
+		t.Errorf("shown log = %q, want %q", shown.String(), wantShown)
+	}
+	wantVerbose := "[AVHWDeviceContext @ 0x5581c5a1e440] [verbose] libva: VA-API version 1.20.0\n" +
+		"[in#0/mov @ 0x5581c5a1f000] [verbose] Input stream #0:0 (video): 240 packets read\n"
+	if f.Verbose.String() != wantVerbose {
+		t.Errorf("verbose log = %q, want %q", f.Verbose.String(), wantVerbose)
+	}
+}
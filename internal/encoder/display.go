@@ -0,0 +1,156 @@
+package encoder
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// FormatArgs joins FFmpeg args into a readable, shell-pasteable command string. Arguments
+// with spaces or shell metacharacters are quoted, and credentials in URLs, RTMP stream
+// keys, HTTP header values and cookie values are redacted.
+func FormatArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case i > 0 && args[i-1] == "-headers":
+			arg = redactHeaders(arg)
+		case i > 0 && args[i-1] == "-cookies":
+			arg = redactCookies(arg)
+		default:
+			arg = RedactStreamURL(arg)
+		}
+		quoted[i] = quoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quoteArg wraps an argument in double quotes when a shell would otherwise split or expand it
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'$`\\;&|<>()*?[]#~!") {
+		return arg
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(arg) + `"`
+}
+
+// secretParams are URL query parameters whose values are redacted
+var secretParams = []string{"key", "token", "password", "passphrase", "secret", "auth", "streamid"}
+
+// RedactURL hides the password and secret query parameters of a URL argument, leaving
+// anything that isn't a URL unchanged
+func RedactURL(arg string) string {
+	if !strings.Contains(arg, "://") {
+		return arg
+	}
+	u, err := url.Parse(arg)
+	if err != nil {
+		return arg
+	}
+
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), "REDACTED")
+	}
+
+	query := u.Query()
+	redacted := false
+	for name := range query {
+		for _, secret := range secretParams {
+			if strings.EqualFold(name, secret) {
+				query.Set(name, "REDACTED")
+				redacted = true
+			}
+		}
+	}
+	if redacted {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// RedactStreamURL is RedactURL that also hides an RTMP stream key: the path after the
+// application name, as in rtmp://live.example.com/app/KEY
+func RedactStreamURL(rawURL string) string {
+	if key := rtmpStreamKey(rawURL); key != "" {
+		if u, err := url.Parse(rawURL); err == nil {
+			u.Path = strings.TrimSuffix(u.Path, key) + "REDACTED"
+			rawURL = u.String()
+		}
+	}
+	return RedactURL(rawURL)
+}
+
+// RedactStreamDiagnostics hides the streaming URL's secrets wherever FFmpeg's messages
+// repeat them, whole or in part (e.g. "rtmp://host/app/KEY: Input/output error")
+func RedactStreamDiagnostics(text, rawURL string) string {
+	if rawURL == "" {
+		return text
+	}
+	text = strings.ReplaceAll(text, rawURL, RedactStreamURL(rawURL))
+	for _, secret := range urlSecrets(rawURL) {
+		text = strings.ReplaceAll(text, secret, "REDACTED")
+	}
+	return text
+}
+
+// rtmpStreamKey returns the stream key of an rtmp:// or rtmps:// URL, or ""
+func rtmpStreamKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (!strings.EqualFold(u.Scheme, "rtmp") && !strings.EqualFold(u.Scheme, "rtmps")) {
+		return ""
+	}
+	_, key, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	return key
+}
+
+// urlSecrets returns the secret parts of a URL that RedactStreamURL hides: the password,
+// the values of secret query parameters and an RTMP stream key
+func urlSecrets(rawURL string) []string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	var secrets []string
+	if password, ok := u.User.Password(); ok && password != "" {
+		secrets = append(secrets, password)
+	}
+	for name, values := range u.Query() {
+		for _, secret := range secretParams {
+			if strings.EqualFold(name, secret) {
+				secrets = append(secrets, values...)
+			}
+		}
+	}
+	if key := rtmpStreamKey(rawURL); key != "" {
+		secrets = append(secrets, key)
+	}
+	return slices.DeleteFunc(secrets, func(s string) bool { return s == "" })
+}
+
+// redactHeaders hides the values of a -headers block ("Name: value\r\n" lines), keeping the names
+func redactHeaders(arg string) string {
+	lines := strings.Split(strings.TrimSuffix(arg, "\r\n"), "\r\n")
+	for i, line := range lines {
+		if name, _, ok := strings.Cut(line, ":"); ok {
+			lines[i] = name + ": REDACTED"
+		}
+	}
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// redactCookies hides the values of a -cookies list (newline-separated Set-Cookie strings),
+// keeping cookie names and attributes such as domain and path
+func redactCookies(arg string) string {
+	cookies := strings.Split(arg, "\n")
+	for i, cookie := range cookies {
+		pair, attrs, hasAttrs := strings.Cut(cookie, ";")
+		if name, _, ok := strings.Cut(pair, "="); ok {
+			cookies[i] = name + "=REDACTED"
+			if hasAttrs {
+				cookies[i] += ";" + attrs
+			}
+		}
+	}
+	return strings.Join(cookies, "\n")
+}
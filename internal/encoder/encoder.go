@@ -2,6 +2,8 @@ package encoder
 
 import (
 	"runtime"
+	"strings"
+
 	"video_processing/utils"
 )
 
@@ -22,7 +24,86 @@ func (e *Encoder) ConfigureForGPU(gpu utils.GPUInfo) (string, string, string) {
 	return acceleration, codec, preset
 }
 
+// ConfigureWithAcceleration configures encoding for a user-selected acceleration method,
+// picking the encoder that matches the GPU vendor for decode-only APIs such as dxva2
+func (e *Encoder) ConfigureWithAcceleration(gpu utils.GPUInfo, acceleration string) (string, string, string) {
+	codec := e.getCodec(acceleration)
+	preset := e.getPreset(acceleration)
+
+	if isWindowsDecodeAPI(acceleration) {
+		switch gpu.Vendor {
+		case "nvidia":
+			codec, preset = "h264_nvenc", "medium"
+		case "intel":
+			codec, preset = "h264_qsv", "medium"
+		default:
+			codec, preset = "h264_amf", "balanced"
+		}
+	}
+
+	return acceleration, codec, preset
+}
+
+// ConfigureForCodec configures encoding around a user-chosen encoder, picking the
+// acceleration method and preset that go with it. Software encoders get no acceleration.
+func (e *Encoder) ConfigureForCodec(codec string) (string, string, string) {
+	var acceleration string
+	switch {
+	case strings.HasSuffix(codec, "_nvenc"):
+		acceleration = "cuda"
+	case strings.HasSuffix(codec, "_qsv"):
+		acceleration = "qsv"
+	case strings.HasSuffix(codec, "_vaapi"):
+		acceleration = "vaapi"
+	case strings.HasSuffix(codec, "_videotoolbox"):
+		acceleration = "videotoolbox"
+	case strings.HasSuffix(codec, "_amf") && runtime.GOOS == "windows":
+		acceleration = "d3d11va"
+	case strings.HasSuffix(codec, "_amf") && runtime.GOOS == "linux":
+		acceleration = "amf"
+	default:
+		acceleration = "none"
+	}
+
+	preset := e.getPreset(acceleration)
+	if strings.HasSuffix(codec, "_amf") {
+		preset = "balanced"
+	}
+	return acceleration, codec, preset
+}
+
+// CodecVendors returns the GPU vendors whose hardware runs the encoder, or nil for software encoders
+func CodecVendors(codec string) []string {
+	switch {
+	case strings.HasSuffix(codec, "_nvenc"):
+		return []string{"nvidia"}
+	case strings.HasSuffix(codec, "_qsv"):
+		return []string{"intel"}
+	case strings.HasSuffix(codec, "_vaapi"):
+		return []string{"intel", "amd"}
+	case strings.HasSuffix(codec, "_amf"):
+		return []string{"amd"}
+	case strings.HasSuffix(codec, "_videotoolbox"):
+		return []string{"apple"}
+	}
+	return nil
+}
+
+// isWindowsDecodeAPI reports whether the acceleration is a Windows DirectX decode API
+// that pairs with a vendor encoder rather than providing one itself
+func isWindowsDecodeAPI(acceleration string) bool {
+	switch acceleration {
+	case "d3d11va", "dxva2", "d3d12va":
+		return true
+	}
+	return false
+}
+
 func (e *Encoder) getAccelerationMethod(gpu utils.GPUInfo) string {
+	// WSL has no render nodes for VAAPI; only NVIDIA's CUDA path reaches the GPU
+	if utils.IsWSL() && gpu.Vendor != "nvidia" {
+		return "none"
+	}
 	switch gpu.Vendor {
 	case "nvidia":
 		return "cuda"
@@ -53,7 +134,7 @@ func (e *Encoder) getCodec(acceleration string) string {
 		return "h264_vaapi"
 	case "videotoolbox":
 		return "h264_videotoolbox"
-	case "d3d11va":
+	case "d3d11va", "dxva2", "d3d12va", "amf":
 		return "h264_amf"
 	default:
 		return "libx264"
@@ -70,7 +151,7 @@ func (e *Encoder) getPreset(acceleration string) string {
 		return "ultrafast"
 	case "videotoolbox":
 		return "balanced"
-	case "d3d11va":
+	case "d3d11va", "dxva2", "d3d12va", "amf":
 		return "balanced"
 	default:
 		return "medium"
@@ -0,0 +1,29 @@
+package encoder
+
+import "testing"
+
+func TestConfigureForCodec(t *testing.T) {
+	tests := []struct {
+		codec        string
+		acceleration string
+		preset       string
+	}{
+		{"h264_qsv", "qsv", "medium"},
+		{"hevc_nvenc", "cuda", "medium"},
+		{"h264_vaapi", "vaapi", "ultrafast"},
+		{"hevc_videotoolbox", "videotoolbox", "balanced"},
+		{"libx265", "none", "medium"},
+		{"libsvtav1", "none", "medium"},
+	}
+
+	e := New()
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			acceleration, codec, preset := e.ConfigureForCodec(tt.codec)
+			if acceleration != tt.acceleration || codec != tt.codec || preset != tt.preset {
+				t.Errorf("ConfigureForCodec(%s) = %s, %s, %s; want %s, %s, %s",
+					tt.codec, acceleration, codec, preset, tt.acceleration, tt.codec, tt.preset)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrAllFallbacksFailed is returned when every fallback encoding method fails
+var ErrAllFallbacksFailed = errors.New("all fallback encoding methods failed")
+
+// maxStderrExcerpt bounds how much of FFmpeg's stderr is kept on an EncodeError
+const maxStderrExcerpt = 2048
+
+// EncodeError reports a failed FFmpeg run along with its exit code and the tail of its stderr
+type EncodeError struct {
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+// NewEncodeError builds an EncodeError from an exec error and the captured stderr
+func NewEncodeError(err error, stderr []byte) *EncodeError {
+	exitCode := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	}
+
+	return &EncodeError{
+		ExitCode: exitCode,
+		Stderr:   stderrExcerpt(stderr),
+		Err:      err,
+	}
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("ffmpeg exited with code %d: %v", e.ExitCode, e.Err)
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// stderrExcerpt keeps the last few lines of stderr, which is where FFmpeg reports the failure
+func stderrExcerpt(stderr []byte) string {
+	s := strings.TrimSpace(string(stderr))
+	if len(s) <= maxStderrExcerpt {
+		return s
+	}
+
+	s = s[len(s)-maxStderrExcerpt:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
@@ -4,211 +4,187 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"strings"
+	"slices"
 	"video_processing/internal/config"
+	"video_processing/internal/style"
+	"video_processing/utils"
 )
 
 // FallbackMethod represents a fallback encoding method
 type FallbackMethod struct {
 	Description string
+	Codec       string // video encoder the method uses, for the report
 	Args        []string
 }
 
 // FallbackManager handles fallback encoding strategies
-type FallbackManager struct{}
+type FallbackManager struct {
+	ffmpegPath string
+	gpus       []utils.GPUInfo
+}
 
-// NewFallbackManager creates a new fallback manager
-func NewFallbackManager() *FallbackManager {
-	return &FallbackManager{}
+// NewFallbackManager creates a new fallback manager that runs the given ffmpeg binary
+func NewFallbackManager(ffmpegPath string) *FallbackManager {
+	return &FallbackManager{
+		ffmpegPath: ffmpegPath,
+	}
 }
 
-// TryFallbacks attempts fallback encoding methods with live FFmpeg logs
-func (fm *FallbackManager) TryFallbacks(config *config.ProcessingConfig) error {
-	fallbacks := fm.getFallbackMethods(config)
+// TryFallbacks attempts fallback encoding methods with live FFmpeg logs, returning the
+// method that succeeded. The other detected GPU vendors' encoders are
+// tried before software.
+func (fm *FallbackManager) TryFallbacks(config *config.ProcessingConfig) (FallbackMethod, error) {
+	fallbacks := append(fm.crossVendorMethods(config), fm.getFallbackMethods(config)...)
 
 	for i, fallback := range fallbacks {
-		fmt.Printf("\n🔁 Attempt %d/%d: %s\n", i+1, len(fallbacks), fallback.Description)
-		fmt.Printf("▶️ Running: ffmpeg %s\n", formatArgsForDisplay(fallback.Args))
+		fmt.Printf("\n%s Attempt %d/%d: %s\n", style.Retry, i+1, len(fallbacks), fallback.Description)
+		fmt.Printf("%s Running: %s %s\n", style.Run, fm.ffmpegPath, FormatArgs(fallback.Args))
 
-		cmd := exec.Command("ffmpeg", fallback.Args...)
+		cmd := exec.Command(fm.ffmpegPath, fallback.Args...)
 		cmd.Stderr = os.Stderr // FFmpeg logs (progress, errors)
 		cmd.Stdout = os.Stdout // Optional: capture output if needed
 
 		if err := cmd.Run(); err != nil {
-			fmt.Printf("❌ Fallback %d failed: %v\n", i+1, err)
+			fmt.Printf("%s Fallback %d failed: %v\n", style.Error, i+1, err)
 			continue
 		}
 
-		fmt.Printf("✅ Fallback method succeeded: %s\n", fallback.Description)
-		return nil
+		fmt.Printf("%s Fallback method succeeded: %s\n", style.OK, fallback.Description)
+		return fallback, nil
 	}
 
-	return fmt.Errorf("❌ All fallback encoding methods failed")
+	return FallbackMethod{}, ErrAllFallbacksFailed
 }
 
-// getFallbackMethods returns a list of fallback encoding strategies
+// getFallbackMethods returns a list of fallback encoding strategies. Each is the failed
+// command rebuilt for libx264, so trims, filters, extra inputs, stream selection and muxer
+// settings carry over and only the encoder changes.
 func (fm *FallbackManager) getFallbackMethods(config *config.ProcessingConfig) []FallbackMethod {
-	// Build base arguments for software encoding
-	baseArgs := []string{
-		"-i", config.InputPath,
-		"-c:v", "libx264",
-		"-fflags", "nobuffer",
-		"-flags", "low_delay",
-		"-fflags", "+discardcorrupt",
-		"-analyzeduration", "0",
-		"-probesize", "32",
-		"-preset", "ultrafast",
-		"-tune", "zerolatency",
-		"-crf", fmt.Sprintf("%d", config.Quality),
-		"-c:a", "copy",
+	software := softwareConfig(config)
+	if IsStreamingURL(config.OutputPath) {
+		return fm.getStreamingFallbackMethods(software)
+	}
+	if config.SegmentTime != "" {
+		return fm.getSegmentFallbackMethods(software)
+	}
+
+	cb := NewCommandBuilder()
+	methods := []FallbackMethod{{
+		Description: fmt.Sprintf("Software encoding (%s) with auto-detected output format", software.Codec),
+		Codec:       software.Codec,
+		Args:        cb.BuildFFmpegCommand(software),
+	}}
+
+	// WebM can't switch to MP4 under its own name
+	switch cb.OutputFormat(config.OutputPath) {
+	case "mp4", "hls", "dash", "webm":
+	default:
+		methods = append(methods, FallbackMethod{
+			Description: fmt.Sprintf("Software encoding (%s) with MP4 format fallback", software.Codec),
+			Codec:       software.Codec,
+			Args:        fm.addMP4Fallback(cb.BuildFFmpegCommand(software)),
+		})
 	}
 
-	// Add output format based on output path
-	argsWithFormat := fm.addOutputFormat(baseArgs, config.OutputPath)
+	// The minimal retry drops the optional tuning but nothing that shapes the output
+	minimal := *software
+	minimal.Preset = "ultrafast"
+	minimal.HQTuning = false
+	minimal.Threads = 0
+	if args := cb.BuildFFmpegCommand(&minimal); !slices.Equal(args, methods[0].Args) {
+		methods = append(methods, FallbackMethod{
+			Description: "Basic software encoding (minimal options)",
+			Codec:       software.Codec,
+			Args:        args,
+		})
+	}
+	return methods
+}
 
-	// Add final output options
-	finalArgs := append(argsWithFormat,
-		"-movflags", "+faststart",
-		"-y", config.OutputPath,
-	)
+// getStreamingFallbackMethods returns software retries that publish to the same streaming URL.
+// Unlike the file fallbacks they never switch to MP4 or drop the protocol's muxer, since the
+// endpoint could not receive such output. They use ultrafast to keep up in real time.
+func (fm *FallbackManager) getStreamingFallbackMethods(software *config.ProcessingConfig) []FallbackMethod {
+	cb := NewCommandBuilder()
+	stream := *software
+	stream.Preset = "ultrafast"
+
+	withAAC := stream
+	if withAAC.AudioCodec == "" || withAAC.AudioCodec == "copy" {
+		withAAC.AudioCodec = "aac"
+		if withAAC.AudioBitrate == "" {
+			withAAC.AudioBitrate = "128k"
+		}
+	}
 
 	return []FallbackMethod{
 		{
-			Description: "Software encoding (libx264) with auto-detected output format",
-			Args:        finalArgs,
-		},
-		{
-			Description: "Software encoding (libx264) with MP4 format fallback",
-			Args: append(fm.addMP4Fallback(baseArgs),
-				"-movflags", "+faststart",
-				"-y", config.OutputPath,
-			),
+			Description: fmt.Sprintf("Software encoding (%s) to the same stream", software.Codec),
+			Codec:       software.Codec,
+			Args:        cb.BuildFFmpegCommand(&stream),
 		},
 		{
-			Description: "Basic software encoding (minimal options)",
-			Args: []string{
-				"-i", config.InputPath,
-				"-c:v", "libx264",
-				"-preset", "ultrafast",
-				"-crf", fmt.Sprintf("%d", config.Quality),
-				"-c:a", "copy",
-				"-y", config.OutputPath,
-			},
+			Description: fmt.Sprintf("Software encoding (%s) with AAC audio to the same stream", software.Codec),
+			Codec:       software.Codec,
+			Args:        cb.BuildFFmpegCommand(&withAAC),
 		},
 	}
 }
 
-// addOutputFormat adds the appropriate output format based on the output path/URL
-func (fm *FallbackManager) addOutputFormat(args []string, outputPath string) []string {
-	// Check if it's a streaming URL
-	if fm.isStreamingURL(outputPath) {
-		return fm.addStreamingFormat(args, outputPath)
+// getSegmentFallbackMethods returns a software retry that keeps the segmented output, so a
+// fallback never replaces the numbered files with a single large one
+func (fm *FallbackManager) getSegmentFallbackMethods(software *config.ProcessingConfig) []FallbackMethod {
+	return []FallbackMethod{
+		{
+			Description: fmt.Sprintf("Software encoding (%s) to the same segments", software.Codec),
+			Codec:       software.Codec,
+			Args:        NewCommandBuilder().BuildFFmpegCommand(software),
+		},
 	}
+}
 
-	// For file outputs, determine format from extension
-	ext := strings.ToLower(filepath.Ext(outputPath))
-	switch ext {
-	case ".mp4":
-		args = append(args, "-f", "mp4")
-	case ".mkv":
-		args = append(args, "-f", "matroska")
-	case ".avi":
-		args = append(args, "-f", "avi")
-	case ".mov":
-		args = append(args, "-f", "mov")
-	case ".webm":
-		args = append(args, "-f", "webm")
-	case ".flv":
-		args = append(args, "-f", "flv")
-	case ".ts":
-		args = append(args, "-f", "mpegts")
-	case ".m3u8":
-		args = append(args, "-f", "hls")
-		args = append(args, "-hls_time", "10")
-		args = append(args, "-hls_list_size", "0")
-	default:
-		// Default to mp4 if extension is unknown or missing
-		args = append(args, "-f", "mp4")
+// softwareConfig returns a copy of the config switched to libx264, or for WebM outputs to a
+// software encoder WebM can hold, with the quality converted to its scale and the video
+// re-encoded even if it was being copied
+func softwareConfig(cfg *config.ProcessingConfig) *config.ProcessingConfig {
+	codec := "libx264"
+	if OutputFormatArgs(cfg.OutputPath)[1] == "webm" {
+		codec = "libvpx-vp9"
+		if IsWebMCodec(cfg.Codec) {
+			codec = cfg.Codec
+		}
 	}
 
-	return args
+	software := *cfg
+	software.SetSoftwareEncoding()
+	software.Codec = codec
+	software.Preset = fallbackPreset(cfg)
+	software.Quality = ConvertQuality(cfg.Quality, cfg.Codec, codec)
+	software.CopyVideo = false
+	software.GPUDevice = ""
+	return &software
 }
 
-// addMP4Fallback adds MP4 format as a safe fallback
+// addMP4Fallback switches the output muxer, the last -f of the command, to MP4 with faststart
 func (fm *FallbackManager) addMP4Fallback(args []string) []string {
-	return append(args, "-f", "mp4")
-}
-
-// isStreamingURL checks if the output path is a streaming URL
-func (fm *FallbackManager) isStreamingURL(outputPath string) bool {
-	lower := strings.ToLower(outputPath)
-	return strings.HasPrefix(lower, "rtmp://") ||
-		strings.HasPrefix(lower, "rtmps://") ||
-		strings.HasPrefix(lower, "rtsp://") ||
-		strings.HasPrefix(lower, "rtsps://") ||
-		strings.HasPrefix(lower, "srt://") ||
-		strings.HasPrefix(lower, "udp://") ||
-		strings.HasPrefix(lower, "tcp://") ||
-		strings.HasPrefix(lower, "http://") ||
-		strings.HasPrefix(lower, "https://")
-}
-
-// addStreamingFormat adds the appropriate format for streaming URLs
-func (fm *FallbackManager) addStreamingFormat(args []string, outputPath string) []string {
-	lower := strings.ToLower(outputPath)
-
-	switch {
-	case strings.HasPrefix(lower, "rtmp://") || strings.HasPrefix(lower, "rtmps://"):
-		args = append(args, "-f", "flv")
-	case strings.HasPrefix(lower, "rtsp://") || strings.HasPrefix(lower, "rtsps://"):
-		// RTSP publisher/server mode
-		args = append(args, "-f", "rtsp")
-		// Add RTSP-specific options for publishing
-		args = append(args, "-rtsp_transport", "tcp")
-		args = append(args, "-muxdelay", "0.1")
-		// Optional: Set buffer size for low latency
-		args = append(args, "-bufsize", "64k")
-		args = append(args, "-maxrate", "2000k")
-		// RTSP publisher options
-		args = append(args, "-rtsp_flags", "listen")
-		args = append(args, "-timeout", "5000000")
-		args = append(args, "-stimeout", "5000000")
-	case strings.HasPrefix(lower, "srt://"):
-		args = append(args, "-f", "mpegts")
-	case strings.HasPrefix(lower, "udp://"):
-		args = append(args, "-f", "mpegts")
-	case strings.HasPrefix(lower, "tcp://"):
-		args = append(args, "-f", "mpegts")
-	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
-		// For HTTP streaming, check if it's HLS or DASH
-		if strings.Contains(lower, ".m3u8") {
-			args = append(args, "-f", "hls")
-			args = append(args, "-hls_time", "10")
-			args = append(args, "-hls_list_size", "0")
-		} else if strings.Contains(lower, ".mpd") {
-			args = append(args, "-f", "dash")
-		} else {
-			// Default HTTP streaming format
-			args = append(args, "-f", "mpegts")
+	out := slices.Clone(args)
+	for i := len(out) - 2; i >= 0; i-- {
+		if out[i] == "-f" {
+			out[i+1] = "mp4"
+			if !slices.Contains(out, "-movflags") {
+				out = slices.Insert(out, i+2, "-movflags", "+faststart")
+			}
+			break
 		}
-	default:
-		// Fallback to mpegts for unknown streaming protocols
-		args = append(args, "-f", "mpegts")
 	}
-
-	return args
+	return out
 }
 
-// formatArgsForDisplay joins FFmpeg args into a readable command string
-func formatArgsForDisplay(args []string) string {
-	var builder strings.Builder
-	for _, arg := range args {
-		if strings.ContainsAny(arg, " \t") {
-			builder.WriteString(fmt.Sprintf("\"%s\" ", arg))
-		} else {
-			builder.WriteString(arg + " ")
-		}
+// fallbackPreset returns the resolved -software-preset for file fallbacks, or ultrafast when
+// none was resolved. Streaming and the minimal fallback stay on ultrafast to keep up in real time.
+func fallbackPreset(cfg *config.ProcessingConfig) string {
+	if cfg.SoftwarePreset == "" || cfg.SoftwarePreset == config.SoftwarePresetAuto {
+		return "ultrafast"
 	}
-	return strings.TrimSpace(builder.String())
+	return cfg.SoftwarePreset
 }
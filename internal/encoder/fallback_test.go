@@ -0,0 +1,225 @@
+package encoder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"video_processing/internal/config"
+	"video_processing/utils"
+)
+
+func TestGetFallbackMethods(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProcessingConfig
+		want []FallbackMethod
+	}{
+		{
+			name: "matroska file",
+			cfg:  config.ProcessingConfig{Codec: "h264_nvenc", Acceleration: "cuda", InputPath: "in.mkv", OutputPath: "out.mkv", Quality: 23},
+			want: []FallbackMethod{
+				{
+					Description: "Software encoding (libx264) with auto-detected output format",
+					Codec:       "libx264",
+					Args: concat([]string{"-i", "in.mkv", "-c:v", "libx264", "-preset", "ultrafast", "-crf", "23", "-c:a", "copy"},
+						[]string{"-f", "matroska"}, outputOptions, []string{"out.mkv"}),
+				},
+				{
+					Description: "Software encoding (libx264) with MP4 format fallback",
+					Codec:       "libx264",
+					Args: concat([]string{"-i", "in.mkv", "-c:v", "libx264", "-preset", "ultrafast", "-crf", "23", "-c:a", "copy"},
+						[]string{"-f", "mp4"}, faststart, outputOptions, []string{"out.mkv"}),
+				},
+			},
+		},
+		{
+			name: "trim, replacement audio and resolved preset are kept",
+			cfg: config.ProcessingConfig{Codec: "h264_qsv", Acceleration: "qsv", InputPath: "in.mp4", OutputPath: "out.mp4", Quality: 23,
+				StartTime: "10", Duration: "30", AudioFile: "music.mp3", SoftwarePreset: "slow"},
+			want: []FallbackMethod{
+				{
+					Description: "Software encoding (libx264) with auto-detected output format",
+					Codec:       "libx264",
+					Args: concat([]string{"-ss", "10", "-i", "in.mp4", "-i", "music.mp3", "-t", "30", "-map", "0:v:0", "-map", "1:a:0"},
+						[]string{"-c:v", "libx264", "-preset", "slow", "-crf", "23", "-c:a", "copy", "-shortest"},
+						[]string{"-f", "mp4"}, faststart, outputOptions, []string{"out.mp4"}),
+				},
+				{
+					Description: "Basic software encoding (minimal options)",
+					Codec:       "libx264",
+					Args: concat([]string{"-ss", "10", "-i", "in.mp4", "-i", "music.mp3", "-t", "30", "-map", "0:v:0", "-map", "1:a:0"},
+						[]string{"-c:v", "libx264", "-preset", "ultrafast", "-crf", "23", "-c:a", "copy", "-shortest"},
+						[]string{"-f", "mp4"}, faststart, outputOptions, []string{"out.mp4"}),
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewFallbackManager("ffmpeg").getFallbackMethods(&tt.cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getFallbackMethods()\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetFallbackMethodsWebM(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config.ProcessingConfig
+		codec string
+	}{
+		{"vp9 kept", config.ProcessingConfig{Codec: "libvpx-vp9", Acceleration: "none", OutputPath: "out.webm.tmp", Quality: 31}, "libvpx-vp9"},
+		{"av1 kept", config.ProcessingConfig{Codec: "libsvtav1", Acceleration: "none", OutputPath: "out.webm", Quality: 35}, "libsvtav1"},
+		{"hardware switches to vp9", config.ProcessingConfig{Codec: "h264_nvenc", Acceleration: "cuda", OutputPath: "out.webm", Quality: 23}, "libvpx-vp9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.InputPath = "in.mp4"
+			methods := NewFallbackManager("ffmpeg").getFallbackMethods(&tt.cfg)
+			if len(methods) == 0 {
+				t.Fatal("no fallback methods")
+			}
+			for _, m := range methods {
+				args := strings.Join(m.Args, " ")
+				if m.Codec != tt.codec || !strings.Contains(args, "-c:v "+tt.codec) || !strings.Contains(args, "-f webm") {
+					t.Errorf("%s: codec %s, args %q; want %s into the webm muxer", m.Description, m.Codec, args, tt.codec)
+				}
+			}
+		})
+	}
+}
+
+func TestGetStreamingFallbackMethods(t *testing.T) {
+	base := []string{"-i", "in.mp4", "-c:v", "libx264", "-preset", "ultrafast", "-crf", "28"}
+
+	tests := []struct {
+		name   string
+		cfg    config.ProcessingConfig
+		format []string
+	}{
+		{
+			name:   "rtmp",
+			cfg:    config.ProcessingConfig{InputPath: "in.mp4", OutputPath: "rtmp://live.example.com/app/key", Quality: 28},
+			format: []string{"-f", "flv"},
+		},
+		{
+			name:   "srt",
+			cfg:    config.ProcessingConfig{InputPath: "in.mp4", OutputPath: "srt://example.com:9000", Quality: 28},
+			format: []string{"-f", "mpegts"},
+		},
+		{
+			name:   "rtsp publish",
+			cfg:    config.ProcessingConfig{InputPath: "in.mp4", OutputPath: "rtsp://example.com/live", Quality: 28, FFmpegMajor: 6},
+			format: []string{"-f", "rtsp", "-rtsp_transport", "tcp", "-timeout", "5000000"},
+		},
+	}
+
+	fm := NewFallbackManager("ffmpeg")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := []FallbackMethod{
+				{
+					Description: "Software encoding (libx264) to the same stream",
+					Codec:       "libx264",
+					Args:        concat(base, []string{"-c:a", "copy"}, tt.format, outputOptions, []string{tt.cfg.OutputPath}),
+				},
+				{
+					Description: "Software encoding (libx264) with AAC audio to the same stream",
+					Codec:       "libx264",
+					Args:        concat(base, []string{"-c:a", "aac", "-b:a", "128k"}, tt.format, outputOptions, []string{tt.cfg.OutputPath}),
+				},
+			}
+
+			got := fm.getFallbackMethods(&tt.cfg)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("getFallbackMethods()\n got: %q\nwant: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestCrossVendorMethods(t *testing.T) {
+	nvidia := utils.GPUInfo{Vendor: "nvidia", Model: "RTX 4070", DeviceIndex: "0"}
+	intel := utils.GPUInfo{Vendor: "intel", Model: "UHD 770", Integrated: true}
+	apple := utils.GPUInfo{Vendor: "apple", Model: "M2"}
+
+	tests := []struct {
+		name  string
+		cfg   config.ProcessingConfig
+		gpus  []utils.GPUInfo
+		want  []string // descriptions
+		codec string   // -c:v of the first retry
+	}{
+		{"other vendor tried", config.ProcessingConfig{Codec: "h264_qsv", Acceleration: "qsv"},
+			[]utils.GPUInfo{intel, nvidia}, []string{"Hardware encoding (h264_nvenc) on NVIDIA RTX 4070"}, "h264_nvenc"},
+		{"hevc stays hevc", config.ProcessingConfig{Codec: "hevc_qsv", Acceleration: "qsv"},
+			[]utils.GPUInfo{intel, nvidia}, []string{"Hardware encoding (hevc_nvenc) on NVIDIA RTX 4070"}, "hevc_nvenc"},
+		{"same vendor skipped", config.ProcessingConfig{Codec: "h264_nvenc", Acceleration: "cuda"},
+			[]utils.GPUInfo{nvidia, nvidia}, nil, ""},
+		{"each encoder tried once", config.ProcessingConfig{Codec: "h264_videotoolbox", Acceleration: "videotoolbox"},
+			[]utils.GPUInfo{apple, nvidia, nvidia}, []string{"Hardware encoding (h264_nvenc) on NVIDIA RTX 4070"}, "h264_nvenc"},
+		{"untargetable gpus skipped", config.ProcessingConfig{Codec: "h264_qsv", Acceleration: "qsv"},
+			[]utils.GPUInfo{intel, {Vendor: "nvidia", Model: "RTX 4070"}, {Vendor: "nvidia", Model: "RTX 3060"}}, nil, ""},
+		{"disabled", config.ProcessingConfig{Codec: "h264_qsv", Acceleration: "qsv", NoCrossVendor: true},
+			[]utils.GPUInfo{intel, nvidia}, nil, ""},
+		{"software encode", config.ProcessingConfig{Codec: "libx264", Acceleration: "none"},
+			[]utils.GPUInfo{nvidia}, nil, ""},
+		{"stream copy", config.ProcessingConfig{Codec: "h264_qsv", Acceleration: "qsv", CopyVideo: true},
+			[]utils.GPUInfo{intel, nvidia}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.InputPath, tt.cfg.OutputPath, tt.cfg.Quality = "in.mp4", "out.mp4", 23
+			fm := NewFallbackManager("ffmpeg")
+			fm.SetGPUs(tt.gpus)
+
+			methods := fm.crossVendorMethods(&tt.cfg)
+			var got []string
+			for _, m := range methods {
+				got = append(got, m.Description)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("crossVendorMethods() = %q, want %q", got, tt.want)
+			}
+			if len(methods) > 0 && !strings.Contains(strings.Join(methods[0].Args, " "), "-c:v "+tt.codec) {
+				t.Errorf("first retry args %q lack -c:v %s", methods[0].Args, tt.codec)
+			}
+		})
+	}
+}
+
+func TestFallbacksKeepDurationCap(t *testing.T) {
+	// A live input capped by -max-duration reaches the builder as Duration
+	tests := []config.ProcessingConfig{
+		{Codec: "h264_nvenc", InputPath: "rtsp://cam.local/stream", OutputPath: "capture.mkv", Duration: "7200"},
+		{Codec: "h264_nvenc", InputPath: "rtsp://cam.local/stream", OutputPath: "srt://example.com:9000", Duration: "7200"},
+		{Codec: "h264_nvenc", InputPath: "rtsp://cam.local/stream", OutputPath: "capture.mkv", Duration: "7200", SegmentTime: "600"},
+	}
+
+	for _, cfg := range tests {
+		for _, method := range NewFallbackManager("ffmpeg").getFallbackMethods(&cfg) {
+			if !strings.Contains(strings.Join(method.Args, " "), "-t 7200") {
+				t.Errorf("%s fallback %q to %s lacks -t 7200: %q", cfg.InputPath, method.Description, cfg.OutputPath, method.Args)
+			}
+		}
+	}
+}
+
+func TestFallbacksKeepExtraInputs(t *testing.T) {
+	cfg := config.ProcessingConfig{Codec: "h264_nvenc", Acceleration: "cuda", InputPath: "in.mp4", OutputPath: "out.mp4",
+		Watermark: "logo.png", WatermarkPosition: config.WatermarkTopLeft, AddSilence: true, AudioCodec: "aac"}
+	want := []string{"-i", "logo.png", "-f", "lavfi", "-i", silentAudioSource, "-map", "[vout]", "-map", "2:a:0", "-filter_complex"}
+
+	for _, method := range NewFallbackManager("ffmpeg").getFallbackMethods(&cfg) {
+		args := strings.Join(method.Args, " ")
+		if !strings.Contains(args, strings.Join(want, " ")) {
+			t.Errorf("fallback %q lacks the watermark and silent audio inputs: %q", method.Description, method.Args)
+		}
+	}
+}
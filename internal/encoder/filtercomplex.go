@@ -0,0 +1,51 @@
+package encoder
+
+import (
+	"regexp"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// trailingLabel matches an output label closing a filter graph, e.g. "...,scale=1280:-2[v]"
+var trailingLabel = regexp.MustCompile(`\[([^\[\]]+)\]\s*$`)
+
+// filterComplexMaps returns the -map selection for a -filter-complex encode. The graph supplies
+// the video, so the source video maps are dropped; a labeled final output is mapped by label,
+// keeping the source audio that FFmpeg would otherwise stop selecting. An unlabeled output
+// needs no map, since FFmpeg adds it to the output automatically.
+func filterComplexMaps(cfg *config.ProcessingConfig) []string {
+	label := ""
+	if m := trailingLabel.FindStringSubmatch(cfg.FilterComplex); m != nil {
+		label = "[" + m[1] + "]"
+	}
+	return graphOutputMaps(label, cfg)
+}
+
+// graphOutputMaps maps a filter graph's labeled video output in place of the source video,
+// keeping the other selected streams, or the source audio when nothing else is selected
+func graphOutputMaps(label string, cfg *config.ProcessingConfig) []string {
+	var maps []string
+	if label != "" {
+		maps = append(maps, label)
+	}
+
+	var others []string
+	for _, m := range cfg.Maps {
+		if !strings.HasPrefix(m, "0:v") {
+			others = append(others, m)
+		}
+	}
+	if len(maps) > 0 && len(others) == 0 && !cfg.NoAudio {
+		others = append(others, "0:a?")
+	}
+	return append(maps, others...)
+}
+
+// ReplacedFilters returns the video filters the builder would have generated that a
+// -filter-complex graph replaces
+func (cb *CommandBuilder) ReplacedFilters(cfg *config.ProcessingConfig) []string {
+	generated := *cfg
+	generated.FilterComplex = ""
+	return cb.videoFilters(&generated)
+}
@@ -0,0 +1,104 @@
+package encoder
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// streamingPrefixes are the URL schemes treated as network outputs rather than files
+var streamingPrefixes = []string{
+	"rtmp://", "rtmps://", "rtsp://", "rtsps://", "srt://", "udp://", "tcp://", "http://", "https://",
+}
+
+// OutputExtensions are the file extensions OutputFormatArgs maps to a specific muxer
+var OutputExtensions = []string{".mp4", ".mkv", ".avi", ".mov", ".webm", ".flv", ".ts", ".m3u8", ".mpd"}
+
+// IsStreamingURL checks if the output path is a streaming URL
+func IsStreamingURL(outputPath string) bool {
+	lower := strings.ToLower(outputPath)
+	for _, prefix := range streamingPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TempSuffix marks a temporary output that is renamed to its final name once complete
+const TempSuffix = ".tmp"
+
+// OutputFormatArgs returns the -f muxer arguments for an output path or URL. It is shared by
+// the command builder and the fallback manager so retries write the same container.
+// Temporary outputs (e.g. out.mkv.tmp) get the muxer of their final name.
+func OutputFormatArgs(outputPath string) []string {
+	outputPath = strings.TrimSuffix(outputPath, TempSuffix)
+	if IsStreamingURL(outputPath) {
+		return streamingFormatArgs(outputPath)
+	}
+
+	// For file outputs, determine format from extension
+	switch strings.ToLower(filepath.Ext(outputPath)) {
+	case ".mkv":
+		return []string{"-f", "matroska"}
+	case ".avi":
+		return []string{"-f", "avi"}
+	case ".mov":
+		return []string{"-f", "mov"}
+	case ".webm":
+		return []string{"-f", "webm"}
+	case ".flv":
+		return []string{"-f", "flv"}
+	case ".ts":
+		return []string{"-f", "mpegts"}
+	case ".m3u8":
+		return hlsFormatArgs(config.DefaultHLSTime)
+	case ".mpd":
+		return []string{"-f", "dash"}
+	default:
+		// .mp4, and the default if the extension is unknown or missing
+		return []string{"-f", "mp4"}
+	}
+}
+
+// streamingFormatArgs returns the muxer for a streaming URL based on its protocol
+func streamingFormatArgs(outputPath string) []string {
+	lower := strings.ToLower(outputPath)
+
+	switch {
+	case strings.HasPrefix(lower, "rtmp://") || strings.HasPrefix(lower, "rtmps://"):
+		return []string{"-f", "flv"}
+	case strings.HasPrefix(lower, "rtsp://") || strings.HasPrefix(lower, "rtsps://"):
+		// Publishing options are added per config by rtspOutputOptions
+		return []string{"-f", "rtsp"}
+	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
+		// For HTTP streaming, check if it's HLS, DASH or an MP4 upload
+		switch {
+		case strings.Contains(lower, ".m3u8"):
+			return hlsFormatArgs(config.DefaultHLSTime)
+		case strings.Contains(lower, ".mpd"):
+			return []string{"-f", "dash"}
+		case strings.Contains(lower, ".mp4"):
+			return []string{"-f", "mp4"}
+		}
+	}
+	// SRT, UDP, TCP, plain HTTP and unknown protocols carry MPEG-TS
+	return []string{"-f", "mpegts"}
+}
+
+// hlsFormatArgs returns the HLS muxer with segments of the given length and a complete playlist
+func hlsFormatArgs(seconds int) []string {
+	return []string{"-f", "hls", "-hls_time", strconv.Itoa(seconds), "-hls_list_size", "0"}
+}
+
+// OutputFormatArgsFor returns OutputFormatArgs for the config's output, with HLS segments of
+// -hls-time and the configured HLS packaging
+func OutputFormatArgsFor(cfg *config.ProcessingConfig) []string {
+	args := OutputFormatArgs(cfg.OutputPath)
+	if args[1] == "hls" {
+		return append(hlsFormatArgs(HLSTime(cfg)), hlsPackagingArgs(cfg)...)
+	}
+	return args
+}
@@ -0,0 +1,90 @@
+package encoder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"video_processing/internal/config"
+)
+
+func TestOutputFormatArgs(t *testing.T) {
+	tests := []struct {
+		output string
+		want   []string
+	}{
+		{"out.mp4", []string{"-f", "mp4"}},
+		{"OUT.MKV", []string{"-f", "matroska"}},
+		{"out.avi", []string{"-f", "avi"}},
+		{"out.mov", []string{"-f", "mov"}},
+		{"out.webm", []string{"-f", "webm"}},
+		{"manifest.mpd", []string{"-f", "dash"}},
+		{"out.flv", []string{"-f", "flv"}},
+		{"out.ts", []string{"-f", "mpegts"}},
+		{"out.m3u8", []string{"-f", "hls", "-hls_time", "10", "-hls_list_size", "0"}},
+		{"out", []string{"-f", "mp4"}},
+		{"out.mkv.tmp", []string{"-f", "matroska"}},
+		{"rtmps://example.com/live", []string{"-f", "flv"}},
+		{"rtsp://example.com/live", []string{"-f", "rtsp"}},
+		{"srt://example.com:9000", []string{"-f", "mpegts"}},
+		{"udp://239.0.0.1:1234", []string{"-f", "mpegts"}},
+		{"tcp://127.0.0.1:9000", []string{"-f", "mpegts"}},
+		{"https://cdn.example.com/live/index.m3u8", []string{"-f", "hls", "-hls_time", "10", "-hls_list_size", "0"}},
+		{"https://cdn.example.com/live/manifest.mpd", []string{"-f", "dash"}},
+		{"https://cdn.example.com/live/video.mp4", []string{"-f", "mp4"}},
+		{"http://example.com/ingest", []string{"-f", "mpegts"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			got := OutputFormatArgs(tt.output)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OutputFormatArgs(%q) = %q, want %q", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+// muxerArgs returns the -f option and the muxer options following it in an FFmpeg command
+func muxerArgs(args []string) []string {
+	for i, arg := range args {
+		if arg == "-f" {
+			end := i + 2
+			for end < len(args) && strings.HasPrefix(args[end], "-hls_") {
+				end += 2
+			}
+			return args[i:end]
+		}
+	}
+	return nil
+}
+
+// TestFallbackFormatMatchesCommandBuilder checks that the primary command and the fallback
+// retries for the same output select the same muxer
+func TestFallbackFormatMatchesCommandBuilder(t *testing.T) {
+	paths := []string{
+		"out.mp4", "out.mkv", "out.avi", "out.mov", "out.webm", "out.flv", "out.ts", "out.m3u8", "out.mpd", "out",
+		"rtmp://example.com/live", "rtmps://example.com/live", "rtsp://example.com/live",
+		"srt://example.com:9000", "udp://239.0.0.1:1234", "tcp://example.com:9000",
+		"https://cdn.example.com/live.m3u8", "https://cdn.example.com/live.mpd",
+		"https://cdn.example.com/upload/a.mp4", "http://example.com/ingest",
+	}
+
+	cb := NewCommandBuilder()
+	fm := NewFallbackManager("ffmpeg")
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			cfg := &config.ProcessingConfig{
+				Acceleration: "none", Codec: "libx264", Preset: "medium", Quality: 23,
+				InputPath: "in.mkv", OutputPath: path,
+			}
+			want := muxerArgs(cb.BuildFFmpegCommand(cfg))
+
+			// The first retry keeps the detected format; later file retries deliberately switch to MP4
+			got := muxerArgs(fm.getFallbackMethods(cfg)[0].Args)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("fallback muxer %q, command builder muxer %q", got, want)
+			}
+		})
+	}
+}
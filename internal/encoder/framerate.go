@@ -0,0 +1,23 @@
+package encoder
+
+import "video_processing/internal/config"
+
+// fpsModeMajor is the first FFmpeg major version given -fps_mode. It arrived in 5.1 and
+// deprecates -vsync, but only the major version is detected, so 5.x keeps -vsync, which
+// every 5.x release still accepts.
+const fpsModeMajor = 6
+
+// addFrameRateOptions sets the output frame rate and, with -cfr, the frame sync mode that
+// drops or duplicates frames to hold it. An unknown FFmpeg version (0) is treated as current.
+func (cb *CommandBuilder) addFrameRateOptions(args []string, cfg *config.ProcessingConfig) []string {
+	if cfg.FrameRate != "" {
+		args = append(args, "-r", cfg.FrameRate)
+	}
+	if !cfg.CFR {
+		return args
+	}
+	if cfg.FFmpegMajor > 0 && cfg.FFmpegMajor < fpsModeMajor {
+		return append(args, "-vsync", "cfr")
+	}
+	return append(args, "-fps_mode", "cfr")
+}
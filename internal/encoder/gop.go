@@ -0,0 +1,84 @@
+package encoder
+
+import (
+	"strconv"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// closedGOPFlag is the codec flag that keeps B-frames at the start of a GOP from
+// referencing the previous one; it goes into the command's -flags
+const closedGOPFlag = "+cgop"
+
+// ClosedGOPCodecs are the encoders -closed-gop can configure
+var ClosedGOPCodecs = []string{"libx264", "libx265", "h264_nvenc", "hevc_nvenc", "h264_qsv", "hevc_qsv"}
+
+// ClosedGOPFormats are the containers -closed-gop output may be written to: MPEG-TS for
+// broadcast, and the formats packaging and playout systems take in
+var ClosedGOPFormats = []string{"mpegts", "hls", "mp4", "mov", "matroska"}
+
+// addGOPOptions sets the keyframe interval and, with -no-scenecut or -closed-gop, stops the
+// encoder from inserting extra keyframes at scene changes so every GOP is exactly -gop frames
+// long. Encoders without scene-cut detection (VAAPI, AMF, VideoToolbox) only get -g.
+func (cb *CommandBuilder) addGOPOptions(args []string, cfg *config.ProcessingConfig) []string {
+	if cfg.GOPSize > 0 {
+		args = append(args, "-g", strconv.Itoa(cfg.GOPSize))
+	}
+	if cfg.ClosedGOP {
+		args = addClosedGOPOptions(args, cfg)
+	}
+	if !cfg.NoSceneCut && !cfg.ClosedGOP {
+		return args
+	}
+
+	switch {
+	case strings.HasSuffix(cfg.Codec, "_nvenc"):
+		args = append(args, "-no-scenecut", "1")
+	case strings.HasSuffix(cfg.Codec, "_qsv"):
+		args = append(args, "-adaptive_i", "0")
+	case cfg.Codec == "libx264":
+		args = append(args, "-sc_threshold", "0")
+	case cfg.Codec == "libvpx-vp9", cfg.Codec == "libaom-av1":
+		// libvpx and libaom place keyframes freely between -keyint_min and -g
+		if cfg.GOPSize > 0 {
+			args = append(args, "-keyint_min", strconv.Itoa(cfg.GOPSize))
+		}
+	case cfg.Codec == "libsvtav1":
+		args = append(args, "-svtav1-params", "scd=0")
+	}
+	return args
+}
+
+// addClosedGOPOptions adds each encoder's own closed-GOP settings; the +cgop flag itself is
+// added with the command's other -flags. NVENC keeps GOPs strictly -gop frames, QSV makes
+// every I-frame an IDR, and forced keyframes (HLS boundaries) become IDRs too.
+func addClosedGOPOptions(args []string, cfg *config.ProcessingConfig) []string {
+	switch {
+	case strings.HasSuffix(cfg.Codec, "_nvenc"):
+		args = append(args, "-strict_gop", "1", "-forced-idr", "1")
+	case strings.HasSuffix(cfg.Codec, "_qsv"):
+		args = append(args, "-idr_interval", "0")
+	case cfg.Codec == "libx264":
+		args = append(args, "-forced-idr", "1")
+	}
+	return args
+}
+
+// addX265Params passes libx265's own options, which FFmpeg has no flags for, as one -x265-params list
+func (cb *CommandBuilder) addX265Params(args []string, cfg *config.ProcessingConfig) []string {
+	var params []string
+	if cfg.Lossless {
+		params = append(params, "lossless=1")
+	}
+	if cfg.NoSceneCut || cfg.ClosedGOP {
+		params = append(params, "scenecut=0")
+	}
+	if cfg.ClosedGOP {
+		params = append(params, "open-gop=0")
+	}
+	if len(params) == 0 {
+		return args
+	}
+	return append(args, "-x265-params", strings.Join(params, ":"))
+}
@@ -0,0 +1,24 @@
+package encoder
+
+import "strings"
+
+// hevcEncoders maps each H.264 encoder to its HEVC counterpart on the same hardware
+var hevcEncoders = map[string]string{
+	"h264_nvenc":        "hevc_nvenc",
+	"h264_qsv":          "hevc_qsv",
+	"h264_vaapi":        "hevc_vaapi",
+	"h264_videotoolbox": "hevc_videotoolbox",
+	"h264_amf":          "hevc_amf",
+	"libx264":           "libx265",
+}
+
+// HEVCEncoder returns the HEVC encoder for the same acceleration path as the H.264 codec,
+// or "" if there is none
+func HEVCEncoder(codec string) string {
+	return hevcEncoders[codec]
+}
+
+// IsHEVC reports whether the video encoder produces HEVC
+func IsHEVC(codec string) bool {
+	return strings.HasPrefix(codec, "hevc_") || codec == "libx265"
+}
@@ -0,0 +1,62 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// HLSTime returns the HLS segment length in seconds, defaulting when -hls-time is unset
+func HLSTime(cfg *config.ProcessingConfig) int {
+	if cfg.HLSTime > 0 {
+		return cfg.HLSTime
+	}
+	return config.DefaultHLSTime
+}
+
+// addHLSKeyframes aligns keyframes with HLS segment boundaries. The HLS muxer can only cut
+// at keyframes, so a GOP that doesn't divide the segment length makes segments start
+// mid-GOP and players stall. A keyframe is forced at every boundary; the GOP itself is
+// capped by addGOPOptions, and scene cuts may still add keyframes between boundaries
+// unless -no-scenecut is set.
+func (cb *CommandBuilder) addHLSKeyframes(args []string, cfg *config.ProcessingConfig) []string {
+	args = append(args, "-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", HLSTime(cfg)))
+	if strings.HasSuffix(cfg.Codec, "_nvenc") {
+		// NVENC turns forced keyframes into plain I-frames unless told to make them IDR
+		args = append(args, "-forced-idr", "1")
+	}
+	return args
+}
+
+// hlsPackagingArgs returns the HLS muxer's segment container, playlist type and flags.
+// Re-encoded segments are declared independent: each starts on a keyframe forced at the
+// boundary (see addHLSKeyframes), so players can decode any segment on its own. Copied
+// video may use open GOPs, so it makes no such promise.
+func hlsPackagingArgs(cfg *config.ProcessingConfig) []string {
+	var args, flags []string
+	if cfg.HLSSegmentType != "" {
+		args = append(args, "-hls_segment_type", cfg.HLSSegmentType)
+	}
+	if cfg.HLSVOD {
+		args = append(args, "-hls_playlist_type", "vod")
+	}
+	if !cfg.CopyVideo {
+		flags = append(flags, "independent_segments")
+	}
+	if cfg.HLSSingleFile {
+		flags = append(flags, "single_file")
+	}
+	if len(flags) > 0 {
+		args = append(args, "-hls_flags", strings.Join(flags, "+"))
+	}
+	return args
+}
+
+// hlsSegmentExtension returns the file extension of HLS media segments
+func hlsSegmentExtension(cfg *config.ProcessingConfig) string {
+	if cfg.HLSSegmentType == config.HLSSegmentFMP4 {
+		return ".m4s"
+	}
+	return ".ts"
+}
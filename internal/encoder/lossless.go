@@ -0,0 +1,105 @@
+package encoder
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// SupportsLossless reports whether the encoder has a mathematically lossless mode. QSV, VAAPI,
+// AMF, VideoToolbox and SVT-AV1 don't, so -lossless switches them to a software encoder.
+func SupportsLossless(codec string) bool {
+	switch {
+	case strings.HasSuffix(codec, "_nvenc"):
+		return true
+	case codec == "libx264", codec == "libx265", codec == "libvpx-vp9", codec == "libaom-av1":
+		return true
+	}
+	return false
+}
+
+// LosslessFallback returns the software encoder used for -lossless when codec can't encode
+// losslessly: the same family where possible, otherwise x264
+func LosslessFallback(codec string) string {
+	switch {
+	case IsHEVC(codec):
+		return "libx265"
+	case codec == "libsvtav1":
+		return "libaom-av1"
+	}
+	return "libx264"
+}
+
+// addLosslessEncoding adds each encoder's lossless mode in place of its quality setting
+func (cb *CommandBuilder) addLosslessEncoding(args []string, cfg *config.ProcessingConfig) []string {
+	args = append(args, "-c:v", cfg.Codec)
+	switch {
+	case strings.HasSuffix(cfg.Codec, "_nvenc"):
+		if cfg.GPUDevice != "" {
+			args = append(args, "-gpu", cfg.GPUDevice)
+		}
+		args = append(args, "-preset", "lossless", "-rc", "constqp", "-qp", "0")
+	case cfg.Codec == "libx265":
+		args = append(args, "-preset", "veryslow") // lossless=1 goes in addX265Params
+	case cfg.Codec == "libvpx-vp9":
+		args = append(args, "-lossless", "1", "-row-mt", "1")
+	case cfg.Codec == "libaom-av1":
+		args = append(args, "-aom-params", "lossless=1", "-row-mt", "1")
+	default: // libx264; -crf 0 is only lossless at 8 bits
+		args = append(args, "-preset", "veryslow", "-qp", "0")
+	}
+	return args
+}
+
+// LosslessDepth reports whether codec's lossless mode keeps the source's bit depth. NVENC
+// encodes H.264 at 8 bits and HEVC at up to 10, so deeper sources need a software encoder.
+func LosslessDepth(codec, source string) bool {
+	switch codec {
+	case "h264_nvenc":
+		return PixFmtDepth(source) <= 8
+	case "hevc_nvenc":
+		return PixFmtDepth(source) <= 10
+	}
+	return true
+}
+
+// planarDepth matches the bit depth suffix of a planar pixel format, e.g. yuv420p10le or p010le
+var planarDepth = regexp.MustCompile(`p(\d+)(?:le|be)?$`)
+
+// PixFmtDepth returns the bits per component of an FFmpeg pixel format, e.g. 10 for yuv420p10le
+func PixFmtDepth(pixFmt string) int {
+	if m := planarDepth.FindStringSubmatch(pixFmt); m != nil {
+		depth, _ := strconv.Atoi(m[1])
+		return depth
+	}
+	// Packed RGB formats name bits per pixel: rgb48le and rgba64le are 16 bits per component
+	if strings.Contains(pixFmt, "48") || strings.Contains(pixFmt, "64") {
+		return 16
+	}
+	return 8
+}
+
+// LosslessPixFmt returns the pixel format a lossless encode needs to keep the source's chroma,
+// or "" when FFmpeg's automatic choice already matches it (4:2:0 sources)
+func LosslessPixFmt(codec, source string) string {
+	deep := PixFmtDepth(source) > 8
+	switch {
+	case strings.Contains(source, "444"), strings.HasPrefix(source, "rgb"), strings.HasPrefix(source, "bgr"), strings.HasPrefix(source, "gbr"):
+		switch {
+		case deep && strings.HasSuffix(codec, "_nvenc"):
+			return "yuv444p16le" // NVENC's only high-bit-depth 4:4:4 input
+		case deep:
+			return "yuv444p10le"
+		}
+		return "yuv444p"
+	case strings.Contains(source, "422") && strings.HasSuffix(codec, "_nvenc"):
+		// NVENC has no 4:2:2 mode, so keep the chroma by going up to 4:4:4
+		if deep {
+			return "yuv444p16le"
+		}
+		return "yuv444p"
+	}
+	return ""
+}
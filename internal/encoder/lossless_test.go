@@ -0,0 +1,46 @@
+package encoder
+
+import "testing"
+
+func TestLosslessPixFmt(t *testing.T) {
+	tests := []struct {
+		codec, source, want string
+	}{
+		{"libx264", "yuv420p", ""},
+		{"libx264", "yuv444p", "yuv444p"},
+		{"libx264", "rgb24", "yuv444p"},
+		{"libx264", "yuv444p10le", "yuv444p10le"},
+		{"h264_nvenc", "yuv422p", "yuv444p"},
+		{"libx264", "yuv422p", ""},
+		{"hevc_nvenc", "gbrp12le", "yuv444p16le"},
+		{"hevc_nvenc", "yuv422p10le", "yuv444p16le"},
+		{"hevc_nvenc", "yuv420p10le", ""},
+	}
+
+	for _, tt := range tests {
+		if got := LosslessPixFmt(tt.codec, tt.source); got != tt.want {
+			t.Errorf("LosslessPixFmt(%q, %q) = %q, want %q", tt.codec, tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestLosslessDepth(t *testing.T) {
+	tests := []struct {
+		codec, source string
+		want          bool
+	}{
+		{"h264_nvenc", "yuv420p", true},
+		{"h264_nvenc", "yuv420p10le", false},
+		{"h264_nvenc", "p010le", false},
+		{"hevc_nvenc", "yuv420p10le", true},
+		{"hevc_nvenc", "yuv420p12le", false},
+		{"hevc_nvenc", "rgb48le", false},
+		{"libx264", "yuv420p10le", true},
+	}
+
+	for _, tt := range tests {
+		if got := LosslessDepth(tt.codec, tt.source); got != tt.want {
+			t.Errorf("LosslessDepth(%q, %q) = %v, want %v", tt.codec, tt.source, got, tt.want)
+		}
+	}
+}
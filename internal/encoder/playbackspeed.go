@@ -0,0 +1,46 @@
+package encoder
+
+import (
+	"math"
+	"strconv"
+
+	"video_processing/internal/config"
+)
+
+// atempo's range per filter instance; older FFmpeg builds reject factors outside it, so
+// larger changes are chained
+const (
+	atempoMin = 0.5
+	atempoMax = 2.0
+)
+
+// retimes reports whether -playback-speed changes the output's timing
+func retimes(cfg *config.ProcessingConfig) bool {
+	return cfg.PlaybackSpeed > 0 && cfg.PlaybackSpeed != 1
+}
+
+// setptsFilter retimes the video for -playback-speed: setpts=PTS/2 plays twice as fast
+func setptsFilter(speed float64) string {
+	return "setpts=PTS/" + formatFactor(speed)
+}
+
+// AtempoFilters returns the atempo chain that changes the audio tempo by speed while
+// keeping its pitch, e.g. atempo=2,atempo=2 for 4x and atempo=0.5,atempo=0.6 for 0.3x
+func AtempoFilters(speed float64) []string {
+	var filters []string
+	for speed > atempoMax {
+		filters = append(filters, "atempo="+formatFactor(atempoMax))
+		speed /= atempoMax
+	}
+	for speed < atempoMin {
+		filters = append(filters, "atempo="+formatFactor(atempoMin))
+		speed /= atempoMin
+	}
+	return append(filters, "atempo="+formatFactor(speed))
+}
+
+// formatFactor prints a speed factor without trailing zeros or the rounding noise the
+// atempo divisions leave, e.g. 0.6 rather than 0.6000000000000001
+func formatFactor(f float64) string {
+	return strconv.FormatFloat(math.Round(f*1e4)/1e4, 'f', -1, 64)
+}
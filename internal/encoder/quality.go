@@ -0,0 +1,113 @@
+package encoder
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// QualityScale describes how an encoder reads the quality number. Encoders disagree on the
+// option, the range and even the direction, so the same number means different things:
+//
+//	encoder             option           range   better   ≈ CRF 18   ≈ CRF 28
+//	libx264             -crf             0-51    lower       18         28
+//	libx265             -crf             0-51    lower       23         33
+//	*_nvenc             -cq              0-51    lower       18         28
+//	*_qsv               -global_quality  1-51    lower       18         28
+//	*_vaapi             -qp              0-51    lower       18         28
+//	*_amf               -qp_i/-qp_p      0-51    lower       18         28
+//	*_videotoolbox      -q:v             1-100   higher      75         45
+//	libvpx-vp9          -crf             0-63    lower       24         37
+//	libaom-av1          -crf             0-63    lower       24         38
+//	libsvtav1           -crf             1-63    lower       25         40
+//
+// The last two columns are each encoder's value for roughly the perceptual quality of x264
+// at CRF 18 (visually lossless) and CRF 28 (visibly compressed). Values in between and
+// beyond are interpolated linearly along that line and clamped to the encoder's range.
+// The hardware H.264/HEVC encoders track x264's scale closely enough to share it.
+type QualityScale struct {
+	Option         string
+	Min, Max       int
+	HigherIsBetter bool
+	// AtCRF18 and AtCRF28 anchor the scale against x264's CRF
+	AtCRF18, AtCRF28 float64
+}
+
+var (
+	x264Scale         = QualityScale{"-crf", 0, 51, false, 18, 28}
+	x265Scale         = QualityScale{"-crf", 0, 51, false, 23, 33}
+	nvencScale        = QualityScale{"-cq", 0, 51, false, 18, 28}
+	qsvScale          = QualityScale{"-global_quality", 1, 51, false, 18, 28}
+	vaapiScale        = QualityScale{"-qp", 0, 51, false, 18, 28}
+	amfScale          = QualityScale{"-qp_i/-qp_p", 0, 51, false, 18, 28}
+	videoToolboxScale = QualityScale{"-q:v", 1, 100, true, 75, 45}
+	vp9Scale          = QualityScale{"-crf", 0, 63, false, 24, 37}
+	aomAV1Scale       = QualityScale{"-crf", 0, 63, false, 24, 38}
+	svtAV1Scale       = QualityScale{"-crf", 1, 63, false, 25, 40}
+)
+
+// QualityScaleFor returns the quality scale of an encoder; unknown encoders use x264's
+func QualityScaleFor(codec string) QualityScale {
+	switch {
+	case codec == "libx265":
+		return x265Scale
+	case codec == "libvpx-vp9":
+		return vp9Scale
+	case codec == "libaom-av1":
+		return aomAV1Scale
+	case codec == "libsvtav1":
+		return svtAV1Scale
+	case strings.HasSuffix(codec, "_nvenc"):
+		return nvencScale
+	case strings.HasSuffix(codec, "_qsv"):
+		return qsvScale
+	case strings.HasSuffix(codec, "_vaapi"):
+		return vaapiScale
+	case strings.HasSuffix(codec, "_amf"):
+		return amfScale
+	case strings.HasSuffix(codec, "_videotoolbox"):
+		return videoToolboxScale
+	}
+	return x264Scale
+}
+
+// Check returns an error if quality is outside the scale's range
+func (s QualityScale) Check(quality int) error {
+	if quality < s.Min || quality > s.Max {
+		return fmt.Errorf("quality %d is outside the %s range %d-%d", quality, s.Option, s.Min, s.Max)
+	}
+	return nil
+}
+
+// Describe summarises the scale for prompts, e.g. "-crf 0-51, lower=better"
+func (s QualityScale) Describe() string {
+	direction := "lower"
+	if s.HigherIsBetter {
+		direction = "higher"
+	}
+	return fmt.Sprintf("%s %d-%d, %s=better", s.Option, s.Min, s.Max, direction)
+}
+
+// fromCRF converts an x264-equivalent CRF into the scale's value
+func (s QualityScale) fromCRF(crf float64) int {
+	v := s.AtCRF18 + (crf-18)/10*(s.AtCRF28-s.AtCRF18)
+	return max(s.Min, min(s.Max, int(math.Round(v))))
+}
+
+// toCRF converts a value on the scale into its x264-equivalent CRF
+func (s QualityScale) toCRF(quality int) float64 {
+	return 18 + (float64(quality)-s.AtCRF18)/(s.AtCRF28-s.AtCRF18)*10
+}
+
+// QualityForLevel translates a normalized quality level, 0 (smallest) to 100 (best), into
+// the encoder's native value. The level maps linearly onto x264 CRF 51-0, so 55 is CRF 23,
+// x264's default, and is then converted with the table above.
+func QualityForLevel(codec string, level int) int {
+	return QualityScaleFor(codec).fromCRF(51 - 0.51*float64(level))
+}
+
+// ConvertQuality translates a quality value chosen for one encoder into the value of
+// roughly the same perceptual quality for another
+func ConvertQuality(quality int, from, to string) int {
+	return QualityScaleFor(to).fromCRF(QualityScaleFor(from).toCRF(quality))
+}
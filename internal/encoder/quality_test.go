@@ -0,0 +1,60 @@
+package encoder
+
+import "testing"
+
+func TestQualityForLevel(t *testing.T) {
+	tests := []struct {
+		codec string
+		level int
+		want  int
+	}{
+		{"libx264", 55, 23},
+		{"libx264", 100, 0},
+		{"libx264", 0, 51},
+		{"h264_nvenc", 55, 23},
+		{"hevc_qsv", 0, 51},
+		{"h264_videotoolbox", 55, 60},
+		{"h264_videotoolbox", 100, 100},
+		{"h264_videotoolbox", 0, 1},
+		{"libvpx-vp9", 55, 30},
+		{"libsvtav1", 100, 1},
+	}
+
+	for _, tt := range tests {
+		if got := QualityForLevel(tt.codec, tt.level); got != tt.want {
+			t.Errorf("QualityForLevel(%q, %d) = %d, want %d", tt.codec, tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestConvertQuality(t *testing.T) {
+	tests := []struct {
+		quality  int
+		from, to string
+		want     int
+	}{
+		{23, "h264_nvenc", "h264_nvenc", 23},
+		{23, "libx264", "h264_nvenc", 23},
+		{60, "h264_videotoolbox", "libx264", 23},
+		{23, "libx264", "libvpx-vp9", 31},
+		{28, "libx264", "libx265", 33},
+	}
+
+	for _, tt := range tests {
+		if got := ConvertQuality(tt.quality, tt.from, tt.to); got != tt.want {
+			t.Errorf("ConvertQuality(%d, %q, %q) = %d, want %d", tt.quality, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestQualityScaleCheck(t *testing.T) {
+	if err := QualityScaleFor("h264_qsv").Check(0); err == nil {
+		t.Error("QSV quality 0 accepted, want an error (range 1-51)")
+	}
+	if err := QualityScaleFor("h264_videotoolbox").Check(75); err != nil {
+		t.Errorf("VideoToolbox quality 75 rejected: %v", err)
+	}
+	if err := QualityScaleFor("libx264").Check(52); err == nil {
+		t.Error("x264 CRF 52 accepted, want an error (range 0-51)")
+	}
+}
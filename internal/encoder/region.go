@@ -0,0 +1,41 @@
+package encoder
+
+import (
+	"fmt"
+
+	"video_processing/internal/config"
+)
+
+// regionFilters returns the crop/zoom and resize filters for -crop, -zoom and -output-size
+func (cb *CommandBuilder) regionFilters(cfg *config.ProcessingConfig) []string {
+	if cfg.Crop != nil && cfg.Zoom {
+		return []string{zoomFilter(cfg)}
+	}
+
+	var filters []string
+	if cfg.Crop != nil {
+		filters = append(filters, "crop="+cfg.Crop.String())
+	}
+	if cfg.OutputWidth > 0 {
+		// Lanczos keeps upscaled crops sharper than the default bicubic
+		filters = append(filters, fmt.Sprintf("scale=%d:%d:flags=lanczos", cfg.OutputWidth, cfg.OutputHeight))
+	}
+	return filters
+}
+
+// zoomFilter builds a zoompan filter that moves linearly from the full frame to the crop
+// region over ZoomFrames frames. zoompan zooms uniformly, so the final zoom fits the whole
+// region and keeps the source aspect ratio; the view is centred on the region and zoompan
+// clamps it to the frame.
+func zoomFilter(cfg *config.ProcessingConfig) string {
+	r := cfg.Crop
+	progress := fmt.Sprintf("min(on/%d,1)", max(cfg.ZoomFrames-1, 1))
+	centerX := float64(r.X) + float64(r.Width)/2
+	centerY := float64(r.Y) + float64(r.Height)/2
+
+	return fmt.Sprintf("zoompan=z='1+(min(iw/%d,ih/%d)-1)*%s':x='iw/2+(%g-iw/2)*%s-iw/zoom/2':y='ih/2+(%g-ih/2)*%s-ih/zoom/2':d=1:s=%dx%d:fps=%s",
+		r.Width, r.Height, progress,
+		centerX, progress,
+		centerY, progress,
+		cfg.OutputWidth, cfg.OutputHeight, cfg.ZoomFPS)
+}
@@ -0,0 +1,43 @@
+package encoder
+
+import (
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// rtspRenameMajor is the FFmpeg major version that renamed the RTSP timeouts: the socket
+// timeout -stimeout became -timeout, and the old -timeout (listen wait) became -listen_timeout
+const rtspRenameMajor = 5
+
+// isRTSP reports whether the URL uses the RTSP protocol
+func isRTSP(url string) bool {
+	lower := strings.ToLower(url)
+	return strings.HasPrefix(lower, "rtsp://") || strings.HasPrefix(lower, "rtsps://")
+}
+
+// rtspSocketTimeout returns the option that sets the RTSP socket I/O timeout (microseconds)
+// for the FFmpeg version; an unknown version (0) is treated as current
+func rtspSocketTimeout(ffmpegMajor int) string {
+	if ffmpegMajor > 0 && ffmpegMajor < rtspRenameMajor {
+		return "-stimeout"
+	}
+	return "-timeout"
+}
+
+// rtspInputOptions returns the options for reading an RTSP input. In listen mode FFmpeg acts
+// as the server and waits for a client to publish to the URL; otherwise it connects as a client.
+func rtspInputOptions(cfg *config.ProcessingConfig) []string {
+	if cfg.RTSPListen {
+		// The listen wait is left at FFmpeg's default of forever; a socket timeout would
+		// abort before anyone connects
+		return []string{"-rtsp_flags", "listen"}
+	}
+	return []string{"-rtsp_transport", "tcp", rtspSocketTimeout(cfg.FFmpegMajor), ioTimeout}
+}
+
+// rtspOutputOptions returns the muxer options for publishing to an RTSP server. FFmpeg's RTSP
+// muxer is always a client (it cannot serve RTSP), so listen flags never apply to outputs.
+func rtspOutputOptions(cfg *config.ProcessingConfig) []string {
+	return []string{"-rtsp_transport", "tcp", rtspSocketTimeout(cfg.FFmpegMajor), ioTimeout}
+}
@@ -0,0 +1,45 @@
+package encoder
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// SegmentPattern returns the segment muxer filename pattern for an output path, so out.mp4
+// becomes out_%03d.mp4. A path whose name already contains a % pattern is used as given.
+func SegmentPattern(outputPath string) string {
+	if strings.Contains(filepath.Base(outputPath), "%") {
+		return outputPath
+	}
+	ext := filepath.Ext(outputPath)
+	return strings.TrimSuffix(outputPath, ext) + "_%03d" + ext
+}
+
+// SegmentPath returns the file name of the segment with the given index
+func SegmentPath(outputPath string, index int) string {
+	return fmt.Sprintf(SegmentPattern(outputPath), index)
+}
+
+// addSegmentOutput writes the output through the segment muxer, which produces standalone
+// files in the container the output extension selects. Re-encodes force a keyframe at each
+// boundary so segments come out at the requested length; copied video can only be cut at
+// the source's keyframes.
+func (cb *CommandBuilder) addSegmentOutput(args []string, cfg *config.ProcessingConfig) []string {
+	if !cfg.CopyVideo {
+		args = append(args, "-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%s)", cfg.SegmentTime))
+	}
+	args = append(args,
+		"-f", "segment",
+		"-segment_time", cfg.SegmentTime,
+		"-segment_format", cb.OutputFormat(cfg.OutputPath),
+		"-reset_timestamps", "1",
+	)
+	if movFlags := cb.movFlags(cfg); movFlags != "" {
+		// The segment muxer passes container options through to each segment's muxer
+		args = append(args, "-segment_format_options", "movflags="+movFlags)
+	}
+	return args
+}
@@ -0,0 +1,24 @@
+package encoder
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// silentAudioSource is the lavfi source of the -replace-audio-silence track: endless
+// 48 kHz stereo silence, cut to the video's length by -shortest
+const silentAudioSource = "anullsrc=channel_layout=stereo:sample_rate=48000"
+
+// silentAudioMaps puts the silent track in place of the source audio maps, which select
+// nothing on an input without audio. The video is mapped explicitly when nothing else is,
+// so the lavfi input can't change what FFmpeg picks.
+func (cb *CommandBuilder) silentAudioMaps(maps []string, cfg *config.ProcessingConfig) []string {
+	maps = slices.DeleteFunc(slices.Clone(maps), func(m string) bool { return strings.HasPrefix(m, "0:a") })
+	if len(maps) == 0 {
+		maps = []string{"0:v:0"}
+	}
+	return append(maps, fmt.Sprintf("%d:a:0", len(cb.extraInputs(cfg))+1))
+}
@@ -0,0 +1,47 @@
+package encoder
+
+import (
+	"video_processing/internal/config"
+	"video_processing/utils"
+)
+
+// cpuTier ranks how much x264/x265 work a CPU can take on
+type cpuTier int
+
+const (
+	tierModest cpuTier = iota
+	tierCapable
+	tierPowerful
+)
+
+// softwarePresets maps each CPU tier and Speed level to an x264/x265 preset. The balanced
+// preset of a modest CPU is one step faster than FFmpeg's medium, a powerful one's one step slower.
+var softwarePresets = map[cpuTier]map[string]string{
+	tierModest:   {config.SpeedFast: "veryfast", config.SpeedBalanced: "fast", config.SpeedSlow: "medium"},
+	tierCapable:  {config.SpeedFast: "faster", config.SpeedBalanced: "medium", config.SpeedSlow: "slow"},
+	tierPowerful: {config.SpeedFast: "fast", config.SpeedBalanced: "slow", config.SpeedSlow: "slower"},
+}
+
+// SoftwarePreset picks the x264/x265 preset for the CPU and the -speed intent
+func SoftwarePreset(cpu utils.CPUInfo, speed string) string {
+	presets := softwarePresets[tierOf(cpu)]
+	if preset, ok := presets[speed]; ok {
+		return preset
+	}
+	return presets[config.SpeedBalanced]
+}
+
+// tierOf ranks the CPU: x264 scales well to about 16 threads, and AVX2 speeds up its
+// motion search and transforms by roughly a third. An unknown core count counts as capable.
+func tierOf(cpu utils.CPUInfo) cpuTier {
+	switch {
+	case cpu.Cores == 0:
+		return tierCapable
+	case cpu.Cores >= 12 && cpu.AVX2, cpu.Cores >= 8 && cpu.AVX512:
+		return tierPowerful
+	case cpu.Cores >= 6 && cpu.AVX2, cpu.Cores >= 12:
+		return tierCapable
+	default:
+		return tierModest
+	}
+}
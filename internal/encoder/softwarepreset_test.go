@@ -0,0 +1,67 @@
+package encoder
+
+import (
+	"testing"
+
+	"video_processing/internal/config"
+	"video_processing/utils"
+)
+
+func TestSoftwarePreset(t *testing.T) {
+	tests := []struct {
+		name  string
+		cpu   utils.CPUInfo
+		speed string
+		want  string
+	}{
+		{"quad core laptop", utils.CPUInfo{Cores: 4, AVX2: true}, config.SpeedBalanced, "fast"},
+		{"quad core laptop, fast", utils.CPUInfo{Cores: 4, AVX2: true}, config.SpeedFast, "veryfast"},
+		{"8 cores with AVX2", utils.CPUInfo{Cores: 8, AVX2: true}, config.SpeedBalanced, "medium"},
+		{"8 cores with AVX-512", utils.CPUInfo{Cores: 8, AVX2: true, AVX512: true}, config.SpeedBalanced, "slow"},
+		{"16 cores with AVX2", utils.CPUInfo{Cores: 16, AVX2: true}, config.SpeedBalanced, "slow"},
+		{"16 cores with AVX2, slow", utils.CPUInfo{Cores: 16, AVX2: true}, config.SpeedSlow, "slower"},
+		{"16 cores without AVX", utils.CPUInfo{Cores: 16}, config.SpeedBalanced, "medium"},
+		{"unknown CPU", utils.CPUInfo{}, config.SpeedBalanced, "medium"},
+		{"unknown speed", utils.CPUInfo{Cores: 16, AVX2: true}, "", "slow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SoftwarePreset(tt.cpu, tt.speed); got != tt.want {
+				t.Errorf("SoftwarePreset(%+v, %q) = %q, want %q", tt.cpu, tt.speed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackPreset(t *testing.T) {
+	tests := []struct {
+		preset string
+		want   string
+	}{
+		{"", "ultrafast"},
+		{config.SoftwarePresetAuto, "ultrafast"},
+		{"slow", "slow"},
+	}
+
+	for _, tt := range tests {
+		cfg := &config.ProcessingConfig{InputPath: "in.mkv", OutputPath: "out.mkv", SoftwarePreset: tt.preset}
+		methods := NewFallbackManager("ffmpeg").getFallbackMethods(cfg)
+		if got := argAfter(methods[0].Args, "-preset"); got != tt.want {
+			t.Errorf("file fallback preset with -software-preset %q = %q, want %q", tt.preset, got, tt.want)
+		}
+		if got := argAfter(methods[len(methods)-1].Args, "-preset"); got != "ultrafast" {
+			t.Errorf("minimal fallback preset with -software-preset %q = %q, want ultrafast", tt.preset, got)
+		}
+	}
+}
+
+// argAfter returns the value following option in an FFmpeg command, or "" if it is absent
+func argAfter(args []string, option string) string {
+	for i, arg := range args[:len(args)-1] {
+		if arg == option {
+			return args[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package encoder
+
+import "strings"
+
+// hwDecodeFailurePatterns are FFmpeg log fragments that indicate the hardware decoder
+// (rather than the encoder) could not handle the input
+var hwDecodeFailurePatterns = []string{
+	"failed setup for format cuda",
+	"failed setup for format vaapi",
+	"failed setup for format qsv",
+	"failed setup for format d3d11",
+	"failed setup for format dxva2",
+	"hwaccel initialisation returned error",
+	"failed to get hw surface format",
+	"hardware is lacking required capabilities",
+	"no decoder surfaces left",
+	"your platform doesn't support hardware accelerated",
+	"unsupported codec for hardware decode",
+	"error while decoding stream",
+}
+
+// outOfMemoryPatterns are FFmpeg log fragments that indicate the GPU ran out of memory
+// or encoder resources
+var outOfMemoryPatterns = []string{
+	"out of memory",
+	"cannot allocate memory",
+	"failed to allocate",
+	"mfx_err_memory_alloc",
+	"resource allocation failed",
+	"insufficient resources",
+}
+
+// pixelFormatFailurePatterns are FFmpeg log fragments that indicate the encoder rejected the
+// input's pixel format, typically 10-bit video sent to an 8-bit-only hardware encoder
+var pixelFormatFailurePatterns = []string{
+	"10 bit encode not supported",
+	"no capable devices found",
+	"provided device doesn't support required nvenc features",
+	"incompatible pixel format",
+	"unsupported pixel format",
+	"is invalid or not supported",
+	"impossible to convert between the formats supported by the filter",
+	"invalid video parameters",
+}
+
+// transientInitPatterns are FFmpeg log fragments from a hardware encoder or device that failed
+// to start, which right after boot or a driver reload often succeeds on a second attempt
+var transientInitPatterns = []string{
+	"generic error in an external library",
+	"cuinit(0) failed",
+	"device creation failed",
+	"mfx_err_device_failed",
+}
+
+// permanentInitPatterns are FFmpeg log fragments from an encoder that can't start on this
+// system at all: a missing driver library or one too old for FFmpeg's NVENC headers
+var permanentInitPatterns = []string{
+	"cannot load nvcuda.dll",
+	"cannot load libcuda.so",
+	"cannot load nvencodeapi64.dll",
+	"cannot load libnvidia-encode.so",
+	"driver does not support the required nvenc api version",
+	"the minimum required nvidia driver",
+	"failed to initialise vaapi connection",
+	"libva: va_getdriverinit failed",
+}
+
+// IsTransientInitFailure reports whether FFmpeg's stderr shows the hardware encoder failing to
+// initialize in a way worth retrying. Missing or outdated drivers, capability and memory errors
+// also surface during initialization but fail the same way every time, so they are excluded.
+func IsTransientInitFailure(stderr string) bool {
+	return containsAny(stderr, transientInitPatterns) && !containsAny(stderr, permanentInitPatterns) &&
+		!IsPixelFormatFailure(stderr) && !IsOutOfMemory(stderr)
+}
+
+// IsPixelFormatFailure reports whether FFmpeg's stderr shows the encoder rejecting the input pixel format
+func IsPixelFormatFailure(stderr string) bool {
+	return containsAny(stderr, pixelFormatFailurePatterns)
+}
+
+// IsOutOfMemory reports whether FFmpeg's stderr shows a GPU memory or resource exhaustion
+func IsOutOfMemory(stderr string) bool {
+	return containsAny(stderr, outOfMemoryPatterns)
+}
+
+// IsHWDecodeFailure reports whether FFmpeg's stderr shows a hardware decode failure
+func IsHWDecodeFailure(stderr string) bool {
+	return containsAny(stderr, hwDecodeFailurePatterns)
+}
+
+func containsAny(stderr string, patterns []string) bool {
+	lower := strings.ToLower(stderr)
+	for _, pattern := range patterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
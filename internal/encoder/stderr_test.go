@@ -0,0 +1,67 @@
+package encoder
+
+import "testing"
+
+func TestIsPixelFormatFailure(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   bool
+	}{
+		{"[h264_nvenc @ 0x55d0] 10 bit encode not supported\nError initializing output stream 0:0", true},
+		{"[h264_nvenc @ 0x55d0] No capable devices found", true},
+		{"Incompatible pixel format 'yuv420p10le' for codec 'h264_qsv', auto-selecting format 'nv12'", true},
+		{"[h264_qsv @ 0x5601] Error initializing the encoder: invalid video parameters (-15)", true},
+		{"[h264_nvenc @ 0x55d0] OpenEncodeSessionEx failed: out of memory (10)", false},
+		{"Conversion failed!", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPixelFormatFailure(tt.stderr); got != tt.want {
+			t.Errorf("IsPixelFormatFailure(%q) = %v, want %v", tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestIsTransientInitFailure(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   bool
+	}{
+		{"[h264_nvenc @ 0x55d0] Cannot load nvcuda.dll\nError initializing output stream 0:0", false},
+		{"[AVHWDeviceContext @ 0x5601] Cannot load libcuda.so.1\nDevice creation failed: -1313558101.", false},
+		{"[h264_nvenc @ 0x55d0] Driver does not support the required nvenc API version. Required: 12.1 Found: 12.0\nGeneric error in an external library", false},
+		{"[h264_nvenc @ 0x55d0] OpenEncodeSessionEx failed: generic error in an external library (20)", true},
+		{"[AVHWDeviceContext @ 0x5601] cu->cuInit(0) failed -> CUDA_ERROR_NO_DEVICE: no CUDA-capable device is detected\nDevice creation failed: -542398533.", true},
+		{"[AVHWDeviceContext @ 0x5601] Failed to initialise VAAPI connection: -1 (unknown libva error).\nDevice creation failed: -5.", false},
+		{"[h264_nvenc @ 0x55d0] No capable devices found\nGeneric error in an external library", false},
+		{"[h264_nvenc @ 0x55d0] OpenEncodeSessionEx failed: out of memory (10)\nGeneric error in an external library", false},
+		{"Conversion failed!", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransientInitFailure(tt.stderr); got != tt.want {
+			t.Errorf("IsTransientInitFailure(%q) = %v, want %v", tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestStreamCheckFailure(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   string
+	}{
+		{"[tcp @ 0x5601] Connection to tcp://10.0.0.1:1935 failed: Connection refused", "connection refused: nothing is listening at that host and port"},
+		{"[tcp @ 0x5601] Failed to resolve hostname live.example.invalid: Name or service not known", "the host name could not be resolved"},
+		{"dial tcp: lookup live.example.invalid: no such host", "the host name could not be resolved"},
+		{"[rtmp @ 0x5601] Server error: Authentication failed", "the server rejected the stream: check the stream key, passphrase or credentials"},
+		{"[srt @ 0x5601] Connection setup failure: connection rejected", "the server rejected the stream: check the stream key, passphrase or credentials"},
+		{"[srt @ 0x5601] Connection setup failure: connection timed out", "the connection timed out: the host is unreachable or a firewall drops it"},
+		{"Conversion failed!", ""},
+	}
+
+	for _, tt := range tests {
+		if got := StreamCheckFailure(tt.stderr); got != tt.want {
+			t.Errorf("StreamCheckFailure(%q) = %q, want %q", tt.stderr, got, tt.want)
+		}
+	}
+}
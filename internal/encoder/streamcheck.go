@@ -0,0 +1,69 @@
+package encoder
+
+import (
+	"strconv"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// StreamCheckSeconds is how long -check-stream publishes its test pattern to non-RTMP outputs
+const StreamCheckSeconds = 1
+
+// streamCheckPrefixes are the outputs -check-stream can test: protocols that connect to
+// the endpoint before sending. UDP sends blindly, so a wrong address can't be detected.
+var streamCheckPrefixes = []string{"rtmp://", "rtmps://", "srt://", "rtsp://", "rtsps://"}
+
+// streamCheckFailures maps FFmpeg's connection errors to an explanation of the likely cause
+var streamCheckFailures = []struct {
+	patterns []string
+	reason   string
+}{
+	{[]string{"connection refused"}, "connection refused: nothing is listening at that host and port"},
+	{[]string{"name or service not known", "no such host", "failed to resolve", "nodename nor servname", "no address associated"},
+		"the host name could not be resolved"},
+	{[]string{"unauthorized", "forbidden", "authentication", "connection rejected", "server error"},
+		"the server rejected the stream: check the stream key, passphrase or credentials"},
+	{[]string{"timed out", "timeout"}, "the connection timed out: the host is unreachable or a firewall drops it"},
+	{[]string{"network is unreachable", "no route to host"}, "the host is unreachable from this network"},
+}
+
+// StreamCheckable reports whether -check-stream can test the output
+func StreamCheckable(outputPath string) bool {
+	lower := strings.ToLower(outputPath)
+	for _, prefix := range streamCheckPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildStreamCheckCommand returns the FFmpeg arguments that publish a short, small test
+// pattern with silent audio to the streaming output, in the container the encode will use
+func (cb *CommandBuilder) BuildStreamCheckCommand(config *config.ProcessingConfig) []string {
+	args := []string{
+		"-hide_banner", "-loglevel", "error",
+		"-re", "-f", "lavfi", "-i", "testsrc=size=320x240:rate=25",
+		"-f", "lavfi", "-i", silentAudioSource,
+		"-t", strconv.Itoa(StreamCheckSeconds),
+		"-c:v", "libx264", "-preset", "ultrafast", "-tune", "zerolatency", "-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+	}
+	args = append(args, OutputFormatArgsFor(config)...)
+	if isRTSP(config.OutputPath) {
+		args = append(args, rtspOutputOptions(config)...)
+	}
+	return append(args, config.OutputPath)
+}
+
+// StreamCheckFailure explains a failed -check-stream from FFmpeg's stderr, or returns ""
+// when the error isn't a recognised connection failure
+func StreamCheckFailure(stderr string) string {
+	for _, failure := range streamCheckFailures {
+		if containsAny(stderr, failure.patterns) {
+			return failure.reason
+		}
+	}
+	return ""
+}
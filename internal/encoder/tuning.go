@@ -0,0 +1,93 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// hqLookahead is how many frames -hq lets the encoder look ahead for rate control and AQ
+const hqLookahead = "32"
+
+// HQTuningCodecs are the encoders -hq has extra quality options for
+var HQTuningCodecs = []string{"h264_nvenc", "hevc_nvenc", "h264_qsv"}
+
+// addHQTuning enables the encoder's slower, higher-quality options for -hq. Support depends
+// on the hardware and driver generation:
+//
+//   - NVENC -multipass fullres: NVENC SDK 10 (FFmpeg 4.4+, NVIDIA driver 450+); FFmpeg 4 and
+//     older builds get the equivalent -2pass 1
+//   - NVENC -spatial-aq and -rc-lookahead: every NVENC generation FFmpeg supports (Maxwell+)
+//   - NVENC -temporal-aq: Pascal or newer for H.264, Turing or newer for HEVC
+//   - QSV -look_ahead: h264_qsv only; look-ahead with -global_quality (LA_ICQ) needs a
+//     runtime that implements it, which older Linux Media SDK builds don't
+//
+// Options a GPU doesn't support make FFmpeg fail to open the encoder, in which case the
+// usual fallbacks retry without them.
+func (cb *CommandBuilder) addHQTuning(args []string, cfg *config.ProcessingConfig) []string {
+	switch {
+	case strings.HasSuffix(cfg.Codec, "_nvenc"):
+		if cfg.FFmpegMajor > 0 && cfg.FFmpegMajor <= 4 {
+			args = append(args, "-2pass", "1")
+		} else {
+			args = append(args, "-multipass", "fullres")
+		}
+		args = append(args, "-spatial-aq", "1", "-temporal-aq", "1", "-rc-lookahead", hqLookahead)
+	case cfg.Codec == "h264_qsv":
+		args = append(args, "-look_ahead", "1", "-look_ahead_depth", hqLookahead)
+	}
+	return args
+}
+
+// nvencTuneMinMajor is the first FFmpeg major version known to have NVENC's -tune option. It
+// arrived in FFmpeg 4.3, but 4.x builds can't be told apart from the major version alone.
+const nvencTuneMinMajor = 5
+
+// NVENCTune returns the NVENC tuning mode for the encode: -nvenc-tune when given, otherwise
+// picked from what the output is for:
+//
+//	lossless  -lossless encodes, matching their constqp QP 0 rate control
+//	ull       streaming outputs (RTMP, SRT, RTSP, pipes), where latency matters most
+//	hq        file outputs, where quality matters more than latency
+//
+// ll (low latency) is only used when asked for; it keeps more quality than ull at a little
+// more delay, e.g. for interactive streams.
+func (cb *CommandBuilder) NVENCTune(cfg *config.ProcessingConfig) string {
+	switch {
+	case cfg.NVENCTune != "":
+		return cfg.NVENCTune
+	case cfg.Lossless:
+		return "lossless"
+	case !cb.IsFileOutput(cfg.OutputPath):
+		return "ull"
+	}
+	return "hq"
+}
+
+// ValidateNVENCTune checks that the tuning mode agrees with the NVENC preset. The legacy
+// low-latency (ll, llhq, llhp) and lossless presets set their own tuning, which FFmpeg
+// applies over -tune.
+func ValidateNVENCTune(tune, preset string) error {
+	switch {
+	case strings.HasPrefix(preset, "ll") && tune != "ll" && tune != "ull":
+		return fmt.Errorf("NVENC preset %s is a low-latency preset and can't be tuned for %s; use -nvenc-tune ll or ull", preset, tune)
+	case strings.HasPrefix(preset, "lossless") && tune != "lossless":
+		return fmt.Errorf("NVENC preset %s is lossless and can't be tuned for %s", preset, tune)
+	case tune == "lossless" && !strings.HasPrefix(preset, "lossless"):
+		return fmt.Errorf("-nvenc-tune lossless needs NVENC's lossless preset; use -lossless")
+	}
+	return nil
+}
+
+// addTuning adds the encoder's -tune: NVENC's tuning mode, or zerolatency for the x264-style
+// encoders. NVENC rejects zerolatency, and FFmpeg builds before -tune existed get neither.
+func (cb *CommandBuilder) addTuning(args []string, cfg *config.ProcessingConfig) []string {
+	if !strings.HasSuffix(cfg.Codec, "_nvenc") {
+		return append(args, "-tune", "zerolatency")
+	}
+	if cfg.FFmpegMajor > 0 && cfg.FFmpegMajor < nvencTuneMinMajor {
+		return args
+	}
+	return append(args, "-tune", cb.NVENCTune(cfg))
+}
@@ -0,0 +1,53 @@
+package encoder
+
+import (
+	"reflect"
+	"testing"
+
+	"video_processing/internal/config"
+)
+
+func TestAddTuning(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProcessingConfig
+		want []string
+	}{
+		{"x264", config.ProcessingConfig{Codec: "libx264", OutputPath: "out.mp4"}, []string{"-tune", "zerolatency"}},
+		{"nvenc file", config.ProcessingConfig{Codec: "h264_nvenc", OutputPath: "out.mp4"}, []string{"-tune", "hq"}},
+		{"nvenc stream", config.ProcessingConfig{Codec: "h264_nvenc", OutputPath: "rtmp://live.example.com/app/key"}, []string{"-tune", "ull"}},
+		{"nvenc lossless", config.ProcessingConfig{Codec: "hevc_nvenc", OutputPath: "out.mkv", Lossless: true}, []string{"-tune", "lossless"}},
+		{"nvenc explicit", config.ProcessingConfig{Codec: "h264_nvenc", OutputPath: "out.mp4", NVENCTune: "ll"}, []string{"-tune", "ll"}},
+		{"nvenc on FFmpeg 4", config.ProcessingConfig{Codec: "h264_nvenc", OutputPath: "out.mp4", FFmpegMajor: 4}, nil},
+	}
+
+	cb := NewCommandBuilder()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cb.addTuning(nil, &tt.cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addTuning() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNVENCTune(t *testing.T) {
+	tests := []struct {
+		tune, preset string
+		wantErr      bool
+	}{
+		{"hq", "medium", false},
+		{"ull", "p4", false},
+		{"ull", "llhq", false},
+		{"hq", "llhq", true},
+		{"lossless", "lossless", false},
+		{"hq", "lossless", true},
+		{"lossless", "medium", true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateNVENCTune(tt.tune, tt.preset); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateNVENCTune(%s, %s) error = %v, wantErr %v", tt.tune, tt.preset, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package encoder
+
+import (
+	"fmt"
+	"video_processing/internal/config"
+)
+
+// vp9AV1Speed maps each speed level to the encoder's own speed option: -cpu-used for
+// libvpx-vp9 and libaom-av1 (higher is faster), -preset for SVT-AV1 (0-13, higher is faster)
+var vp9AV1Speed = map[string]map[string]string{
+	"libvpx-vp9": {config.SpeedSlow: "1", config.SpeedBalanced: "2", config.SpeedFast: "4"},
+	"libaom-av1": {config.SpeedSlow: "3", config.SpeedBalanced: "5", config.SpeedFast: "7"},
+	"libsvtav1":  {config.SpeedSlow: "4", config.SpeedBalanced: "8", config.SpeedFast: "10"},
+}
+
+// IsWebMCodec reports whether the video encoder produces a codec WebM can hold
+func IsWebMCodec(codec string) bool {
+	switch codec {
+	case "libvpx", "libvpx-vp9", "libaom-av1", "libsvtav1":
+		return true
+	}
+	return false
+}
+
+// addVP9AV1Encoding adds constant-quality VP9/AV1 software encoding with row-based
+// multithreading and tiles. Without them libvpx-vp9 and libaom-av1 encode on a single thread.
+func (cb *CommandBuilder) addVP9AV1Encoding(args []string, cfg *config.ProcessingConfig) []string {
+	speed := cfg.Speed
+	if speed == "" {
+		speed = config.SpeedBalanced
+	}
+	level := vp9AV1Speed[cfg.Codec][speed]
+	quality := fmt.Sprintf("%d", cfg.Quality)
+
+	args = append(args, "-c:v", cfg.Codec)
+	switch cfg.Codec {
+	case "libvpx-vp9":
+		// -b:v 0 selects pure constant quality; tile-columns is log2, so 2 means up to 4 columns
+		args = append(args, "-crf", quality, "-b:v", "0", "-deadline", "good", "-cpu-used", level)
+		args = append(args, "-row-mt", "1", "-tile-columns", "2", "-frame-parallel", "0")
+	case "libaom-av1":
+		args = append(args, "-crf", quality, "-b:v", "0", "-cpu-used", level)
+		args = append(args, "-row-mt", "1", "-tiles", "2x2")
+	case "libsvtav1":
+		// SVT-AV1 threads and tiles internally
+		args = append(args, "-crf", quality, "-preset", level)
+	}
+	return args
+}
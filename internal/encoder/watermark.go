@@ -0,0 +1,74 @@
+package encoder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"video_processing/internal/config"
+)
+
+// watermarkLabel names the watermarked video in the filter graph, for -map
+const watermarkLabel = "[vout]"
+
+// watermarkMargin is the gap in pixels between the watermark and the frame edges
+const watermarkMargin = 10
+
+// watermarkPosition returns the overlay coordinates for -watermark-position. main_w and
+// main_h are the frame that reaches the overlay, i.e. after scaling and cropping.
+func watermarkPosition(position string) string {
+	m := watermarkMargin
+	switch position {
+	case config.WatermarkTopLeft:
+		return fmt.Sprintf("x=%d:y=%d", m, m)
+	case config.WatermarkTopRight:
+		return fmt.Sprintf("x=main_w-overlay_w-%d:y=%d", m, m)
+	case config.WatermarkBottomLeft:
+		return fmt.Sprintf("x=%d:y=main_h-overlay_h-%d", m, m)
+	case config.WatermarkCenter:
+		return "x=(main_w-overlay_w)/2:y=(main_h-overlay_h)/2"
+	}
+	return fmt.Sprintf("x=main_w-overlay_w-%d:y=main_h-overlay_h-%d", m, m)
+}
+
+// watermarkInput returns the input index of the -watermark image, which follows the source
+// and any -audio-file
+func (cb *CommandBuilder) watermarkInput(cfg *config.ProcessingConfig) int {
+	if cfg.AudioFile != "" {
+		return 2
+	}
+	return 1
+}
+
+// watermarkGraph returns the -filter_complex graph that burns the -watermark image into the
+// video. The source runs through its regular filters first, and the image is sized and
+// placed against the frame that comes out of them: scale2ref resolves a percentage against
+// that frame's width and the overlay coordinates use its size, so a downscale or crop
+// can't misplace the watermark. post runs after the overlay, e.g. the VAAPI upload.
+func (cb *CommandBuilder) watermarkGraph(cfg *config.ProcessingConfig, filters, post []string) string {
+	chain := "null"
+	if len(filters) > 0 {
+		chain = strings.Join(filters, ",")
+	}
+
+	var graph strings.Builder
+	fmt.Fprintf(&graph, "[0:v:0]%s[base];", chain)
+
+	base, logo := "[base]", fmt.Sprintf("[%d:v]", cb.watermarkInput(cfg))
+	switch size := cfg.WatermarkSize; {
+	case size.Percent > 0:
+		percent := strconv.FormatFloat(size.Percent, 'f', -1, 64)
+		fmt.Fprintf(&graph, "%s%sscale2ref=w=main_w*%s/100:h=ow/a[wm][ref];", logo, base, percent)
+		base, logo = "[ref]", "[wm]"
+	case size.Pixels > 0:
+		fmt.Fprintf(&graph, "%sscale=%d:-1[wm];", logo, size.Pixels)
+		logo = "[wm]"
+	}
+
+	fmt.Fprintf(&graph, "%s%soverlay=%s", base, logo, watermarkPosition(cfg.WatermarkPosition))
+	for _, filter := range post {
+		graph.WriteString("," + filter)
+	}
+	graph.WriteString(watermarkLabel)
+	return graph.String()
+}
@@ -0,0 +1,174 @@
+package estimate
+
+import (
+	"math"
+	"strconv"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/probe"
+)
+
+// Estimate is a rough prediction of an encode's output size and wall-clock time
+type Estimate struct {
+	DurationSeconds float64 // of the output, after -start/-duration and any speed change
+	VideoBitrate    float64 // bits per second
+	AudioBitrate    float64 // bits per second
+	SizeBytes       float64
+	EncodeSeconds   float64
+	EncodeFPS       float64
+}
+
+// Error bars applied when presenting an estimate; real results vary widely with content
+const (
+	SizeLow  = 0.5
+	SizeHigh = 1.75
+	TimeLow  = 0.5
+	TimeHigh = 2.0
+)
+
+// referenceBPP is the bits per pixel per frame libx264 typically produces at CRF 23
+const referenceBPP = 0.075
+
+// encoderSizeFactor accounts for hardware encoders needing more bits than libx264 at the same quality value
+var encoderSizeFactor = map[string]float64{
+	"h264_nvenc":        1.3,
+	"h264_qsv":          1.3,
+	"h264_vaapi":        1.4,
+	"h264_videotoolbox": 1.4,
+	"h264_amf":          1.4,
+	"libx264":           1.0,
+	// HEVC needs roughly 60-70% of H.264's bitrate at matching quality
+	"hevc_nvenc":        0.85,
+	"hevc_qsv":          0.85,
+	"hevc_vaapi":        0.9,
+	"hevc_videotoolbox": 0.9,
+	"hevc_amf":          0.9,
+	"libx265":           0.65,
+}
+
+// encoderFPS is a typical 1080p encode speed per encoder, used when no benchmark is available
+var encoderFPS = map[string]float64{
+	"h264_nvenc":        400,
+	"h264_qsv":          250,
+	"h264_vaapi":        200,
+	"h264_videotoolbox": 250,
+	"h264_amf":          250,
+	"hevc_nvenc":        300,
+	"hevc_qsv":          180,
+	"hevc_vaapi":        150,
+	"hevc_videotoolbox": 180,
+	"hevc_amf":          180,
+	"libx265":           20,
+}
+
+// x264PresetFPS is a typical 1080p libx264 speed per preset on a modern desktop CPU
+var x264PresetFPS = map[string]float64{
+	"ultrafast": 250, "superfast": 180, "veryfast": 130, "faster": 90,
+	"fast": 75, "medium": 60, "slow": 30, "slower": 15, "veryslow": 6,
+}
+
+const pixels1080p = 1920 * 1080
+
+// Compute predicts output size and encode time for the config from the probed input,
+// taking the trimmed range, -scale-height and -maxrate into account
+func Compute(cfg *config.ProcessingConfig, input *probe.Result) Estimate {
+	e := Estimate{DurationSeconds: outputDuration(cfg, input.DurationSeconds())}
+
+	video := input.VideoStream()
+	width, height, fps := 1920, 1080, 30.0
+	if video != nil {
+		if video.Width > 0 && video.Height > 0 {
+			width, height = video.Width, video.Height
+		}
+		if f := video.FPS(); f > 0 {
+			fps = f
+		}
+	}
+	if cfg.ScaleHeight > 0 && cfg.ScaleHeight < height && !cfg.CopyVideo {
+		width, height = width*cfg.ScaleHeight/height, cfg.ScaleHeight
+	}
+	pixels := float64(width * height)
+
+	switch {
+	case cfg.CopyVideo && video != nil && video.BitsPerSecond() > 0:
+		e.VideoBitrate = video.BitsPerSecond()
+	case cfg.CopyVideo:
+		e.VideoBitrate = sourceVideoBitrate(input)
+	default:
+		// Every 6 CRF steps roughly halves or doubles the bitrate, on x264's quality scale
+		crf := encoder.ConvertQuality(cfg.Quality, cfg.Codec, "libx264")
+		factor := encoderSizeFactor[cfg.Codec]
+		if factor == 0 {
+			factor = 1
+		}
+		e.VideoBitrate = referenceBPP * pixels * fps * math.Pow(2, float64(23-crf)/6) * factor
+		if maxRate := config.ParseBitrate(cfg.MaxRate); maxRate > 0 {
+			e.VideoBitrate = math.Min(e.VideoBitrate, maxRate)
+		}
+	}
+
+	e.AudioBitrate = audioBitrate(cfg, input)
+	e.SizeBytes = (e.VideoBitrate + e.AudioBitrate) * e.DurationSeconds / 8
+
+	switch {
+	case cfg.CopyVideo:
+		// Stream copy is I/O bound; assume roughly 50x realtime
+		e.EncodeFPS = fps * 50
+	default:
+		base := encoderFPS[cfg.Codec]
+		if base == 0 {
+			base = x264PresetFPS[cfg.Preset]
+		}
+		if base == 0 {
+			base = x264PresetFPS["medium"]
+		}
+		e.EncodeFPS = base * pixels1080p / pixels
+	}
+	e.EncodeSeconds = e.DurationSeconds * fps / e.EncodeFPS
+
+	return e
+}
+
+// outputDuration is the length of the encoded range of an input lasting total seconds: what
+// follows -start, sped up or slowed down, and capped by -duration, which counts output time
+func outputDuration(cfg *config.ProcessingConfig, total float64) float64 {
+	if start, err := config.ParseDuration(cfg.StartTime); err == nil {
+		total = math.Max(total-start, 0)
+	}
+	if cfg.PlaybackSpeed > 0 {
+		total /= cfg.PlaybackSpeed
+	}
+	if d, err := config.ParseDuration(cfg.Duration); err == nil {
+		total = math.Min(total, d)
+	}
+	return total
+}
+
+// sourceVideoBitrate approximates the video bitrate from the container when the stream doesn't report one
+func sourceVideoBitrate(input *probe.Result) float64 {
+	total, _ := strconv.ParseFloat(input.Format.BitRate, 64)
+	if audio := input.AudioStream(); audio != nil {
+		total -= audio.BitsPerSecond()
+	}
+	return math.Max(total, 0)
+}
+
+func audioBitrate(cfg *config.ProcessingConfig, input *probe.Result) float64 {
+	if cfg.NoAudio {
+		return 0
+	}
+	if cfg.AudioCodec != "" && cfg.AudioCodec != "copy" {
+		if b := config.ParseBitrate(cfg.AudioBitrate); b > 0 {
+			return b
+		}
+		return 128_000
+	}
+	if audio := input.AudioStream(); audio != nil {
+		if b := audio.BitsPerSecond(); b > 0 {
+			return b
+		}
+		return 192_000
+	}
+	return 0
+}
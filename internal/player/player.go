@@ -2,15 +2,36 @@ package player
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
+
+	"video_processing/internal/probe"
+	"video_processing/internal/style"
+)
+
+// finishedMargin is how close to the end playback must stop to count as watched to the end
+const finishedMargin = 5.0
+
+var (
+	// ErrNoPlayer is returned when none of the supported players is installed
+	ErrNoPlayer = errors.New("no video player available")
+
+	// ErrPlayerStart is returned when the installed players all failed to start
+	ErrPlayerStart = errors.New("video player failed to start")
 )
 
 // Player handles video playback
 type Player struct {
-	reader *bufio.Reader
+	reader   *bufio.Reader
+	resume   *ResumeStore
+	prober   *probe.Prober
+	detached bool // start the player without waiting for it to exit
 }
 
 // New creates a new player instance
@@ -22,7 +43,7 @@ func New() *Player {
 
 // OfferPlayback asks user if they want to play the video
 func (p *Player) OfferPlayback(outputPath string) error {
-	fmt.Print("\n🎥 Would you like to play the processed video? (y/n): ")
+	fmt.Printf("\n%s Would you like to play the processed video? (y/n): ", style.Play)
 	choice, _ := p.reader.ReadString('\n')
 	choice = strings.TrimSpace(strings.ToLower(choice))
 
@@ -33,9 +54,46 @@ func (p *Player) OfferPlayback(outputPath string) error {
 	return nil
 }
 
+// EnableResume makes PlayVideo continue from the position where playback of a file last
+// stopped. prober supplies the duration used to tell a finished file from an interrupted one.
+func (p *Player) EnableResume(store *ResumeStore, prober *probe.Prober) {
+	p.resume = store
+	p.prober = prober
+}
+
+// SetDetached makes PlayVideo return as soon as the player has started instead of waiting
+// for it to exit. Resume positions can't be tracked for detached playback.
+func (p *Player) SetDetached(detached bool) {
+	p.detached = detached
+}
+
+// PlayPreview plays a temporary file from the start without remembering its position. It
+// always waits for the player, since the preview is removed once playback ends.
+func (p *Player) PlayPreview(videoPath string) error {
+	resume, detached := p.resume, p.detached
+	p.resume, p.detached = nil, false
+	defer func() { p.resume, p.detached = resume, detached }()
+	return p.PlayVideo(videoPath)
+}
+
 // PlayVideo plays the specified video file
 func (p *Player) PlayVideo(videoPath string) error {
-	fmt.Printf("🎬 Opening video: %s\n", videoPath)
+	fmt.Printf("%s Opening video: %s\n", style.Video, videoPath)
+
+	ffplayArgs := []string{"-autoexit", "-window_title", "Processed Video"}
+	vlcArgs := []string{"--intf", "dummy", "--play-and-exit"}
+	mpvArgs := []string{"--really-quiet"}
+
+	var start float64
+	if p.resume != nil {
+		if start = p.resume.Position(videoPath); start > 0 {
+			fmt.Printf("%s Resuming at %s (last watched position)\n", style.Play, formatPosition(start))
+			seconds := strconv.FormatFloat(start, 'f', 3, 64)
+			ffplayArgs = append(ffplayArgs, "-ss", seconds)
+			vlcArgs = append(vlcArgs, "--start-time="+seconds)
+			mpvArgs = append(mpvArgs, "--start="+seconds)
+		}
+	}
 
 	// Check for available players
 	players := []struct {
@@ -43,49 +101,112 @@ func (p *Player) PlayVideo(videoPath string) error {
 		args []string
 		name string
 	}{
-		{"ffplay", []string{"-autoexit", "-window_title", "Processed Video", videoPath}, "FFplay"},
-		{"vlc", []string{"--intf", "dummy", "--play-and-exit", videoPath}, "VLC"},
-		{"mpv", []string{"--really-quiet", videoPath}, "MPV"},
+		{"ffplay", append(ffplayArgs, videoPath), "FFplay"},
+		{"vlc", append(vlcArgs, videoPath), "VLC"},
+		{"mpv", append(mpvArgs, videoPath), "MPV"},
 	}
 
+	var startErrs []string
 	for _, player := range players {
-		if _, err := exec.LookPath(player.cmd); err == nil {
-			fmt.Printf("🎯 Using %s\n", player.name)
+		if _, err := exec.LookPath(player.cmd); err != nil {
+			continue
+		}
+		fmt.Printf("%s Using %s\n", style.Target, player.name)
 
+		if p.detached {
+			// The player outlives this process, so it gets no terminal streams
 			cmd := exec.Command(player.cmd, player.args...)
-			cmd.Stdin = os.Stdin
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-
 			if err := cmd.Start(); err != nil {
-				fmt.Printf("❌ Failed to start %s: %v\n", player.name, err)
+				fmt.Printf("%s Failed to start %s: %v\n", style.Error, player.name, err)
+				startErrs = append(startErrs, fmt.Sprintf("%s: %v", player.name, err))
 				continue
 			}
-
-			if player.cmd == "ffplay" {
-				p.printFFplayControls()
+			fmt.Printf("%s %s started (pid %d); not waiting for playback to finish\n", style.OK, player.name, cmd.Process.Pid)
+			if p.resume != nil {
+				fmt.Println(style.Info, "The playback position isn't saved for detached playback")
 			}
+			return cmd.Process.Release()
+		}
 
-			if err := cmd.Wait(); err != nil {
-				fmt.Printf("%s exited with error: %v\n", player.name, err)
-			} else {
-				fmt.Println("✅ Video playback finished")
-			}
+		clock := &clockWriter{}
+		cmd := exec.Command(player.cmd, player.args...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if player.cmd == "ffplay" {
+			cmd.Stderr = io.MultiWriter(os.Stderr, clock)
+		}
 
-			return nil
+		if err := cmd.Start(); err != nil {
+			fmt.Printf("%s Failed to start %s: %v\n", style.Error, player.name, err)
+			startErrs = append(startErrs, fmt.Sprintf("%s: %v", player.name, err))
+			continue
 		}
+
+		if player.cmd == "ffplay" {
+			p.printFFplayControls()
+		}
+
+		// Playback happened even if the player then failed, e.g. on a decode error or when
+		// its window was closed, so that is reported but not returned
+		var exitErr *exec.ExitError
+		if err := cmd.Wait(); errors.As(err, &exitErr) {
+			fmt.Printf("%s %s exited with code %d\n", style.Warn, player.name, exitErr.ExitCode())
+		} else if err != nil {
+			fmt.Printf("%s %s exited with error: %v\n", style.Warn, player.name, err)
+		} else {
+			fmt.Println(style.OK, "Video playback finished")
+		}
+
+		if p.resume != nil {
+			// Only ffplay reports its clock; VLC and MPV can be paused or seeked, so the
+			// wall-clock time says nothing about where they stopped
+			if position := clock.Clock(); position > 0 {
+				p.rememberPosition(videoPath, position)
+			} else if player.cmd != "ffplay" {
+				fmt.Printf("%s %s doesn't report its position; it is only saved when playing with FFplay\n", style.Info, player.name)
+			}
+		}
+		return nil
+	}
+
+	if len(startErrs) > 0 {
+		return fmt.Errorf("%w (%s)", ErrPlayerStart, strings.Join(startErrs, "; "))
 	}
 
-	fmt.Println("❌ No video player found. Please install one of:")
+	fmt.Println(style.Error, "No video player found. Please install one of:")
 	fmt.Println("   - FFmpeg (ffplay): https://ffmpeg.org/download.html")
 	fmt.Println("   - VLC: https://www.videolan.org/vlc/")
 	fmt.Println("   - MPV: https://mpv.io/")
 
-	return fmt.Errorf("no video player available")
+	return ErrNoPlayer
+}
+
+// rememberPosition stores where playback stopped, or forgets the file if it was watched to the end
+func (p *Player) rememberPosition(videoPath string, position float64) {
+	if result, err := p.prober.Probe(videoPath); err == nil {
+		if duration := result.DurationSeconds(); duration > 0 && position >= duration-finishedMargin {
+			position = 0
+		}
+	}
+
+	if err := p.resume.Save(videoPath, position); err != nil {
+		fmt.Printf("%s Could not save playback position: %v\n", style.Warn, err)
+		return
+	}
+	if position > 0 {
+		fmt.Printf("%s Playback position %s saved; the next playback resumes there\n", style.Save, formatPosition(position))
+	}
+}
+
+// formatPosition formats seconds as H:MM:SS
+func formatPosition(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
 }
 
 func (p *Player) printFFplayControls() {
-	fmt.Println("🎮 FFplay controls:")
+	fmt.Println(style.Controls, "FFplay controls:")
 	fmt.Println("   Space: Pause/Play")
 	fmt.Println("   ←/→: Seek ±10 seconds")
 	fmt.Println("   ↑/↓: Seek ±1 minute")
@@ -0,0 +1,136 @@
+package player
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resumeEntry is the remembered playback position of one file
+type resumeEntry struct {
+	Position float64   `json:"position"`
+	ModTime  time.Time `json:"mod_time"`
+}
+
+// ResumeStore remembers where playback of each file stopped. Positions are keyed by absolute
+// path and dropped when the file has been modified since, e.g. by re-encoding to the same output.
+type ResumeStore struct {
+	path string
+}
+
+// NewResumeStore creates a store kept in the JSON file at path
+func NewResumeStore(path string) *ResumeStore {
+	return &ResumeStore{path: path}
+}
+
+// DefaultResumePath returns the playback state location in the user's cache directory
+func DefaultResumePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "video_processing", "playback.json")
+}
+
+// Position returns the remembered position of the video in seconds, or 0 to start from the beginning
+func (s *ResumeStore) Position(videoPath string) float64 {
+	key, modTime, ok := resumeKey(videoPath)
+	if !ok {
+		return 0
+	}
+	entry, found := s.load()[key]
+	if !found || !entry.ModTime.Equal(modTime) {
+		return 0
+	}
+	return entry.Position
+}
+
+// Save remembers the position of the video; a position of 0 forgets it
+func (s *ResumeStore) Save(videoPath string, position float64) error {
+	key, modTime, ok := resumeKey(videoPath)
+	if !ok {
+		return nil
+	}
+
+	entries := s.load()
+	if position > 0 {
+		entries[key] = resumeEntry{Position: position, ModTime: modTime}
+	} else {
+		delete(entries, key)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o644)
+}
+
+func (s *ResumeStore) load() map[string]resumeEntry {
+	entries := map[string]resumeEntry{}
+	if data, err := os.ReadFile(s.path); err == nil {
+		_ = json.Unmarshal(data, &entries)
+	}
+	return entries
+}
+
+// resumeKey returns the store key and modification time of a local file
+func resumeKey(videoPath string) (string, time.Time, bool) {
+	abs, err := filepath.Abs(videoPath)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return abs, info.ModTime(), true
+}
+
+// clockWriter passes ffplay's stderr through while recording the playback clock from its
+// status line ("  12.34 A-V:  0.001 fd=   0 aq= ..."), which follows seeks and pauses
+type clockWriter struct {
+	mu      sync.Mutex
+	clock   float64
+	pending string
+}
+
+func (w *clockWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending += string(b)
+	lines := strings.FieldsFunc(w.pending, func(r rune) bool { return r == '\r' || r == '\n' })
+	if n := len(w.pending); n > 0 && w.pending[n-1] != '\r' && w.pending[n-1] != '\n' && len(lines) > 0 {
+		// Keep the unterminated tail for the next write
+		w.pending = lines[len(lines)-1]
+		lines = lines[:len(lines)-1]
+	} else {
+		w.pending = ""
+	}
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.Contains(line, "fd=") {
+			continue
+		}
+		if t, err := strconv.ParseFloat(fields[0], 64); err == nil && t > 0 {
+			w.clock = t
+		}
+	}
+	return len(b), nil
+}
+
+// Clock returns the last playback position ffplay reported
+func (w *clockWriter) Clock() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.clock
+}
@@ -0,0 +1,61 @@
+package player
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResumeStore(t *testing.T) {
+	dir := t.TempDir()
+	video := filepath.Join(dir, "out.mp4")
+	if err := os.WriteFile(video, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewResumeStore(filepath.Join(dir, "state", "playback.json"))
+
+	if got := store.Position(video); got != 0 {
+		t.Fatalf("Position() before saving = %v, want 0", got)
+	}
+	if err := store.Save(video, 754.5); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if got := store.Position(video); got != 754.5 {
+		t.Errorf("Position() = %v, want 754.5", got)
+	}
+
+	// Re-encoding to the same path invalidates the position
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(video, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.Position(video); got != 0 {
+		t.Errorf("Position() after modification = %v, want 0", got)
+	}
+
+	if err := store.Save(video, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(video, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.Position(video); got != 0 {
+		t.Errorf("Position() after forgetting = %v, want 0", got)
+	}
+}
+
+func TestClockWriter(t *testing.T) {
+	w := &clockWriter{}
+	w.Write([]byte("Input #0, mov,mp4, from 'out.mp4':\n  Duration: 00:10:00.00\n"))
+	w.Write([]byte("  12.34 A-V:  0.001 fd=   0 aq=   12KB vq=  340KB sq=    0B \r  13.0"))
+	if got := w.Clock(); got != 12.34 {
+		t.Errorf("Clock() = %v, want 12.34", got)
+	}
+
+	// A status line split across writes is parsed once complete
+	w.Write([]byte("5 A-V:  0.000 fd=   1 aq=   10KB vq=  320KB sq=    0B \r"))
+	if got := w.Clock(); got != 13.05 {
+		t.Errorf("Clock() = %v, want 13.05", got)
+	}
+}
@@ -0,0 +1,254 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"video_processing/internal/capture"
+)
+
+// Stream describes a single stream reported by ffprobe
+type Stream struct {
+	Index      int               `json:"index"`
+	CodecType  string            `json:"codec_type"`
+	CodecName  string            `json:"codec_name"`
+	Width      int               `json:"width,omitempty"`
+	Height     int               `json:"height,omitempty"`
+	PixFmt     string            `json:"pix_fmt,omitempty"`
+	ColorRange string            `json:"color_range,omitempty"`
+	FrameRate  string            `json:"r_frame_rate,omitempty"`
+	Channels   int               `json:"channels,omitempty"`
+	BitRate    string            `json:"bit_rate,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+}
+
+// Format describes the container reported by ffprobe
+type Format struct {
+	FormatName string `json:"format_name"`
+	Duration   string `json:"duration,omitempty"`
+	StartTime  string `json:"start_time,omitempty"`
+	BitRate    string `json:"bit_rate,omitempty"`
+	Size       string `json:"size,omitempty"`
+}
+
+// Result holds the parsed ffprobe output for an input
+type Result struct {
+	Streams []Stream `json:"streams"`
+	Format  Format   `json:"format"`
+}
+
+// VideoStream returns the first video stream, or nil if the input has none
+func (r *Result) VideoStream() *Stream {
+	for i := range r.Streams {
+		if r.Streams[i].CodecType == "video" {
+			return &r.Streams[i]
+		}
+	}
+	return nil
+}
+
+// AudioStream returns the first audio stream, or nil if the input has none
+func (r *Result) AudioStream() *Stream {
+	for i := range r.Streams {
+		if r.Streams[i].CodecType == "audio" {
+			return &r.Streams[i]
+		}
+	}
+	return nil
+}
+
+// DurationSeconds returns the container duration, or 0 when unknown (e.g. live streams)
+func (r *Result) DurationSeconds() float64 {
+	d, err := strconv.ParseFloat(r.Format.Duration, 64)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// StartSeconds returns the container's first timestamp, or 0 when unknown. FFmpeg's -ss
+// counts from it, while packet timestamps include it.
+func (r *Result) StartSeconds() float64 {
+	s, err := strconv.ParseFloat(r.Format.StartTime, 64)
+	if err != nil {
+		return 0
+	}
+	return s
+}
+
+// FPS parses the stream's frame rate (e.g. "30000/1001"), returning 0 when unknown
+func (s *Stream) FPS() float64 {
+	num, den, found := strings.Cut(s.FrameRate, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	if !found {
+		return n
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+// Range returns the stream's color range, "tv" (limited) or "pc" (full), or "" when the
+// stream doesn't say. The deprecated yuvj pixel formats are always full range.
+func (s *Stream) Range() string {
+	switch {
+	case s.ColorRange == "pc" || strings.HasPrefix(s.PixFmt, "yuvj"):
+		return "pc"
+	case s.ColorRange == "tv":
+		return "tv"
+	}
+	return ""
+}
+
+// BitsPerSecond parses the stream bit rate, returning 0 when unknown
+func (s *Stream) BitsPerSecond() float64 {
+	b, err := strconv.ParseFloat(s.BitRate, 64)
+	if err != nil {
+		return 0
+	}
+	return b
+}
+
+// ErrFFprobeNotFound is returned when the ffprobe binary can't be found. Some installs ship
+// only ffmpeg, so callers should fall back to their non-probe behavior.
+var ErrFFprobeNotFound = errors.New("ffprobe not found")
+
+// Prober inspects media inputs with ffprobe
+type Prober struct {
+	ffprobePath string
+	timeout     time.Duration
+	httpOptions []string // protocol options for HTTP(S) inputs, such as -headers
+}
+
+// New creates a new prober instance that runs the given ffprobe binary
+func New(ffprobePath string) *Prober {
+	return &Prober{
+		ffprobePath: ffprobePath,
+		timeout:     30 * time.Second,
+	}
+}
+
+// SetHTTPOptions sets options passed before HTTP(S) inputs, such as the -headers and
+// -cookies an authenticated source needs
+func (p *Prober) SetHTTPOptions(options []string) {
+	p.httpOptions = options
+}
+
+// Available reports whether the ffprobe binary can be found
+func (p *Prober) Available() bool {
+	_, err := exec.LookPath(p.ffprobePath)
+	return err == nil
+}
+
+// Version returns the version string reported by `ffprobe -version`
+func (p *Prober) Version() (string, error) {
+	if !p.Available() {
+		return "", fmt.Errorf("%w (%s)", ErrFFprobeNotFound, p.ffprobePath)
+	}
+	out, err := exec.Command(p.ffprobePath, "-version").Output()
+	if err != nil {
+		return "", fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	// First line looks like: "ffprobe version 6.1.1-3ubuntu5 Copyright (c) 2007-2023 ..."
+	firstLine, _, _ := strings.Cut(string(out), "\n")
+	fields := strings.Fields(firstLine)
+	if len(fields) < 3 || fields[1] != "version" {
+		return "", fmt.Errorf("unrecognised ffprobe -version output: %q", firstLine)
+	}
+	return fields[2], nil
+}
+
+// Probe runs ffprobe against the input and parses its stream and format information
+func (p *Prober) Probe(input string) (*Result, error) {
+	if !p.Available() {
+		return nil, fmt.Errorf("%w (%s)", ErrFFprobeNotFound, p.ffprobePath)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	args := []string{"-v", "error", "-print_format", "json", "-show_streams", "-show_format"}
+	cmd := exec.CommandContext(ctx, p.ffprobePath, append(args, p.inputArgs(input)...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var result Result
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	return &result, nil
+}
+
+// inputArgs returns ffprobe's input arguments, naming the capture format for device inputs
+// and adding the HTTP options for HTTP(S) inputs
+func (p *Prober) inputArgs(input string) []string {
+	if capture.IsDevice(input) {
+		if device, err := capture.Parse(input); err == nil {
+			return []string{"-f", device.Format, device.Name}
+		}
+	}
+	lower := strings.ToLower(input)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		return append(append([]string{}, p.httpOptions...), input)
+	}
+	return []string{input}
+}
+
+// keyframeSearchWindow is how far before a seek point, in seconds, ffprobe looks for keyframes
+const keyframeSearchWindow = 30
+
+// KeyframeBefore returns the position of the last video keyframe at or before t seconds,
+// which is where a stream-copy cut starting at t actually begins. Positions are relative to
+// the container's start time, as -ss is; start is that time, from Result.StartSeconds.
+func (p *Prober) KeyframeBefore(input string, t, start float64) (float64, error) {
+	if !p.Available() {
+		return 0, fmt.Errorf("%w (%s)", ErrFFprobeNotFound, p.ffprobePath)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	t += start
+	from := math.Max(0, t-keyframeSearchWindow)
+	args := []string{
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-skip_frame", "nokey",
+		"-read_intervals", fmt.Sprintf("%.3f%%%.3f", from, t+0.001),
+		"-show_entries", "frame=best_effort_timestamp_time",
+		"-of", "csv=p=0",
+	}
+	cmd := exec.CommandContext(ctx, p.ffprobePath, append(args, p.inputArgs(input)...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	keyframe := -1.0
+	for _, line := range strings.Split(string(out), "\n") {
+		ts, err := strconv.ParseFloat(strings.Trim(strings.TrimSpace(line), ","), 64)
+		if err == nil && ts <= t+0.001 && ts > keyframe {
+			keyframe = ts
+		}
+	}
+	if keyframe < 0 {
+		return 0, fmt.Errorf("no keyframe found within %ds before %.3fs", keyframeSearchWindow, t-start)
+	}
+	return math.Max(0, keyframe-start), nil
+}
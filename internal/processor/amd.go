@@ -0,0 +1,26 @@
+package processor
+
+import (
+	"fmt"
+
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// useAMF decides whether an AMD GPU on Linux encodes with AMF instead of VAAPI. VAAPI stays
+// the default since it works with the stock Mesa drivers; AMF needs AMD's runtime but is the
+// way out when a Mesa release breaks VAAPI H.264 encoding. -amd-encoder amf fails when AMF
+// can't run, while auto quietly keeps VAAPI.
+func (p *Processor) useAMF(cfg *config.ProcessingConfig) (bool, error) {
+	err := p.validator.ValidateAMF("h264_amf")
+	switch {
+	case err == nil:
+		fmt.Printf("%s AMD AMF encoder available; using it instead of VAAPI (-amd-encoder %s)\n", style.Switch, cfg.AMDEncoder)
+		return true, nil
+	case cfg.AMDEncoder == config.AMDEncoderAMF:
+		return false, fmt.Errorf("-amd-encoder amf: %w; use -amd-encoder vaapi for Mesa's encoder", err)
+	default:
+		fmt.Printf("%s AMF is not usable (%v); using VAAPI\n", style.Info, err)
+		return false, nil
+	}
+}
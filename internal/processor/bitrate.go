@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"fmt"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// overBudgetMargin is how far, as a fraction of -maxrate, the output's average video bitrate
+// may exceed the cap before the encode is flagged as over budget
+const overBudgetMargin = 0.10
+
+// checkOutputBitrate shows the bitrate of each output stream and, when -maxrate is set, warns
+// if the video averaged clearly above it. That points at a cap the encoder didn't honour,
+// e.g. a CRF/CQ mode producing far more than a bandwidth-limited target can carry.
+func (p *Processor) checkOutputBitrate(cfg *config.ProcessingConfig) {
+	video, audio := p.outputBitrates(cfg)
+	if video <= 0 {
+		return
+	}
+	p.job.VideoBitrate, p.job.AudioBitrate = int64(video), int64(audio)
+	if audio > 0 {
+		fmt.Printf("%s Output bitrate: video %.0f kbps, audio %.0f kbps\n", style.Stats, video/1e3, audio/1e3)
+	} else {
+		fmt.Printf("%s Output bitrate: video %.0f kbps\n", style.Stats, video/1e3)
+	}
+
+	limit := config.ParseBitrate(cfg.MaxRate)
+	if limit <= 0 || cfg.CopyVideo || video <= limit*(1+overBudgetMargin) {
+		return
+	}
+	p.job.OverBudget = true
+	fmt.Printf("%s Video averaged %.0f kbps, %.0f%% over -maxrate %s; the target may not keep up. Check that %s honours -maxrate/-bufsize or lower the quality\n",
+		style.Warn, video/1e3, (video/limit-1)*100, cfg.MaxRate, cfg.Codec)
+}
+
+// outputBitrates returns the average video and audio bitrates of the output in bits per
+// second, or 0 when they can't be measured. Files are probed; streams use FFmpeg's own
+// progress reports, less the expected audio share.
+func (p *Processor) outputBitrates(cfg *config.ProcessingConfig) (video, audio float64) {
+	if encoder.IsStreamingURL(cfg.OutputPath) {
+		// A fallback encode runs without progress reports, so the figure would be stale
+		if p.streamBitrate <= 0 || p.job.Fallback != "" {
+			return 0, 0
+		}
+		audio = p.streamAudioBitrate(cfg)
+		return max(0, p.streamBitrate-audio), audio
+	}
+
+	files := p.outputFiles(cfg)
+	if !p.commandBuilder.IsFileOutput(cfg.OutputPath) || len(files) == 0 {
+		return 0, 0
+	}
+	result, err := p.prober.Probe(files[0])
+	if err != nil || result.VideoStream() == nil {
+		return 0, 0
+	}
+
+	if a := result.AudioStream(); a != nil {
+		audio = a.BitsPerSecond()
+	}
+	video = result.VideoStream().BitsPerSecond()
+	if video <= 0 {
+		// Matroska and MPEG-TS don't record per-stream bitrates; derive it from the container's
+		total := config.ParseBitrate(result.Format.BitRate)
+		share := audio
+		if result.AudioStream() != nil && share <= 0 {
+			share = p.streamAudioBitrate(cfg)
+		}
+		if total > 0 {
+			video = max(0, total-share)
+		}
+	}
+	return video, audio
+}
@@ -0,0 +1,29 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+
+	"video_processing/internal/capture"
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// prepareCapture checks options that don't apply to a capture device and says how the
+// capture ends, since a device never reaches the end of its input
+func (p *Processor) prepareCapture(cfg *config.ProcessingConfig) error {
+	device, err := capture.Parse(cfg.InputPath)
+	if err != nil {
+		return err
+	}
+	if cfg.StartTime != "" {
+		return errors.New("-start can't seek in a capture device; use -duration to limit the capture")
+	}
+
+	if cfg.Duration != "" {
+		fmt.Printf("%s Capturing %ss from %s (%s)\n", style.Video, cfg.Duration, device.Name, device.Format)
+	} else {
+		fmt.Printf("%s Capturing from %s (%s) until stopped with Ctrl+C\n", style.Video, device.Name, device.Format)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package processor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// prepareClosedGOP checks -closed-gop has what broadcast output needs: an encoder and
+// container that support it and a fixed -gop cadence. Without -cfr the GOPs are a fixed
+// number of frames but not of a fixed duration, so that is pointed out.
+func (p *Processor) prepareClosedGOP(cfg *config.ProcessingConfig) error {
+	if !slices.Contains(encoder.ClosedGOPCodecs, cfg.Codec) {
+		return fmt.Errorf("-closed-gop is not supported with %s (supported: %s)", cfg.Codec, strings.Join(encoder.ClosedGOPCodecs, ", "))
+	}
+	format := p.commandBuilder.OutputFormat(cfg.OutputPath)
+	if !slices.Contains(encoder.ClosedGOPFormats, format) {
+		return fmt.Errorf("-closed-gop needs MPEG-TS (.ts), HLS, MP4, MOV or Matroska output, not %s", format)
+	}
+	if cfg.GOPSize == 0 {
+		return fmt.Errorf("-closed-gop needs a fixed keyframe interval: set -gop, e.g. -gop 50 for 2-second GOPs at 25 fps")
+	}
+
+	fmt.Printf("%s Closed GOPs every %d frames, no scene-cut keyframes\n", style.Ruler, cfg.GOPSize)
+	if !cfg.CFR {
+		fmt.Printf("%s -closed-gop without -cfr: GOPs have %d frames but a variable frame rate may vary their duration\n", style.Warn, cfg.GOPSize)
+	}
+	return nil
+}
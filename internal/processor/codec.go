@@ -0,0 +1,107 @@
+package processor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+	"video_processing/utils"
+)
+
+// resolveCodecFamily switches the configured H.264 encoder to HEVC for -codec-family hevc,
+// or for auto when the source is at least -hevc-min-height. It stays on H.264 when the
+// output container can't carry HEVC or FFmpeg lacks an HEVC encoder for the hardware path.
+func (p *Processor) resolveCodecFamily(cfg *config.ProcessingConfig) {
+	if cfg.CodecFamily == config.CodecFamilyAuto && !p.prefersHEVC(cfg) {
+		return
+	}
+
+	format := p.commandBuilder.OutputFormat(cfg.OutputPath)
+	if err := p.validator.ValidateStreamCopy(format, "hevc"); err != nil {
+		fmt.Printf("%s %s output can't carry HEVC; keeping %s\n", style.Warn, format, cfg.Codec)
+		return
+	}
+
+	hevc := encoder.HEVCEncoder(cfg.Codec)
+	if hevc == "" {
+		fmt.Printf("%s No HEVC counterpart for %s; keeping it\n", style.Warn, cfg.Codec)
+		return
+	}
+
+	encoders, err := p.validator.Encoders()
+	switch {
+	case err != nil || encoders[hevc]:
+	case encoders["libx265"]:
+		fmt.Printf("%s FFmpeg has no %s encoder; using libx265 (software)\n", style.Warn, hevc)
+		cfg.SetSoftwareEncoding()
+		hevc = "libx265"
+	default:
+		fmt.Printf("%s FFmpeg has no HEVC encoder (%s or libx265); keeping %s\n", style.Warn, hevc, cfg.Codec)
+		return
+	}
+
+	fmt.Printf("%s Codec family %s: encoding HEVC with %s\n", style.Switch, cfg.CodecFamily, hevc)
+	cfg.Codec = hevc
+}
+
+// prefersHEVC reports whether the source, after any -scale-height downscale, reaches the
+// HEVC threshold. The shorter side is compared so portrait 4K counts as 4K.
+func (p *Processor) prefersHEVC(cfg *config.ProcessingConfig) bool {
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		fmt.Printf("%s Could not probe input resolution, using H.264: %v\n", style.Warn, err)
+		return false
+	}
+	video := result.VideoStream()
+	if video == nil || video.Width <= 0 || video.Height <= 0 {
+		return false
+	}
+
+	shorter := min(video.Width, video.Height)
+	if cfg.ScaleHeight > 0 && cfg.ScaleHeight < video.Height {
+		shorter = shorter * cfg.ScaleHeight / video.Height
+	}
+
+	if shorter < cfg.HEVCMinHeight {
+		fmt.Printf("%s Source is %dx%d, below the %dp HEVC threshold; using H.264\n", style.Film, video.Width, video.Height, cfg.HEVCMinHeight)
+		return false
+	}
+	fmt.Printf("%s Source is %dx%d, at or above the %dp HEVC threshold\n", style.Film, video.Width, video.Height, cfg.HEVCMinHeight)
+	return true
+}
+
+// configureCodecOverride sets up the encoder chosen with -codec, with the acceleration and
+// preset that go with it (or -hwaccel's acceleration, when given). It fails if FFmpeg lacks
+// the encoder and warns when it won't run on a detected GPU.
+func (p *Processor) configureCodecOverride(cfg *config.ProcessingConfig, gpus []utils.GPUInfo) error {
+	if encoders, err := p.validator.Encoders(); err == nil && !encoders[cfg.CodecOverride] {
+		return fmt.Errorf("-codec %s: this FFmpeg build has no such encoder", cfg.CodecOverride)
+	}
+
+	acceleration, codec, preset := p.encoder.ConfigureForCodec(cfg.CodecOverride)
+	if cfg.HWAccel != "" {
+		acceleration = cfg.HWAccel
+	}
+	if codec == "libx264" || codec == "libx265" {
+		preset = cfg.SoftwarePreset
+	}
+	cfg.SetHardwareEncoding(acceleration, codec, preset)
+	cfg.Quality = encoder.ConvertQuality(cfg.Quality, "libx264", cfg.Codec)
+
+	vendors := encoder.CodecVendors(codec)
+	switch {
+	case vendors == nil:
+		fmt.Printf("%s -codec %s encodes in software, without GPU acceleration\n", style.Warn, codec)
+	case !slices.ContainsFunc(gpus, func(gpu utils.GPUInfo) bool { return slices.Contains(vendors, gpu.Vendor) }):
+		fmt.Printf("%s -codec %s needs a %s GPU, but none was detected; the encode will likely fall back to software\n",
+			style.Warn, codec, strings.Join(vendors, " or "))
+	}
+
+	fmt.Printf("%s Encoder: %s (-codec), acceleration: %s\n", style.Rocket, cfg.Codec, cfg.Acceleration)
+	fmt.Printf("%s Quality setting: %d, Preset: %s\n", style.Stats, cfg.Quality, cfg.Preset)
+	fmt.Println(strings.Repeat("-", 50))
+	return nil
+}
@@ -0,0 +1,43 @@
+package processor
+
+import (
+	"fmt"
+
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// resolveColorRange probes the source's color range for -color-range, assuming limited (tv)
+// range when the stream doesn't declare one, as players do. Without a probe, -color-range
+// auto is dropped and tv/pc only tag the output.
+func (p *Processor) resolveColorRange(cfg *config.ProcessingConfig) {
+	result, err := p.prober.Probe(cfg.InputPath)
+	var source string
+	if err == nil && result.VideoStream() != nil {
+		source = result.VideoStream().Range()
+		if source == "" {
+			fmt.Printf("%s Source doesn't declare its color range; assuming tv (limited)\n", style.Info)
+			source = config.ColorRangeTV
+		}
+	}
+
+	if source == "" {
+		if cfg.ColorRange == config.ColorRangeAuto {
+			fmt.Printf("%s Could not probe the source's color range; leaving it to FFmpeg\n", style.Warn)
+			cfg.ColorRange = ""
+			return
+		}
+		fmt.Printf("%s Could not probe the source's color range; tagging the output %s without converting\n", style.Warn, cfg.ColorRange)
+		return
+	}
+
+	cfg.SourceColorRange = source
+	if cfg.ColorRange == config.ColorRangeAuto {
+		cfg.ColorRange = source
+	}
+	if source != cfg.ColorRange {
+		fmt.Printf("%s Converting color range %s → %s\n", style.Switch, source, cfg.ColorRange)
+	} else {
+		fmt.Printf("%s Keeping color range %s\n", style.Info, source)
+	}
+}
@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+	"video_processing/internal/version"
+)
+
+// commandLogName is the sidecar used when the output isn't a file (streams, pipes)
+const commandLogName = "video_processing.cmd"
+
+// commandSidecarPath returns where the FFmpeg command for an output is recorded
+func (p *Processor) commandSidecarPath(cfg *config.ProcessingConfig) string {
+	if p.finalOutput != "" {
+		return p.finalOutput + ".cmd"
+	}
+	if !p.commandBuilder.IsFileOutput(cfg.OutputPath) {
+		return commandLogName
+	}
+	return cfg.OutputPath + ".cmd"
+}
+
+// saveCommand appends the FFmpeg command, with the tool version and a timestamp, to the
+// output's sidecar file. Each encode adds one entry, so repeated runs build a log.
+func (p *Processor) saveCommand(cfg *config.ProcessingConfig, args []string) {
+	path := p.commandSidecarPath(cfg)
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			fmt.Printf("%s Could not save command: %v\n", style.Warn, err)
+			return
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		fmt.Printf("%s Could not save command: %v\n", style.Warn, err)
+		return
+	}
+	defer f.Close()
+
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "# video_processing %s, %s\n", version.Get().Version, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&entry, "%s\n\n", encoder.FormatArgs(append([]string{cfg.FFmpegPath}, args...)))
+	if _, err := f.WriteString(entry.String()); err != nil {
+		fmt.Printf("%s Could not save command: %v\n", style.Warn, err)
+		return
+	}
+	fmt.Printf("%s Command saved to %s\n", style.Note, path)
+}
@@ -0,0 +1,125 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"video_processing/internal/style"
+)
+
+// dependency is an external tool the program runs when it's available
+type dependency struct {
+	name string
+	// platforms limits the check to these GOOS values; empty means every platform
+	platforms []string
+	// versionArgs print the tool's version cheaply; nil skips the version query
+	versionArgs []string
+	// unavailable describes what stops working without the tool
+	unavailable string
+	required    bool
+}
+
+// depVersionTimeout bounds each version query, since some tools start slowly or hang
+const depVersionTimeout = 5 * time.Second
+
+// dependencies returns the tools CheckDeps looks for, with ffmpeg and ffprobe resolved from -ffmpeg-path
+func (p *Processor) dependencies() []dependency {
+	return []dependency{
+		{name: p.cfg.FFmpegPath, versionArgs: []string{"-version"}, unavailable: "all encoding and streaming", required: true},
+		{name: p.cfg.FFprobePath(), versionArgs: []string{"-version"},
+			unavailable: "input probing: progress percentages, codec and start checks, -estimate, -segment-size, -preview, output bitrate checks"},
+		{name: "ffplay", versionArgs: []string{"-version"}, unavailable: "-preview and playback with resume (VLC or MPV still play the output)"},
+		{name: "vlc", unavailable: "playback with VLC"},
+		{name: "mpv", versionArgs: []string{"--version"}, unavailable: "playback with MPV"},
+		{name: "nvidia-smi", versionArgs: []string{"--query-gpu=driver_version", "--format=csv,noheader"},
+			unavailable: "NVIDIA GPU details, -gpu-id UUID matching, encoder session checks and GPU detection under WSL"},
+		{name: "lspci", platforms: []string{"linux"}, versionArgs: []string{"--version"}, unavailable: "GPU detection on Linux"},
+		{name: "lshw", platforms: []string{"linux"}, unavailable: "GPU detection fallback on Linux"},
+		{name: "glxinfo", platforms: []string{"linux"}, unavailable: "GPU detection fallback on Linux"},
+		{name: "vainfo", platforms: []string{"linux"}, unavailable: "VAAPI H.264 capability check"},
+		{name: "powershell", platforms: []string{"windows"}, unavailable: "GPU detection on Windows"},
+		{name: "wmic", platforms: []string{"windows"}, unavailable: "GPU detection fallback on Windows"},
+		{name: "system_profiler", platforms: []string{"darwin"}, unavailable: "GPU detection on macOS"},
+	}
+}
+
+// CheckDeps reports which of the optional tools are installed, with their versions, and
+// what is unavailable without the missing ones. It fails only when FFmpeg itself is missing.
+func (p *Processor) CheckDeps() error {
+	fmt.Println(style.Tool, "Checking external tools...")
+
+	var missingRequired []string
+	found, checked := 0, 0
+	for _, dep := range p.dependencies() {
+		if len(dep.platforms) > 0 && !slices.Contains(dep.platforms, runtime.GOOS) {
+			continue
+		}
+		checked++
+
+		path, err := exec.LookPath(dep.name)
+		if err != nil {
+			marker := style.Warn
+			if dep.required {
+				marker = style.Error
+				missingRequired = append(missingRequired, dep.name)
+			}
+			fmt.Printf("   %s %-16s not found (unavailable: %s)\n", marker, dep.name, dep.unavailable)
+			continue
+		}
+		found++
+
+		version := toolVersion(path, dep.versionArgs)
+		if version == "" {
+			version = "-"
+		}
+		fmt.Printf("   %s %-16s %-20s %s\n", style.OK, dep.name, version, path)
+	}
+
+	fmt.Printf("\n%s %d of %d tools found\n", style.Stats, found, checked)
+	if len(missingRequired) > 0 {
+		return fmt.Errorf("%s not found; install FFmpeg or point -ffmpeg-path at it", strings.Join(missingRequired, ", "))
+	}
+	return nil
+}
+
+// toolVersion runs the tool's version query and extracts the version, or returns "" if
+// the tool has none or the query fails
+func toolVersion(path string, args []string) string {
+	if args == nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), depVersionTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, args...).Output()
+	if err != nil && !errors.As(err, new(*exec.ExitError)) || len(out) == 0 {
+		return ""
+	}
+	return parseToolVersion(string(out))
+}
+
+// parseToolVersion picks the version from the first line of a tool's output: the word after
+// "version" ("ffmpeg version 6.1.1 ..."), else the first word of a bare version line ("550.54.14")
+func parseToolVersion(out string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	fields := strings.Fields(line)
+	for i, field := range fields {
+		if strings.EqualFold(field, "version") && i+1 < len(fields) {
+			return strings.TrimSuffix(fields[i+1], ",")
+		}
+	}
+	// mpv prints "mpv 0.37.0 Copyright ..."
+	if len(fields) >= 2 && strings.ContainsAny(fields[1], "0123456789") {
+		return fields[1]
+	}
+	if len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
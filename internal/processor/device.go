@@ -0,0 +1,31 @@
+package processor
+
+import (
+	"fmt"
+	"slices"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+	"video_processing/utils"
+)
+
+// reconcileDevice checks the device FFmpeg's log shows the encode running on against the
+// detected GPU it was configured for. The device is recorded in the report, and a different
+// vendor is warned about, e.g. VAAPI opening the integrated GPU when a discrete one was picked.
+func (p *Processor) reconcileDevice(cfg *config.ProcessingConfig, stderr []byte) {
+	used, ok := encoder.DeviceFromStderr(slices.Concat(p.deviceLog, stderr))
+	if !ok {
+		return
+	}
+	p.job.Device = used.String()
+	if used.Vendor == "" || p.gpu.Vendor == "" || used.Vendor == p.gpu.Vendor {
+		return
+	}
+
+	p.job.DeviceMismatch = true
+	fmt.Printf("%s FFmpeg encoded on %s, not the detected %s %s\n", style.Warn, used, utils.VendorName(p.gpu.Vendor), p.gpu.Model)
+	if cfg.Acceleration == "vaapi" && cfg.RenderNode == "" {
+		fmt.Println("   VAAPI used /dev/dri/renderD128, which may be the other GPU; pick the GPU with -gpu-id <PCI address>")
+	}
+}
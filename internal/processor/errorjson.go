@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os/exec"
+
+	"video_processing/internal/encoder"
+	"video_processing/internal/probe"
+	"video_processing/internal/report"
+	"video_processing/internal/validator"
+)
+
+// Error classes written by -error-json, so wrappers can react without parsing messages
+const (
+	ErrorClassUsage          = "usage"            // invalid options or profile
+	ErrorClassNoInput        = "no_input"         // no input given, or none could be prompted for
+	ErrorClassOutputExists   = "output_exists"    // the output exists, or would overwrite the input
+	ErrorClassStalled        = "stalled"          // the watchdog stopped an encode without progress
+	ErrorClassUnreachable    = "unreachable"      // -check-stream could not publish to the streaming output
+	ErrorClassMissingTool    = "missing_tool"     // ffmpeg or ffprobe not found
+	ErrorClassCancelled      = "cancelled"        // the encode was cancelled or timed out
+	ErrorClassEncodeFailed   = "encode_failed"    // FFmpeg failed, including every fallback
+	ErrorClassFallbackFailed = "fallbacks_failed" // the fallbacks failed without an FFmpeg error to report
+	ErrorClassOther          = "error"
+)
+
+// errorJSON is the object -error-json writes. ExitCode is the tool's own exit status;
+// FFmpegExitCode and Stderr come from the failed FFmpeg run, when there was one.
+type errorJSON struct {
+	Class          string `json:"class"`
+	Message        string `json:"message"`
+	ExitCode       int    `json:"exit_code"`
+	FFmpegExitCode int    `json:"ffmpeg_exit_code,omitempty"`
+	Stderr         string `json:"stderr,omitempty"`
+}
+
+// ErrorClass names the kind of failure from the typed errors the processor returns
+func ErrorClass(err error) string {
+	var encodeErr *encoder.EncodeError
+	switch {
+	case errors.Is(err, ErrNoInput), errors.Is(err, ErrNotInteractive):
+		return ErrorClassNoInput
+	case errors.Is(err, ErrOutputExists), errors.Is(err, ErrOutputIsInput):
+		return ErrorClassOutputExists
+	case errors.Is(err, ErrEncodeStalled):
+		return ErrorClassStalled
+	case errors.Is(err, ErrStreamUnreachable):
+		return ErrorClassUnreachable
+	case errors.Is(err, exec.ErrNotFound), errors.Is(err, validator.ErrFFmpegNotFound), errors.Is(err, probe.ErrFFprobeNotFound):
+		return ErrorClassMissingTool
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassCancelled
+	case errors.As(err, &encodeErr):
+		return ErrorClassEncodeFailed
+	case errors.Is(err, encoder.ErrAllFallbacksFailed):
+		return ErrorClassFallbackFailed
+	}
+	return ErrorClassOther
+}
+
+// WriteErrorJSON writes err to w as a single-line JSON object of the given class, with the
+// exit code the tool is about to exit with and the FFmpeg details of a wrapped EncodeError.
+// Credentials of the urls, the input and output, are redacted as in the report.
+func WriteErrorJSON(w io.Writer, class string, err error, exitCode int, urls ...string) error {
+	jobErr := report.NewJobError(err, urls...)
+	return json.NewEncoder(w).Encode(errorJSON{
+		Class:          class,
+		Message:        jobErr.Message,
+		ExitCode:       exitCode,
+		FFmpegExitCode: jobErr.ExitCode,
+		Stderr:         jobErr.Stderr,
+	})
+}
@@ -0,0 +1,23 @@
+package processor
+
+import "errors"
+
+var (
+	// ErrNoInput is returned when the user does not provide an input path
+	ErrNoInput = errors.New("no input provided")
+
+	// ErrNotInteractive is returned when a required answer can't be prompted for because stdin is not a terminal
+	ErrNotInteractive = errors.New("stdin is not a terminal")
+
+	// ErrOutputExists is returned when the output exists and the overwrite policy is "error"
+	ErrOutputExists = errors.New("output file already exists")
+
+	// ErrEncodeStalled is returned when the watchdog stops an encode that made no progress for -stall-timeout
+	ErrEncodeStalled = errors.New("encode stalled")
+
+	// ErrOutputIsInput is returned when the output would overwrite the input and -in-place is not set
+	ErrOutputIsInput = errors.New("output is the same file as the input")
+
+	// ErrStreamUnreachable is returned when -check-stream could not reach the streaming output
+	ErrStreamUnreachable = errors.New("streaming output check failed")
+)
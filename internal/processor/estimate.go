@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"video_processing/internal/estimate"
+	"video_processing/internal/style"
+)
+
+// Estimate runs detection, configuration and input prompts like Run, then prints a rough
+// prediction of output size and encode time instead of encoding
+func (p *Processor) Estimate() error {
+	fmt.Println(style.Ruler, "FFmpeg Video Processor estimate")
+	fmt.Println(strings.Repeat("=", 50))
+
+	gpus, err := p.detectAndDisplayGPUs()
+	if err != nil {
+		return fmt.Errorf("GPU detection failed: %w", err)
+	}
+
+	cfg, err := p.configureProcessing(gpus)
+	if err != nil {
+		return fmt.Errorf("configuration failed: %w", err)
+	}
+
+	if err := p.getUserInput(cfg); err != nil {
+		return fmt.Errorf("input failed: %w", err)
+	}
+
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		return fmt.Errorf("could not probe input: %w", err)
+	}
+	if result.DurationSeconds() <= 0 {
+		return fmt.Errorf("input has no known duration (live stream?), nothing to estimate")
+	}
+
+	e := estimate.Compute(cfg, result)
+
+	fmt.Println(strings.Repeat("-", 50))
+	fmt.Println(style.Warn, "ESTIMATE ONLY — actual results depend heavily on content and hardware")
+	fmt.Printf("%s Output duration: %v\n", style.Timer, secondsToDuration(e.DurationSeconds).Round(time.Second))
+	fmt.Printf("%s Predicted bitrate: ~%.1f Mbps video + %.0f kbps audio\n", style.Stats, e.VideoBitrate/1e6, e.AudioBitrate/1e3)
+	fmt.Printf("%s Predicted size: ~%.1f MB (likely %.1f–%.1f MB)\n", style.Save,
+		e.SizeBytes/(1024*1024), e.SizeBytes*estimate.SizeLow/(1024*1024), e.SizeBytes*estimate.SizeHigh/(1024*1024))
+	fmt.Printf("%s Predicted encode time: ~%v (likely %v–%v at ~%.0f fps with %s)\n", style.Clock,
+		secondsToDuration(e.EncodeSeconds).Round(time.Second),
+		secondsToDuration(e.EncodeSeconds*estimate.TimeLow).Round(time.Second),
+		secondsToDuration(e.EncodeSeconds*estimate.TimeHigh).Round(time.Second),
+		e.EncodeFPS, cfg.Codec)
+
+	return nil
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
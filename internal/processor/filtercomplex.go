@@ -0,0 +1,22 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// warnFilterComplex explains what a -filter-complex graph replaces. The graph is passed to
+// FFmpeg untouched, so the options that generate video filters stop having an effect, and
+// hardware encoders that take GPU surfaces need the graph to upload its frames itself.
+func (p *Processor) warnFilterComplex(cfg *config.ProcessingConfig) {
+	fmt.Printf("%s Using the custom -filter-complex graph as-is; decoded frames reach it in system memory\n", style.Warn)
+	if replaced := p.commandBuilder.ReplacedFilters(cfg); len(replaced) > 0 {
+		fmt.Printf("%s Skipping the generated video filters: %s\n", style.Warn, strings.Join(replaced, ","))
+	}
+	if strings.HasSuffix(cfg.Codec, "_vaapi") && !strings.Contains(cfg.FilterComplex, "hwupload") {
+		fmt.Printf("%s %s encodes from GPU surfaces; end the graph with format=nv12,hwupload or the encode will fail\n", style.Warn, cfg.Codec)
+	}
+}
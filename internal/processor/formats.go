@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// ListFormats prints the output containers the command builder can target with their FFmpeg
+// muxers, flagging any the local FFmpeg build cannot write
+func (p *Processor) ListFormats() error {
+	muxers, err := p.validator.Muxers()
+	if err != nil {
+		fmt.Printf("%s Could not query FFmpeg muxers, availability not checked: %v\n", style.Warn, err)
+	}
+
+	fmt.Println(style.Package, "Supported output formats:")
+	fmt.Printf("   %-10s %-10s %s\n", "EXTENSION", "MUXER", "LOCAL FFMPEG")
+	for _, ext := range encoder.OutputExtensions {
+		muxer := p.commandBuilder.OutputFormat("output" + ext)
+
+		status := "unknown"
+		if muxers != nil {
+			status = style.OK.String() + " available"
+			if !muxers[muxer] {
+				status = style.Error.String() + " not available"
+			}
+		}
+		fmt.Printf("   %-10s %-10s %s\n", ext, muxer, status)
+	}
+
+	fmt.Println(strings.Repeat("-", 50))
+	fmt.Println("Streaming URLs: rtmp(s):// -> flv, rtsp(s):// -> rtsp, srt:// udp:// tcp:// -> mpegts,")
+	fmt.Println("http(s):// -> hls (.m3u8), dash (.mpd), mp4 (.mp4) or mpegts")
+	return nil
+}
@@ -0,0 +1,138 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// imageExtensions are the still formats -frame-at writes, chosen by the output's extension
+var imageExtensions = []string{".png", ".jpg", ".jpeg", ".webp", ".bmp", ".tif", ".tiff"}
+
+// ExtractFrame saves the frame at -frame-at as an image. Accurate seeking decodes from the
+// preceding keyframe up to the exact frame; fast seeking takes that keyframe, which is
+// quicker on long-GOP sources but can be seconds early.
+func (p *Processor) ExtractFrame() error {
+	fmt.Println(style.Camera, "FFmpeg Frame Extractor")
+	fmt.Println(strings.Repeat("=", 50))
+
+	cfg := p.cfg
+	input, err := p.promptInputPath(cfg, fmt.Sprintf("%s Enter input video file path: ", style.Folder), func(s string) error {
+		if s == "" {
+			return ErrNoInput
+		}
+		if p.commandBuilder.IsLiveInput(s) {
+			return fmt.Errorf("-frame-at needs a file or recorded stream to seek in, got %s", s)
+		}
+		return p.validator.ValidateInput(s)
+	})
+	if err != nil {
+		return fmt.Errorf("input failed: %w", err)
+	}
+	if err := p.useInput(cfg, input); err != nil {
+		return err
+	}
+
+	defaultOutput := frameOutputPath(cfg)
+	output, err := p.promptValue(fmt.Sprintf("%s Output image (default: %s): ", style.Save, defaultOutput), p.validateOutputPath)
+	if err != nil {
+		return fmt.Errorf("input failed: %w", err)
+	}
+	cfg.OutputPath = defaultOutput
+	if output != "" {
+		cfg.OutputPath = output
+	}
+	if err := p.createOutputDir(cfg, output); err != nil {
+		return err
+	}
+	if !slices.Contains(imageExtensions, strings.ToLower(filepath.Ext(cfg.OutputPath))) {
+		return fmt.Errorf("output %s is not an image; use one of %s", cfg.OutputPath, strings.Join(imageExtensions, ", "))
+	}
+
+	position, _ := strconv.ParseFloat(cfg.FrameAt, 64)
+	if err := p.checkFramePosition(cfg, position); err != nil {
+		return err
+	}
+
+	skip, err := p.applyOverwritePolicy(cfg)
+	if err != nil {
+		return err
+	}
+	if skip {
+		fmt.Printf("%s Output %s already exists, skipping (overwrite policy: %s)\n", style.Skip, cfg.OutputPath, cfg.OverwritePolicy)
+		return nil
+	}
+
+	args := frameArgs(cfg)
+	fmt.Printf("Command: %s %s\n", cfg.FFmpegPath, encoder.FormatArgs(args))
+	if out, err := exec.CommandContext(context.Background(), cfg.FFmpegPath, args...).CombinedOutput(); err != nil {
+		return encoder.NewEncodeError(err, out)
+	}
+	if info, err := os.Stat(cfg.OutputPath); err != nil || info.Size() == 0 {
+		return fmt.Errorf("FFmpeg wrote no frame; is %ss within the video?", cfg.FrameAt)
+	}
+
+	fmt.Printf("%s Frame at %ss saved to %s\n", style.OK, cfg.FrameAt, cfg.OutputPath)
+	return nil
+}
+
+// checkFramePosition rejects a position past the end of the input and, for fast seeking,
+// reports which keyframe will be used instead
+func (p *Processor) checkFramePosition(cfg *config.ProcessingConfig, position float64) error {
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		fmt.Printf("%s Could not probe input, skipping the position check: %v\n", style.Warn, err)
+		return nil
+	}
+	if result.VideoStream() == nil {
+		return fmt.Errorf("%s has no video stream", cfg.InputPath)
+	}
+	if total := result.DurationSeconds(); total > 0 && position >= total {
+		return fmt.Errorf("frame position %.3fs is beyond the end of the input (%.3fs)", position, total)
+	}
+
+	if cfg.FrameSeek != config.FrameSeekFast {
+		return nil
+	}
+	if keyframe, err := p.prober.KeyframeBefore(cfg.InputPath, position, result.StartSeconds()); err == nil && position-keyframe > 0.001 {
+		fmt.Printf("%s Fast seek: using the keyframe at %.3fs, %.3fs before the requested %.3fs\n", style.Info, keyframe, position-keyframe, position)
+	}
+	return nil
+}
+
+// frameArgs builds the FFmpeg command that writes the single frame. -ss before the input
+// seeks by the index; FFmpeg then decodes up to the exact position unless -noaccurate_seek
+// keeps the keyframe it landed on.
+func frameArgs(cfg *config.ProcessingConfig) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-ss", cfg.FrameAt}
+	if cfg.FrameSeek == config.FrameSeekFast {
+		args = append(args, "-noaccurate_seek")
+	}
+	args = append(args, encoder.InputArgs(cfg)...)
+	args = append(args, "-map", "0:v:0", "-frames:v", "1", "-update", "1")
+	switch strings.ToLower(filepath.Ext(cfg.OutputPath)) {
+	case ".jpg", ".jpeg":
+		args = append(args, "-q:v", "2")
+	}
+	return append(args, "-y", cfg.OutputPath)
+}
+
+// frameOutputPath names the image after the input and position, in the output's directory
+// (movie.mkv at 83.5s -> movie_83.5s.png), unless the output is already an image
+func frameOutputPath(cfg *config.ProcessingConfig) string {
+	if slices.Contains(imageExtensions, strings.ToLower(filepath.Ext(cfg.OutputPath))) {
+		return cfg.OutputPath
+	}
+	base := filepath.Base(cfg.InputPath)
+	name := strings.TrimSuffix(base, filepath.Ext(base)) + "_" + cfg.FrameAt + "s.png"
+	return filepath.Join(filepath.Dir(cfg.OutputPath), name)
+}
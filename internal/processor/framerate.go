@@ -0,0 +1,27 @@
+package processor
+
+import (
+	"fmt"
+
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// prepareFrameRate detects the FFmpeg version for -cfr, which picks between -fps_mode and
+// the older -vsync, and reports the output frame rate
+func (p *Processor) prepareFrameRate(cfg *config.ProcessingConfig) {
+	if cfg.CFR && cfg.FFmpegMajor == 0 {
+		if major, err := p.validator.FFmpegMajorVersion(); err == nil {
+			cfg.FFmpegMajor = major
+		}
+	}
+
+	switch {
+	case cfg.CFR && cfg.FrameRate != "":
+		fmt.Printf("%s Constant %s fps output; frames are dropped or duplicated to hold it\n", style.Film, cfg.FrameRate)
+	case cfg.CFR:
+		fmt.Printf("%s Constant frame rate output at the source's nominal rate; frames are dropped or duplicated to hold it\n", style.Film)
+	default:
+		fmt.Printf("%s Output frame rate: %s fps\n", style.Film, cfg.FrameRate)
+	}
+}
@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// alignHLSKeyframes sets the GOP to one HLS segment's worth of output frames, so keyframes
+// fall on segment boundaries. Without a known frame rate the encoder still forces a keyframe
+// at each boundary, but its own GOP length may add keyframes in between.
+func (p *Processor) alignHLSKeyframes(cfg *config.ProcessingConfig) {
+	seconds := encoder.HLSTime(cfg)
+	if cfg.GOPSize > 0 {
+		fmt.Printf("%s Keyframes every %d frames (-gop) and at each %ds HLS segment boundary\n", style.Ruler, cfg.GOPSize, seconds)
+		return
+	}
+
+	if fps, err := config.ParseFrameRate(cfg.FrameRate); err == nil {
+		cfg.GOPSize = int(math.Round(fps * float64(seconds)))
+		fmt.Printf("%s Keyframes every %d frames (%ds at -fps %s) to match HLS segments\n", style.Ruler, cfg.GOPSize, seconds, cfg.FrameRate)
+		return
+	}
+
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		fmt.Printf("%s Could not probe the frame rate, forcing keyframes every %ds only: %v\n", style.Warn, seconds, err)
+		return
+	}
+	video := result.VideoStream()
+	if video == nil || video.FPS() <= 0 {
+		fmt.Printf("%s Source frame rate unknown, forcing keyframes every %ds only\n", style.Warn, seconds)
+		return
+	}
+
+	fps := video.FPS()
+	cfg.GOPSize = int(math.Round(fps * float64(seconds)))
+	fmt.Printf("%s Keyframes every %d frames (%ds at %.3g fps) to match HLS segments\n", style.Ruler, cfg.GOPSize, seconds, fps)
+}
+
+// prepareABRAudio drops the audio of an ABR ladder when the input has none: every variant in
+// the stream map names an audio stream, which FFmpeg can't resolve for a video-only input
+func (p *Processor) prepareABRAudio(cfg *config.ProcessingConfig) {
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil || len(streamsOfType(result, "audio")) > 0 {
+		return
+	}
+	fmt.Println(style.Info, "Input has no audio; the renditions will be video-only")
+	cfg.NoAudio = true
+}
@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/report"
+	"video_processing/internal/style"
+)
+
+// sameFile reports whether two file paths refer to the same file, resolving relative
+// paths and, when both exist, symlinks and hard links
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA == nil && errB == nil && absA == absB {
+		return true
+	}
+
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// guardInPlace refuses an output that would overwrite the input, unless -in-place is set.
+// With -in-place the encode is redirected to a temporary file next to the input, and the
+// original output path is returned so finishTempOutput can move the result over it.
+func (p *Processor) guardInPlace(cfg *config.ProcessingConfig) (string, error) {
+	if !p.commandBuilder.IsFileOutput(cfg.OutputPath) || strings.Contains(cfg.InputPath, "://") {
+		return "", nil
+	}
+	if !sameFile(cfg.InputPath, cfg.OutputPath) {
+		return "", nil
+	}
+	if !cfg.InPlace {
+		return "", fmt.Errorf("%w: %s (choose another output or pass -in-place to replace it)", ErrOutputIsInput, cfg.OutputPath)
+	}
+
+	// Keep the extension so the output format is still detected from the name
+	dir, name := filepath.Split(cfg.OutputPath)
+	ext := filepath.Ext(name)
+	tmp, err := os.CreateTemp(dir, "."+strings.TrimSuffix(name, ext)+".*.tmp"+ext)
+	if err != nil {
+		return "", fmt.Errorf("could not create temporary output: %w", err)
+	}
+	tmp.Close()
+	// Only the unique name is needed; an existing file would trip the overwrite policy
+	os.Remove(tmp.Name())
+
+	final := cfg.OutputPath
+	cfg.OutputPath = tmp.Name()
+	p.finalOutput, p.replacesInput = final, true
+	fmt.Printf("%s Output replaces the input; encoding to %s first\n", style.Replace, cfg.OutputPath)
+	return final, nil
+}
+
+// useTempOutput redirects a single-file output to <output>.tmp, so the output only appears
+// under its name once complete and media servers watching the directory never ingest a
+// partial file. Streams, pipes, device files and multi-file outputs (segments, HLS, DASH)
+// are written directly, as are live and capture inputs: those usually end with Ctrl+C, which
+// stops this process while FFmpeg finalizes the file, so nothing would be left to move it into
+// place. It returns the final output path, or "" when not redirected.
+func (p *Processor) useTempOutput(cfg *config.ProcessingConfig) string {
+	if cfg.DisableAtomicWrite || !p.commandBuilder.IsFileOutput(cfg.OutputPath) ||
+		segmenting(cfg) || p.commandBuilder.IsLiveInput(cfg.InputPath) {
+		return ""
+	}
+	if format := p.commandBuilder.OutputFormat(cfg.OutputPath); format == "hls" || format == "dash" {
+		return ""
+	}
+	if info, err := os.Stat(cfg.OutputPath); err == nil && !info.Mode().IsRegular() {
+		return ""
+	}
+
+	final := cfg.OutputPath
+	cfg.OutputPath = final + encoder.TempSuffix
+	// A leftover from an interrupted run would trip -n or be mistaken for this encode's output
+	if err := os.Remove(cfg.OutputPath); err == nil {
+		fmt.Printf("%s Removed %s left over from an earlier run\n", style.Trash, cfg.OutputPath)
+	}
+	p.finalOutput = final
+	return final
+}
+
+// finalOutputPath returns the name the output will have once the encode finishes
+func (p *Processor) finalOutputPath(cfg *config.ProcessingConfig) string {
+	if p.finalOutput != "" {
+		return p.finalOutput
+	}
+	return cfg.OutputPath
+}
+
+// finishTempOutput moves the temporary output to its final name after a successful encode,
+// or removes it after a failed one (unless -keep-on-failure). With -in-place the final name
+// is the input, so the source stays untouched unless the encode succeeds.
+func (p *Processor) finishTempOutput(cfg *config.ProcessingConfig, final string, encodeErr error) error {
+	tmp := cfg.OutputPath
+	cfg.OutputPath = final
+	replacesInput := p.replacesInput
+	p.finalOutput, p.replacesInput = "", false
+
+	if encodeErr != nil {
+		if !cfg.KeepOnFailure {
+			os.Remove(tmp)
+		}
+		return encodeErr
+	}
+	if p.job.Status == report.StatusSkipped {
+		os.Remove(tmp)
+		return nil
+	}
+	if err := moveFile(tmp, final); err != nil {
+		return fmt.Errorf("could not move the encoded output to %s (kept at %s): %w", final, tmp, err)
+	}
+	if replacesInput {
+		fmt.Printf("%s Replaced %s with the encoded output\n", style.Replace, final)
+	} else {
+		fmt.Printf("%s Moved the finished output into place: %s\n", style.Folder, final)
+	}
+	return nil
+}
+
+// moveFile renames src to dst, replacing dst. When a rename isn't possible, e.g. across
+// filesystems, it copies and then removes src; the copy is not atomic.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,48 @@
+package processor
+
+import (
+	"fmt"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// prepareLossless switches to an encoder with a lossless mode, checks the container suits a
+// lossless master and keeps the source's chroma resolution
+func (p *Processor) prepareLossless(cfg *config.ProcessingConfig) error {
+	if err := p.validator.ValidateLossless(p.commandBuilder.OutputFormat(cfg.OutputPath)); err != nil {
+		return err
+	}
+
+	if !encoder.SupportsLossless(cfg.Codec) {
+		fallback := encoder.LosslessFallback(cfg.Codec)
+		fmt.Printf("%s %s has no lossless mode; switching to %s\n", style.Switch, cfg.Codec, fallback)
+		cfg.SetSoftwareEncoding()
+		cfg.Codec = fallback
+	}
+
+	var source string
+	if result, err := p.prober.Probe(cfg.InputPath); err != nil {
+		fmt.Printf("%s Could not probe the source pixel format, letting FFmpeg choose: %v\n", style.Warn, err)
+	} else if video := result.VideoStream(); video != nil {
+		source = video.PixFmt
+	}
+
+	if source != "" && !encoder.LosslessDepth(cfg.Codec, source) {
+		fallback := encoder.LosslessFallback(cfg.Codec)
+		fmt.Printf("%s %s can't keep %d-bit %s losslessly; switching to %s\n", style.Switch, cfg.Codec, encoder.PixFmtDepth(source), source, fallback)
+		cfg.SetSoftwareEncoding()
+		cfg.Codec = fallback
+	}
+	if cfg.PixFmt == "" && source != "" {
+		cfg.PixFmt = encoder.LosslessPixFmt(cfg.Codec, source)
+	}
+
+	fmt.Printf("%s Lossless encoding with %s", style.Film, cfg.Codec)
+	if cfg.PixFmt != "" {
+		fmt.Printf(" (%s)", cfg.PixFmt)
+	}
+	fmt.Println()
+	return nil
+}
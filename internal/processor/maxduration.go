@@ -0,0 +1,33 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// capLiveDuration applies -max-duration to a live input, which would otherwise write until
+// stopped and can fill the disk overnight. A shorter -duration is kept. Live inputs without
+// any limit only get a suggestion, since a stream may be meant to run indefinitely.
+func (p *Processor) capLiveDuration(cfg *config.ProcessingConfig, live bool) {
+	if !live {
+		return
+	}
+
+	if cfg.MaxDuration == "" {
+		if cfg.Duration == "" && p.commandBuilder.IsFileOutput(cfg.OutputPath) {
+			fmt.Printf("%s Live input without -duration: %s grows until stopped; cap it with e.g. -max-duration 2h\n", style.Warn, cfg.OutputPath)
+		}
+		return
+	}
+
+	limit, _ := strconv.ParseFloat(cfg.MaxDuration, 64)
+	if current, err := strconv.ParseFloat(cfg.Duration, 64); err == nil && current <= limit {
+		return
+	}
+	cfg.Duration = cfg.MaxDuration
+	fmt.Printf("%s Live input: stopping after %v (-max-duration)\n", style.Timer, time.Duration(limit*float64(time.Second)))
+}
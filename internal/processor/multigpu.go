@@ -0,0 +1,250 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/report"
+	"video_processing/internal/style"
+	"video_processing/utils"
+)
+
+// segmentResult records how one GPU fared with its segment
+type segmentResult struct {
+	gpu      utils.GPUInfo
+	device   string
+	path     string
+	duration float64
+	elapsed  time.Duration
+	err      error
+}
+
+// processMultiGPU splits the input, or its -start/-duration range, into one time segment per
+// capable GPU, encodes the segments concurrently, and concatenates them with the original
+// audio. If the split encode can't be used it falls back to the regular single-GPU path.
+func (p *Processor) processMultiGPU(cfg *config.ProcessingConfig, gpus []utils.GPUInfo) error {
+	fmt.Printf("\n%s Multi-GPU segmented encoding...\n", style.Puzzle)
+
+	capable := multiGPUCapable(gpus)
+	if len(capable) < 2 {
+		fmt.Printf("%s Multi-GPU mode needs at least two discrete NVIDIA GPUs, found %d; using a single GPU\n", style.Warn, len(capable))
+		return p.processVideo(cfg)
+	}
+	if !strings.HasSuffix(cfg.Codec, "_nvenc") {
+		fmt.Printf("%s Multi-GPU mode requires NVENC, but %s is configured; using a single GPU\n", style.Warn, cfg.Codec)
+		return p.processVideo(cfg)
+	}
+
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		fmt.Printf("%s Could not probe input duration (%v); using a single GPU\n", style.Warn, err)
+		return p.processVideo(cfg)
+	}
+	total, err := strconv.ParseFloat(result.Format.Duration, 64)
+	if err != nil || total <= 0 {
+		fmt.Println(style.Warn, "Input has no known duration (live stream?); using a single GPU")
+		return p.processVideo(cfg)
+	}
+	from, length, err := multiGPURange(cfg, total)
+	if err != nil {
+		fmt.Printf("%s %v; using a single GPU\n", style.Warn, err)
+		return p.processVideo(cfg)
+	}
+
+	// Existing outputs are handled once, before any GPU starts on a segment
+	if cfg.SkipExisting && p.upToDate(cfg) {
+		fmt.Printf("%s %s is up to date, skipping\n", style.Skip, p.finalOutputPath(cfg))
+		p.job.Status = report.StatusSkipped
+		return nil
+	}
+	skip, err := p.applyOverwritePolicy(cfg)
+	if err != nil {
+		return err
+	}
+	if skip {
+		fmt.Printf("%s Output %s already exists, skipping (overwrite policy: %s)\n", style.Skip, p.finalOutputPath(cfg), cfg.OverwritePolicy)
+		p.job.Status = report.StatusSkipped
+		return nil
+	}
+	// A single-GPU fallback must not apply them a second time
+	p.outputChecked = true
+	defer func() { p.outputChecked = false }()
+
+	p.applyQuality(cfg, cfg.Codec)
+
+	dir, err := os.MkdirTemp("", "video_processing-segments-")
+	if err != nil {
+		return fmt.Errorf("could not create segment directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	segmentLength := length / float64(len(capable))
+	results := make([]segmentResult, len(capable))
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i, gpu := range capable {
+		wg.Add(1)
+		go func(i int, gpu utils.GPUInfo) {
+			defer wg.Done()
+			results[i] = p.encodeSegment(cfg, gpu, i, dir, from+float64(i)*segmentLength, segmentLength)
+		}(i, gpu)
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Printf("%s Segment on GPU %s failed: %v\n", style.Error, r.device, r.err)
+			fmt.Println(style.Retry, "Falling back to single-GPU encoding")
+			return p.processVideo(cfg)
+		}
+	}
+
+	if err := p.concatSegments(cfg, dir, results, from, length); err != nil {
+		return err
+	}
+
+	fmt.Println(style.Chart, "Per-GPU throughput:")
+	for _, r := range results {
+		speed := r.duration / r.elapsed.Seconds()
+		fmt.Printf("   GPU %s (%s): %.1fs of video in %v (%.2fx realtime)\n",
+			r.device, r.gpu.Model, r.duration, r.elapsed.Round(time.Millisecond), speed)
+	}
+
+	p.finishOutput(cfg, time.Since(start))
+	return nil
+}
+
+// multiGPURange returns the start and length of the part of an input lasting total seconds
+// that -start and -duration select
+func multiGPURange(cfg *config.ProcessingConfig, total float64) (float64, float64, error) {
+	from := 0.0
+	if cfg.StartTime != "" {
+		var err error
+		if from, err = parseOffset(cfg.StartTime); err != nil {
+			return 0, 0, fmt.Errorf("could not split -start %s across GPUs: %w", cfg.StartTime, err)
+		}
+	}
+	if from >= total {
+		return 0, 0, fmt.Errorf("-start %s is past the end of the input", cfg.StartTime)
+	}
+	length := total - from
+	if cfg.Duration != "" {
+		d, err := config.ParseDuration(cfg.Duration)
+		if err != nil {
+			return 0, 0, fmt.Errorf("could not split -duration %s across GPUs: %w", cfg.Duration, err)
+		}
+		length = min(length, d)
+	}
+	return from, length, nil
+}
+
+// parseOffset parses a time position, which unlike a duration may be zero
+func parseOffset(s string) (float64, error) {
+	if v, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil && v == 0 {
+		return 0, nil
+	}
+	return config.ParseDuration(s)
+}
+
+// multiGPUCapable returns the discrete NVIDIA GPUs, in CUDA device order
+func multiGPUCapable(gpus []utils.GPUInfo) []utils.GPUInfo {
+	var capable []utils.GPUInfo
+	for _, gpu := range gpus {
+		if gpu.Vendor == "nvidia" && !gpu.Integrated {
+			capable = append(capable, gpu)
+		}
+	}
+	return capable
+}
+
+// encodeSegment encodes one video-only time range of the input on the given GPU's CUDA device
+func (p *Processor) encodeSegment(cfg *config.ProcessingConfig, gpu utils.GPUInfo, index int, dir string, start, length float64) segmentResult {
+	segCfg := *cfg
+	segCfg.GPUDevice = gpu.DeviceIndex
+	if segCfg.GPUDevice == "" {
+		segCfg.GPUDevice = strconv.Itoa(index)
+	}
+	segCfg.StartTime = strconv.FormatFloat(start, 'f', 3, 64)
+	segCfg.Duration = strconv.FormatFloat(length, 'f', 3, 64)
+	segCfg.NoAudio = true
+	segCfg.Maps = nil
+	segCfg.SubtitleCodec = ""
+	segCfg.CopyChapters = false
+	segCfg.CopyMetadata = false
+	segCfg.OverwritePolicy = config.OverwriteAlways
+	segCfg.OutputPath = filepath.Join(dir, fmt.Sprintf("segment_%03d.mkv", index))
+
+	args := append([]string{"-hide_banner", "-loglevel", "error", "-nostats"}, p.commandBuilder.BuildFFmpegCommand(&segCfg)...)
+	fmt.Printf("%s GPU %s: segment %d (%.1fs from %.1fs)\n", style.Run, segCfg.GPUDevice, index+1, length, start)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(cfg.FFmpegPath, args...)
+	cmd.Stderr = &stderr
+
+	began := time.Now()
+	err := cmd.Run()
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return segmentResult{
+		gpu:      gpu,
+		device:   segCfg.GPUDevice,
+		path:     segCfg.OutputPath,
+		duration: length,
+		elapsed:  time.Since(began),
+		err:      err,
+	}
+}
+
+// concatSegments joins the encoded segments with the concat demuxer and muxes the original
+// audio back in, trimmed to the from/length range the segments cover
+func (p *Processor) concatSegments(cfg *config.ProcessingConfig, dir string, results []segmentResult, from, length float64) error {
+	var list strings.Builder
+	for _, r := range results {
+		fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(r.path, "'", `'\''`))
+	}
+	listPath := filepath.Join(dir, "segments.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0o644); err != nil {
+		return fmt.Errorf("could not write segment list: %w", err)
+	}
+
+	args := []string{
+		"-hide_banner", "-loglevel", "error",
+		"-f", "concat", "-safe", "0", "-i", listPath,
+		"-ss", strconv.FormatFloat(from, 'f', 3, 64), "-t", strconv.FormatFloat(length, 'f', 3, 64),
+		"-i", cfg.InputPath,
+		"-map", "0:v", "-map", "1:a?",
+		"-c:v", "copy",
+	}
+	args = p.commandBuilder.AddAudioEncoding(args, cfg)
+	if encoder.EndAtShortest(cfg, false) {
+		args = append(args, "-shortest")
+	}
+	// The overwrite policy was applied before the split; -n keeps skip safe against races
+	if cfg.OverwritePolicy == config.OverwriteSkip {
+		args = append(args, "-n")
+	} else {
+		args = append(args, "-y")
+	}
+	args = append(args, p.commandBuilder.OutputContainerArgs(cfg)...)
+	args = append(args, cfg.OutputPath)
+
+	fmt.Println(style.Link, "Concatenating segments...")
+	cmd := exec.Command(cfg.FFmpegPath, args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("concatenating segments failed: %w", err)
+	}
+	return nil
+}
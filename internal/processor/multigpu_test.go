@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+)
+
+func TestConcatSegmentsTempOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in FFmpeg is a shell script")
+	}
+
+	// The stand-in FFmpeg records its arguments, one per line
+	dir := t.TempDir()
+	argsPath := filepath.Join(dir, "args.txt")
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := "#!/bin/sh\nfor arg in \"$@\"; do echo \"$arg\"; done > '" + argsPath + "'\n"
+	if err := os.WriteFile(ffmpeg, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.ProcessingConfig{
+		FFmpegPath: ffmpeg, InputPath: "in.mkv", OutputPath: filepath.Join(dir, "out.mp4"+encoder.TempSuffix),
+	}
+	p := &Processor{commandBuilder: encoder.NewCommandBuilder()}
+	results := []segmentResult{{path: filepath.Join(dir, "segment_000.mkv")}, {path: filepath.Join(dir, "segment_001.mkv")}}
+	if err := p.concatSegments(cfg, dir, results, 30, 60); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(argsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if args[len(args)-1] != cfg.OutputPath {
+		t.Fatalf("last argument = %q, want the output %q", args[len(args)-1], cfg.OutputPath)
+	}
+	if i := slices.Index(args, "in.mkv"); i < 5 || !slices.Equal(args[i-5:i], []string{"-ss", "30.000", "-t", "60.000", "-i"}) {
+		t.Errorf("concat args %q don't trim the source audio to the encoded range", args)
+	}
+	i := slices.Index(args, "-movflags")
+	if i < 2 || args[i-2] != "-f" || args[i-1] != "mp4" || args[i+1] != "+faststart" {
+		t.Errorf("concat args %q lack -f mp4 -movflags +faststart for the .tmp output", args)
+	}
+}
+
+func TestMultiGPURange(t *testing.T) {
+	tests := []struct {
+		start, duration string
+		from, length    float64
+		wantErr         bool
+	}{
+		{"", "", 0, 600, false},
+		{"0", "", 0, 600, false},
+		{"90", "", 90, 510, false},
+		{"00:01:30", "60", 90, 60, false},
+		{"", "10m", 0, 600, false},
+		{"590", "60", 590, 10, false},
+		{"600", "", 0, 0, true},
+		{"soon", "", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		cfg := &config.ProcessingConfig{StartTime: tt.start, Duration: tt.duration}
+		from, length, err := multiGPURange(cfg, 600)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("multiGPURange(-start %q, -duration %q) error = %v, want error %v", tt.start, tt.duration, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (from != tt.from || length != tt.length) {
+			t.Errorf("multiGPURange(-start %q, -duration %q) = %g, %g, want %g, %g", tt.start, tt.duration, from, length, tt.from, tt.length)
+		}
+	}
+}
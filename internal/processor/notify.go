@@ -0,0 +1,113 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"video_processing/internal/encoder"
+	"video_processing/internal/report"
+	"video_processing/internal/style"
+)
+
+// notifyTimeout bounds a -notify command or webhook, so a hung receiver can't keep the
+// program from exiting
+const notifyTimeout = 30 * time.Second
+
+// notification is the JSON -notify sends: the overall status and the report of each job
+type notification struct {
+	Status string       `json:"status"`
+	Jobs   []report.Job `json:"jobs"`
+}
+
+// notify sends the finished jobs to the -notify target: a webhook URL gets them POSTed as
+// JSON, anything else is run as a shell command with the JSON on stdin and the main fields
+// in VP_* environment variables. A failed notification is only warned about.
+func (p *Processor) notify(target string, jobs []report.Job) {
+	n := notification{Status: report.StatusSuccess}
+	for _, job := range jobs {
+		if job.Status == report.StatusFailed {
+			n.Status = report.StatusFailed
+		}
+		// Notifications leave the machine, so stream credentials are hidden; the
+		// error was already redacted by report.NewJobError
+		job.Input = encoder.RedactStreamURL(job.Input)
+		job.Output = encoder.RedactStreamURL(job.Output)
+		n.Jobs = append(n.Jobs, job)
+	}
+	data, err := json.Marshal(n)
+	if err != nil {
+		fmt.Printf("%s Could not encode notification: %v\n", style.Warn, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	if isWebhook(target) {
+		err = postWebhook(ctx, target, data)
+	} else {
+		err = runNotifyCommand(ctx, target, n, data)
+	}
+	if err != nil {
+		fmt.Printf("%s Notification failed: %v\n", style.Warn, err)
+		return
+	}
+	fmt.Printf("%s Notification sent (%s)\n", style.Bell, n.Status)
+}
+
+// isWebhook reports whether the -notify target is a URL to POST to rather than a command
+func isWebhook(target string) bool {
+	lower := strings.ToLower(target)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// postWebhook POSTs the notification JSON to url, failing on a non-2xx response
+func postWebhook(ctx context.Context, url string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// runNotifyCommand runs command through the platform shell with the notification JSON on
+// stdin and VP_STATUS, VP_INPUT, VP_OUTPUT and VP_ERROR set from the first job
+func runNotifyCommand(ctx context.Context, command string, n notification, data []byte) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	cmd.Env = append(os.Environ(), "VP_STATUS="+n.Status)
+	if len(n.Jobs) > 0 {
+		job := n.Jobs[0]
+		cmd.Env = append(cmd.Env, "VP_INPUT="+job.Input, "VP_OUTPUT="+job.Output)
+		if job.Error != nil {
+			cmd.Env = append(cmd.Env, "VP_ERROR="+job.Error.Message)
+		}
+	}
+	return cmd.Run()
+}
@@ -0,0 +1,89 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// encoderPollInterval is how often -wait-for-encoder re-checks a busy NVENC engine
+const encoderPollInterval = 5 * time.Second
+
+// checkEncoderBusy warns before an NVENC encode when other processes have exhausted the GPU's
+// encoder sessions or saturated the engine, which otherwise surfaces mid-encode as a cryptic
+// OpenEncodeSessionEx error. With -wait-for-encoder it polls until the encoder frees up or
+// the wait runs out. The finding is recorded in the job report.
+func (p *Processor) checkEncoderBusy(cfg *config.ProcessingConfig) {
+	if !strings.HasSuffix(cfg.Codec, "_nvenc") {
+		return
+	}
+	index := cfg.GPUDevice
+	if index == "" {
+		index = "0"
+	}
+
+	usage, err := p.gpuDetector.QueryEncoderUsage(index)
+	if err != nil {
+		return
+	}
+	reason := usage.Busy()
+	if reason == "" {
+		return
+	}
+
+	if cfg.EncoderWait > 0 {
+		fmt.Printf("%s NVENC busy: %s; waiting up to %v for it to free up\n", style.Clock, reason, cfg.EncoderWait)
+		deadline := time.Now().Add(cfg.EncoderWait)
+		for reason != "" && time.Now().Before(deadline) {
+			time.Sleep(encoderPollInterval)
+			if usage, err = p.gpuDetector.QueryEncoderUsage(index); err != nil {
+				break
+			}
+			reason = usage.Busy()
+		}
+		if reason == "" {
+			fmt.Println(style.OK, "NVENC encoder available")
+			return
+		}
+	}
+
+	p.job.EncoderBusy = reason
+	fmt.Printf("%s NVENC busy: %s\n", style.Warn, reason)
+	fmt.Println("   The encode will likely fail and fall back to software; stop other encodes or pass -wait-for-encoder 5m")
+}
+
+// prepareNVENCTune checks the NVENC tuning mode against the preset and the FFmpeg version,
+// since builds older than FFmpeg 5 may lack -tune. Other encoders ignore -nvenc-tune.
+func (p *Processor) prepareNVENCTune(cfg *config.ProcessingConfig) error {
+	if !strings.HasSuffix(cfg.Codec, "_nvenc") {
+		if cfg.NVENCTune != "" {
+			fmt.Printf("%s -nvenc-tune only applies to NVENC and will be ignored with %s\n", style.Warn, cfg.Codec)
+		}
+		return nil
+	}
+
+	tune := p.commandBuilder.NVENCTune(cfg)
+	preset := cfg.Preset
+	if cfg.Lossless {
+		preset = "lossless"
+	}
+	if err := encoder.ValidateNVENCTune(tune, preset); err != nil {
+		return err
+	}
+
+	if major, err := p.validator.FFmpegMajorVersion(); err == nil {
+		cfg.FFmpegMajor = major
+	}
+	if cfg.FFmpegMajor > 0 && cfg.FFmpegMajor < 5 {
+		if cfg.NVENCTune != "" {
+			fmt.Printf("%s FFmpeg %d may not support NVENC tuning; -nvenc-tune %s will be ignored\n", style.Warn, cfg.FFmpegMajor, cfg.NVENCTune)
+		}
+		return nil
+	}
+	fmt.Printf("%s NVENC tuning: %s\n", style.Slider, tune)
+	return nil
+}
@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"video_processing/internal/capture"
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// applyOutputDir points the default output at -output-dir, named after the input with the
+// default output's extension. An output from OUTPUT_PATH is left alone, and one typed at the
+// output prompt replaces the derived name afterwards.
+func (p *Processor) applyOutputDir(cfg *config.ProcessingConfig) {
+	if cfg.OutputDir == "" || cfg.OutputFromEnv {
+		return
+	}
+
+	ext := filepath.Ext(cfg.OutputPath)
+	if ext == "" {
+		ext = ".mp4"
+	}
+	cfg.OutputPath = filepath.Join(cfg.OutputDir, inputBaseName(cfg.InputPath)+ext)
+	fmt.Printf("%s Output directory %s: writing %s\n", style.Folder, cfg.OutputDir, filepath.Base(cfg.OutputPath))
+}
+
+// createOutputDir creates -output-dir once the output prompt has kept the name derived in it.
+// An output typed at the prompt goes wherever it names, so the directory isn't created then.
+func (p *Processor) createOutputDir(cfg *config.ProcessingConfig, answer string) error {
+	if cfg.OutputDir == "" || cfg.OutputFromEnv || answer != "" {
+		return nil
+	}
+	if err := os.MkdirAll(cfg.OutputDir, 0o755); err != nil {
+		return fmt.Errorf("could not create -output-dir: %w", err)
+	}
+	return nil
+}
+
+// inputBaseName returns the input's name without directory or extension: the file name for
+// files and URLs, the host for URLs without a path and for RTMP, whose last path element is
+// the stream key, and "capture" for devices
+func inputBaseName(input string) string {
+	if capture.IsDevice(input) {
+		return "capture"
+	}
+
+	name := filepath.Base(input)
+	if u, err := url.Parse(input); err == nil && u.Scheme != "" && u.Host != "" {
+		name = path.Base(u.Path)
+		if name == "/" || name == "." || u.Scheme == "rtmp" || u.Scheme == "rtmps" {
+			return u.Hostname()
+		}
+	}
+	if stem := strings.TrimSuffix(name, filepath.Ext(name)); stem != "" {
+		return stem
+	}
+	return name
+}
@@ -0,0 +1,28 @@
+package processor
+
+import "testing"
+
+func TestInputBaseName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"/videos/holiday.mov", "holiday"},
+		{"holiday.final.mkv", "holiday.final"},
+		{"https://cdn.example.com/vod/lecture.mp4?token=abc", "lecture"},
+		{"https://cdn.example.com/live/index.m3u8", "index"},
+		{"rtsp://cam.local:8554/", "cam.local"},
+		{"rtmp://live.example.com", "live.example.com"},
+		{"rtmp://live.example.com/app/secret-key", "live.example.com"},
+		{"srt://10.0.0.1:9000?mode=caller", "10.0.0.1"},
+		{"device:v4l2:/dev/video0", "capture"},
+		{"/videos/.hidden", ".hidden"},
+		{"/videos/.hidden.mp4", ".hidden"},
+	}
+
+	for _, tt := range tests {
+		if got := inputBaseName(tt.input); got != tt.want {
+			t.Errorf("inputBaseName(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+
+	"video_processing/internal/config"
+	"video_processing/internal/report"
+	"video_processing/internal/style"
+)
+
+// truncatedMargin is how far, as a fraction of the expected duration, a failed encode's
+// output may fall short before it counts as truncated
+const truncatedMargin = 0.01
+
+// handlePartialOutput decides what happens to the files a failed encode left behind.
+// With -keep-on-failure they are kept; otherwise empty, unreadable or clearly short files
+// are removed so they aren't mistaken for finished encodes. expected is the duration the
+// output should have had, or 0 when unknown (e.g. live captures); it isn't applied to
+// segmented outputs.
+func (p *Processor) handlePartialOutput(cfg *config.ProcessingConfig, expected float64) {
+	if !p.commandBuilder.IsFileOutput(cfg.OutputPath) {
+		return
+	}
+
+	// Each segment holds only part of the output, so only empty or unreadable ones are removed
+	if segmenting(cfg) {
+		expected = 0
+	}
+
+	var kept, removed int
+	for _, path := range p.outputFiles(cfg) {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if cfg.KeepOnFailure {
+			fmt.Printf("%s Keeping partial output %s (%.2f MB); it may be incomplete\n", style.Warn, path, float64(info.Size())/(1024*1024))
+			kept++
+			continue
+		}
+
+		reason := p.truncationReason(path, info.Size(), expected)
+		if reason == "" {
+			fmt.Printf("%s Keeping %s from the failed encode; it looks complete\n", style.Info, path)
+			kept++
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			fmt.Printf("%s Could not remove partial output %s: %v\n", style.Warn, path, err)
+			kept++
+			continue
+		}
+		fmt.Printf("%s Removed partial output %s (%s); use -keep-on-failure to keep it\n", style.Trash, path, reason)
+		removed++
+	}
+
+	switch {
+	case kept > 0:
+		p.job.PartialOutput = report.PartialKept
+	case removed > 0:
+		p.job.PartialOutput = report.PartialRemoved
+	}
+}
+
+// truncationReason returns why a failed encode's output is clearly unusable, or "" if it
+// can't be told apart from a complete file
+func (p *Processor) truncationReason(path string, size int64, expected float64) string {
+	if size == 0 {
+		return "empty"
+	}
+	if !p.prober.Available() {
+		return ""
+	}
+
+	result, err := p.prober.Probe(path)
+	if err != nil || result.VideoStream() == nil && result.AudioStream() == nil {
+		return "unreadable"
+	}
+	if got := result.DurationSeconds(); expected > 0 && got < expected*(1-truncatedMargin) {
+		return fmt.Sprintf("%.1fs of %.1fs", got, expected)
+	}
+	return ""
+}
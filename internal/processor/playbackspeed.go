@@ -0,0 +1,26 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// preparePlaybackSpeed re-encodes the audio for -playback-speed, since stream copy can't be
+// retimed, and explains what the retiming does to the audio
+func (p *Processor) preparePlaybackSpeed(cfg *config.ProcessingConfig) {
+	fmt.Printf("%s Playback speed %gx: video retimed with setpts\n", style.Film, cfg.PlaybackSpeed)
+	if cfg.NoAudio {
+		return
+	}
+
+	p.requireAudioEncoder(cfg)
+	filters := encoder.AtempoFilters(cfg.PlaybackSpeed)
+	fmt.Printf("%s Audio retimed with %s (%s); atempo keeps its pitch\n", style.Audio, strings.Join(filters, ","), cfg.AudioCodec)
+	if len(filters) > 1 {
+		fmt.Printf("%s Speeds beyond 0.5x-2x chain several atempo filters, which can make the audio sound choppy or echoey\n", style.Warn)
+	}
+}
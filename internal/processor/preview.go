@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// previewExtensions are the output containers a preview keeps; other outputs (streams, HLS,
+// DASH) are previewed as Matroska, which holds any codec they might carry
+var previewExtensions = []string{".mp4", ".mkv", ".mov", ".webm"}
+
+// confirmWithPreview encodes the first -preview of the input with the current settings, plays
+// it and asks whether to run the full encode. Entering a quality value instead re-encodes the
+// preview at that quality. It reports whether the full encode should go ahead.
+func (p *Processor) confirmWithPreview(cfg *config.ProcessingConfig) (bool, error) {
+	if !p.interactive {
+		fmt.Println(style.Warn, "-preview needs a terminal to confirm the preview; skipping it")
+		return true, nil
+	}
+	if result, err := p.prober.Probe(cfg.InputPath); err == nil && result.DurationSeconds() <= 0 {
+		fmt.Println(style.Warn, "-preview needs an input with a known duration (live stream?); skipping the preview")
+		return true, nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(cfg.OutputPath, encoder.TempSuffix)))
+	if !p.commandBuilder.IsFileOutput(cfg.OutputPath) || !slices.Contains(previewExtensions, ext) {
+		ext = ".mkv"
+	}
+	tmp, err := os.CreateTemp("", "preview-*"+ext)
+	if err != nil {
+		return false, fmt.Errorf("creating the preview file: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	seconds := cfg.Preview.Seconds()
+	for {
+		fmt.Printf("\n%s Encoding a %.0fs preview at quality %d...\n", style.Film, seconds, cfg.Quality)
+		if err := p.encodePreview(cfg, tmp.Name(), seconds); err != nil {
+			// The full encode has recoveries and fallbacks the preview doesn't
+			fmt.Printf("%s Preview encode failed, continuing with the full encode: %v\n", style.Warn, err)
+			return true, nil
+		}
+		if info, err := os.Stat(tmp.Name()); err == nil {
+			fmt.Printf("%s Preview size: %.2f MB (~%.1f Mbps)\n", style.Stats, float64(info.Size())/(1024*1024), float64(info.Size())*8/seconds/1e6)
+		}
+		if err := p.player.PlayPreview(tmp.Name()); err != nil {
+			fmt.Printf("%s Could not play the preview: %v\n", style.Warn, err)
+		}
+
+		answer, err := p.promptPreviewAnswer(cfg)
+		if err != nil {
+			return false, err
+		}
+		switch answer {
+		case "", "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		cfg.Quality, _ = parseQuality(answer, cfg.Codec)
+	}
+}
+
+// promptPreviewAnswer asks whether to go ahead with the full encode. Quality values are only
+// offered when the encode is quality-targeted.
+func (p *Processor) promptPreviewAnswer(cfg *config.ProcessingConfig) (string, error) {
+	adjustable := !cfg.CopyVideo && !cfg.Lossless
+	question := fmt.Sprintf("%s Proceed with the full encode? (Y/n): ", style.Play)
+	if adjustable {
+		question = fmt.Sprintf("%s Proceed with the full encode? (Y/n, or a new quality %s to preview again): ",
+			style.Play, encoder.QualityScaleFor(cfg.Codec).Describe())
+	}
+
+	answer, err := p.promptValue(question, func(s string) error {
+		switch strings.ToLower(s) {
+		case "", "y", "yes", "n", "no":
+			return nil
+		}
+		if !adjustable {
+			return fmt.Errorf("answer y or n")
+		}
+		_, err := parseQuality(s, cfg.Codec)
+		return err
+	})
+	return strings.ToLower(answer), err
+}
+
+// encodePreview encodes the start of the selected range to path with cfg's settings
+func (p *Processor) encodePreview(cfg *config.ProcessingConfig, path string, seconds float64) error {
+	previewCfg := *cfg
+	previewCfg.OutputPath = path
+	previewCfg.OverwritePolicy = config.OverwriteAlways
+	previewCfg.SegmentTime = ""
+	previewCfg.SegmentSize = 0
+	previewCfg.LimitRate = ""
+	if d, err := strconv.ParseFloat(cfg.Duration, 64); err != nil || d > seconds {
+		previewCfg.Duration = strconv.FormatFloat(seconds, 'f', -1, 64)
+	}
+
+	args := p.commandBuilder.BuildFFmpegCommand(&previewCfg)
+	stderr, err := p.runFFmpeg(context.Background(), &previewCfg, args)
+	if err != nil {
+		return encoder.NewEncodeError(err, stderr)
+	}
+	return nil
+}
@@ -2,97 +2,202 @@ package processor
 
 import (
 	"bufio"
-	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"video_processing/internal/capture"
 	"video_processing/internal/config"
 	"video_processing/internal/encoder"
 	"video_processing/internal/player"
+	"video_processing/internal/probe"
+	"video_processing/internal/report"
+	"video_processing/internal/style"
 	"video_processing/internal/validator"
+	"video_processing/internal/version"
 	"video_processing/utils"
 )
 
 // Processor is the main video processor
 type Processor struct {
+	cfg             *config.ProcessingConfig
 	gpuDetector     *utils.GPUDetector
 	encoder         *encoder.Encoder
 	commandBuilder  *encoder.CommandBuilder
 	fallbackManager *encoder.FallbackManager
 	validator       *validator.Validator
 	player          *player.Player
+	progressOut     io.Writer
+	job             report.Job // outcome of the current encode, for -report
+	finalOutput     string     // file a temporary output is moved to once the encode succeeds
+	replacesInput   bool       // finalOutput is the input, replaced by an -in-place encode
+	prober          *probe.Prober
+	gpu             utils.GPUInfo // detected GPU the hardware encode was configured for
 	reader          *bufio.Reader
+	interactive     bool    // stdin is a terminal, so questions can be asked
+	streamBitrate   float64 // average bitrate FFmpeg last reported for a streaming output
+	deviceLog       []byte  // verbose lines of the last hardware encode's log, for reconcileDevice
+	outputChecked   bool    // -skip-existing and the overwrite policy were already applied to the output
 }
 
-// New creates a new processor instance
-func New() *Processor {
+// New creates a new processor instance using the given base configuration
+func New(cfg *config.ProcessingConfig) *Processor {
+	detector := utils.NewGPUDetector()
+	if cfg.GPUCacheTTL > 0 {
+		detector.EnableCache(utils.DefaultCachePath(), cfg.GPUCacheTTL)
+	}
+
+	prober := probe.New(cfg.FFprobePath())
+	videoPlayer := player.New()
+	if cfg.ResumePlayback {
+		videoPlayer.EnableResume(player.NewResumeStore(player.DefaultResumePath()), prober)
+	}
+	videoPlayer.SetDetached(cfg.PlayDetached)
+
 	return &Processor{
-		gpuDetector:     utils.NewGPUDetector(),
+		cfg:             cfg,
+		gpuDetector:     detector,
 		encoder:         encoder.New(),
 		commandBuilder:  encoder.NewCommandBuilder(),
-		fallbackManager: encoder.NewFallbackManager(),
-		validator:       validator.New(),
-		player:          player.New(),
+		fallbackManager: encoder.NewFallbackManager(cfg.FFmpegPath),
+		validator:       validator.New(cfg.FFmpegPath),
+		player:          videoPlayer,
+		prober:          prober,
 		reader:          bufio.NewReader(os.Stdin),
+		interactive:     stdinIsTerminal(),
 	}
 }
 
 // Run executes the complete video processing workflow
 func (p *Processor) Run() error {
-	fmt.Println("🎬 FFmpeg GPU-Accelerated Video Processor")
+	fmt.Println(style.Video, "FFmpeg GPU-Accelerated Video Processor")
 	fmt.Println(strings.Repeat("=", 50))
 
 	// Step 1: Detect GPUs
 	gpus, err := p.detectAndDisplayGPUs()
 	if err != nil {
-		return fmt.Errorf("GPU detection failed: %w", err)
+		return p.failEarly(p.cfg, fmt.Errorf("GPU detection failed: %w", err))
 	}
 
 	// Step 2: Configure processing based on detected hardware
 	config, err := p.configureProcessing(gpus)
 	if err != nil {
-		return fmt.Errorf("configuration failed: %w", err)
+		return p.failEarly(p.cfg, fmt.Errorf("configuration failed: %w", err))
 	}
 
 	// Step 3: Validate setup
 	if err := p.validator.ValidateSetup(config); err != nil {
-		fmt.Printf("⚠️  Setup validation warnings: %v\n", err)
+		// Nothing can encode without FFmpeg or the selected hwaccel; other findings are warnings
+		if errors.Is(err, validator.ErrFFmpegNotFound) || errors.Is(err, validator.ErrHWAccelUnavailable) {
+			return p.failEarly(config, fmt.Errorf("setup validation failed: %w", err))
+		}
+		fmt.Printf("%s Setup validation warnings: %v\n", style.Warn, err)
 	}
 
 	// Step 4: Get user input
 	if err := p.getUserInput(config); err != nil {
-		return fmt.Errorf("input failed: %w", err)
+		return p.failEarly(config, fmt.Errorf("input failed: %w", err))
+	}
+
+	if len(config.Renditions) > 0 {
+		return p.processRenditions(config)
+	}
+
+	// Guard against FFmpeg truncating the input it is about to read
+	finalOutput, err := p.guardInPlace(config)
+	if err != nil {
+		return p.failEarly(config, err)
+	}
+	if finalOutput == "" {
+		finalOutput = p.useTempOutput(config)
 	}
 
 	// Step 5: Process video
-	if err := p.processVideo(config); err != nil {
+	p.job = report.Job{Input: config.InputPath}
+	start := time.Now()
+	if config.MultiGPU {
+		err = p.processMultiGPU(config, gpus)
+	} else {
+		err = p.processVideo(config)
+	}
+	if finalOutput != "" {
+		err = p.finishTempOutput(config, finalOutput, err)
+	}
+	if config.ReportPath != "" || config.Notify != "" {
+		p.reportJobs(config, []report.Job{p.completeJob(config, time.Since(start), err)})
+	}
+	if err != nil {
 		return fmt.Errorf("video processing failed: %w", err)
 	}
 
-	// Step 6: Optional playback
-	return p.player.OfferPlayback(config.OutputPath)
+	// Step 6: Optional playback (of the first file when segmenting)
+	if p.job.Status == report.StatusSkipped || !p.interactive {
+		return nil
+	}
+	if files := p.outputFiles(config); len(files) > 0 {
+		return p.player.OfferPlayback(files[0])
+	}
+	return nil
+}
+
+// SetProgressWriter enables JSON-lines progress output to w for FFmpeg encodes
+func (p *Processor) SetProgressWriter(w io.Writer) {
+	p.progressOut = w
+}
+
+// PrintDetectionJSON writes the detected GPUs, including their encoder capabilities, as JSON to stdout
+func (p *Processor) PrintDetectionJSON() error {
+	gpus, err := p.gpuDetector.DetectGPUs()
+	if err != nil {
+		return fmt.Errorf("GPU detection failed: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(gpus)
+}
+
+// PrintVersion prints the build information and the detected FFmpeg version
+func (p *Processor) PrintVersion(asJSON bool) error {
+	info := version.Get()
+	if v, err := p.validator.FFmpegVersion(); err == nil {
+		info.FFmpegVersion = v
+	}
+	if v, err := p.prober.Version(); err == nil {
+		info.FFprobeVersion = v
+	}
+	return info.Print(os.Stdout, asJSON)
 }
 
 func (p *Processor) detectAndDisplayGPUs() ([]utils.GPUInfo, error) {
-	fmt.Println("🔍 Detecting GPU hardware...")
+	fmt.Println(style.Search, "Detecting GPU hardware...")
 
 	gpus, err := p.gpuDetector.DetectGPUs()
 	if err != nil {
 		return nil, err
 	}
 
+	// Let a failed hardware encode retry on another vendor's GPU before software
+	p.fallbackManager.SetGPUs(gpus)
+
 	if len(gpus) == 0 {
-		fmt.Println("❌ No GPUs detected")
+		fmt.Println(style.Error, "No GPUs detected")
 		return gpus, nil
 	}
 
-	fmt.Printf("✅ Found %d GPU(s):\n", len(gpus))
+	fmt.Printf("%s Found %d GPU(s):\n", style.OK, len(gpus))
 	for i, gpu := range gpus {
-		fmt.Printf("  %d. %s %s", i+1, strings.Title(gpu.Vendor), gpu.Model)
+		fmt.Printf("  %d. %s %s", i+1, utils.VendorName(gpu.Vendor), gpu.Model)
+		if gpu.Integrated {
+			fmt.Print(" [integrated]")
+		}
 		if gpu.Memory != "" {
 			fmt.Printf(" (%s)", gpu.Memory)
 		}
@@ -101,8 +206,16 @@ func (p *Processor) detectAndDisplayGPUs() ([]utils.GPUInfo, error) {
 		}
 		fmt.Println()
 
+		if caps := gpu.Capabilities; caps != nil {
+			fmt.Printf("     Encode limits: %dx%d", caps.MaxWidth, caps.MaxHeight)
+			if caps.MaxSessions > 0 {
+				fmt.Printf(", %d concurrent sessions", caps.MaxSessions)
+			}
+			fmt.Printf(" (%s)\n", caps.Source)
+		}
+
 		if gpu.Error != "" {
-			fmt.Printf("     ⚠️  Warning: %s\n", gpu.Error)
+			fmt.Printf("     %s Warning: %s\n", style.Warn, gpu.Error)
 		}
 	}
 
@@ -111,109 +224,528 @@ func (p *Processor) detectAndDisplayGPUs() ([]utils.GPUInfo, error) {
 }
 
 func (p *Processor) configureProcessing(gpus []utils.GPUInfo) (*config.ProcessingConfig, error) {
-	cfg := config.NewDefault()
+	cfg := p.cfg
+	p.resolveSoftwarePreset(cfg)
 
-	if len(gpus) == 0 || gpus[0].Vendor == "unknown" {
-		fmt.Println("🔄 Using software encoding (no GPU acceleration)")
+	// Prefer a discrete GPU over an integrated one when both are present
+	primaryGPU, ok := utils.PreferredGPU(gpus)
+	if cfg.GPUID != "" {
+		primaryGPU, ok = p.selectGPUByID(cfg, gpus, primaryGPU, ok)
+	}
+	if cfg.CodecOverride != "" {
+		if err := p.configureCodecOverride(cfg, gpus); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+	if cfg.HWAccel == "none" || ((!ok || primaryGPU.Vendor == "unknown") && cfg.HWAccel == "") {
+		fmt.Println(style.Switch, "Using software encoding (no GPU acceleration)")
 		cfg.SetSoftwareEncoding()
 		return cfg, nil
 	}
 
-	// Use the primary (first) GPU
-	primaryGPU := gpus[0]
-	acceleration, codec, preset := p.encoder.ConfigureForGPU(primaryGPU)
+	var acceleration, codec, preset string
+	if cfg.HWAccel != "" {
+		acceleration, codec, preset = p.encoder.ConfigureWithAcceleration(primaryGPU, cfg.HWAccel)
+	} else {
+		acceleration, codec, preset = p.encoder.ConfigureForGPU(primaryGPU)
+		if primaryGPU.Vendor == "amd" && acceleration == "vaapi" && cfg.AMDEncoder != config.AMDEncoderVAAPI {
+			amf, err := p.useAMF(cfg)
+			if err != nil {
+				return nil, err
+			}
+			if amf {
+				acceleration, codec, preset = p.encoder.ConfigureWithAcceleration(primaryGPU, "amf")
+			}
+		}
+	}
 	cfg.SetHardwareEncoding(acceleration, codec, preset)
+	p.gpu = primaryGPU
+	if cfg.RenderNode == "" {
+		cfg.RenderNode = utils.RenderNode(primaryGPU.PCIAddress)
+	}
+	// The default quality is an x264 CRF; VideoToolbox, for one, reads the number the other way round
+	cfg.Quality = encoder.ConvertQuality(cfg.Quality, "libx264", cfg.Codec)
 
-	fmt.Printf("🚀 Hardware acceleration: %s (%s)\n", cfg.Acceleration, cfg.Codec)
-	fmt.Printf("📊 Quality setting: %d, Preset: %s\n", cfg.Quality, cfg.Preset)
+	fmt.Printf("%s Selected GPU: %s %s\n", style.Target, utils.VendorName(primaryGPU.Vendor), primaryGPU.Model)
+	fmt.Printf("%s Hardware acceleration: %s (%s)\n", style.Rocket, cfg.Acceleration, cfg.Codec)
+	fmt.Printf("%s Quality setting: %d, Preset: %s\n", style.Stats, cfg.Quality, cfg.Preset)
 	fmt.Println(strings.Repeat("-", 50))
 
 	return cfg, nil
 }
 
+// selectGPUByID picks the GPU named by -gpu-id and points the hardware path at its device.
+// An ID that matches nothing is used as a CUDA device index if numeric, otherwise ignored.
+func (p *Processor) selectGPUByID(cfg *config.ProcessingConfig, gpus []utils.GPUInfo, fallback utils.GPUInfo, ok bool) (utils.GPUInfo, bool) {
+	gpu, found := utils.FindGPU(gpus, cfg.GPUID)
+	if !found {
+		if _, err := strconv.Atoi(cfg.GPUID); err == nil {
+			fmt.Printf("%s No GPU with ID %s; using it as device index\n", style.Target, cfg.GPUID)
+			cfg.GPUDevice = cfg.GPUID
+		} else {
+			fmt.Printf("%s No detected GPU matches -gpu-id %s; using the default GPU\n", style.Warn, cfg.GPUID)
+		}
+		return fallback, ok
+	}
+
+	if gpu.DeviceIndex != "" {
+		cfg.GPUDevice = gpu.DeviceIndex
+	}
+	// VAAPI and QSV open the GPU's render node rather than a device index
+	cfg.RenderNode = utils.RenderNode(gpu.PCIAddress)
+	fmt.Printf("%s -gpu-id %s matched %s %s (PCI %s)\n", style.Target, cfg.GPUID, utils.VendorName(gpu.Vendor), gpu.Model, gpu.PCIAddress)
+	return gpu, true
+}
+
 func (p *Processor) getUserInput(cfg *config.ProcessingConfig) error {
 	// Get input file/URL
-	fmt.Print("📁 Enter input video file path or stream URL: ")
-	input, err := p.reader.ReadString('\n')
+	input, err := p.promptInputPath(cfg, fmt.Sprintf("%s Enter input video file path, stream URL or capture device (device:v4l2:/dev/video0): ", style.Folder), func(s string) error {
+		if s == "" {
+			return ErrNoInput
+		}
+		return p.validator.ValidateInput(s)
+	})
 	if err != nil {
 		return err
 	}
-
-	cfg.InputPath = strings.TrimSpace(input)
-	if cfg.InputPath == "" {
-		return fmt.Errorf("no input provided")
+	if err := p.useInput(cfg, input); err != nil {
+		return err
 	}
 
 	// Optional: Get output path
-	fmt.Printf("💾 Output file (default: %s): ", cfg.OutputPath)
-	output, _ := p.reader.ReadString('\n')
-	output = strings.TrimSpace(output)
+	output, err := p.promptValue(fmt.Sprintf("%s Output file (default: %s): ", style.Save, cfg.OutputPath), p.validateOutputPath)
+	if err != nil {
+		return err
+	}
 	if output != "" {
 		cfg.OutputPath = output
 	}
+	if err := p.createOutputDir(cfg, output); err != nil {
+		return err
+	}
 
-	// Optional: Quality setting
-	fmt.Printf("🎚️  Quality (CRF/QP, default: %d, lower=better): ", cfg.Quality)
-	qualityStr, _ := p.reader.ReadString('\n')
-	qualityStr = strings.TrimSpace(qualityStr)
-	if qualityStr != "" {
-		var quality int
-		if _, err := fmt.Sscanf(qualityStr, "%d", &quality); err == nil && quality >= 0 && quality <= 51 {
-			cfg.Quality = quality
+	// Optional: Quality setting, unless -quality-level already chose it
+	if cfg.QualityLevel != nil {
+		return nil
+	}
+	scale := encoder.QualityScaleFor(cfg.Codec)
+	qualityStr, err := p.promptValue(fmt.Sprintf("%s Quality (%s, default: %d): ", style.Slider, scale.Describe(), cfg.Quality), func(s string) error {
+		if s == "" {
+			return nil
 		}
+		_, err := parseQuality(s, cfg.Codec)
+		return err
+	})
+	if err != nil {
+		fmt.Printf("%s %v; keeping default quality %d\n", style.Warn, err, cfg.Quality)
+		return nil
+	}
+	if qualityStr != "" {
+		cfg.Quality, _ = parseQuality(qualityStr, cfg.Codec)
 	}
 
 	return nil
 }
 
 func (p *Processor) processVideo(cfg *config.ProcessingConfig) error {
-	fmt.Println("\n🎬 Starting video processing...")
+	fmt.Printf("\n%s Starting video processing...\n", style.Video)
+	qualityCodec := cfg.Codec // the encoder whose scale cfg.Quality is on
+
+	if cfg.SkipExisting && !p.outputChecked && p.upToDate(cfg) {
+		fmt.Printf("%s %s is up to date, skipping\n", style.Skip, p.finalOutputPath(cfg))
+		p.job.Status = report.StatusSkipped
+		return nil
+	}
+
+	if !cfg.CopyVideo && cfg.CodecFamily != config.CodecFamilyH264 {
+		p.resolveCodecFamily(cfg)
+	}
+
+	if cfg.CopyVideo {
+		if err := p.validateStreamCopy(cfg); err != nil {
+			return err
+		}
+	} else if p.commandBuilder.OutputFormat(cfg.OutputPath) == "webm" && !encoder.IsWebMCodec(cfg.Codec) {
+		if cfg.CodecOverride != "" {
+			return fmt.Errorf("WebM output needs VP9 or AV1; -codec %s can't be written to it", cfg.CodecOverride)
+		}
+		// WebM only holds VP8/VP9/AV1, which the detected hardware encoders don't produce
+		fmt.Printf("%s WebM output needs VP9; switching from %s to libvpx-vp9 (speed: %s)\n", style.Switch, cfg.Codec, cfg.Speed)
+		cfg.SetSoftwareEncoding()
+		cfg.Codec = "libvpx-vp9"
+		if cfg.AudioCodec == "" && !cfg.NoAudio {
+			cfg.AudioCodec = "libopus"
+		}
+	}
+
+	if cfg.Lossless {
+		if err := p.prepareLossless(cfg); err != nil {
+			return err
+		}
+	} else if !cfg.CopyVideo {
+		p.applyQuality(cfg, qualityCodec)
+	}
+
+	if !cfg.CopyVideo {
+		if err := p.prepareNVENCTune(cfg); err != nil {
+			return err
+		}
+	}
+
+	p.capLiveDuration(cfg, p.commandBuilder.IsLiveInput(cfg.InputPath))
+
+	if capture.IsDevice(cfg.InputPath) {
+		if err := p.prepareCapture(cfg); err != nil {
+			return err
+		}
+	} else if cfg.StartTime != "" {
+		if err := p.checkStartPosition(cfg); err != nil {
+			return err
+		}
+	}
+
+	if cfg.ColorRange != "" && !cfg.CopyVideo {
+		p.resolveColorRange(cfg)
+	}
+
+	if cfg.Crop != nil {
+		if err := p.prepareRegion(cfg); err != nil {
+			return err
+		}
+	}
+
+	if cfg.FilterComplex != "" {
+		p.warnFilterComplex(cfg)
+	}
+
+	if cfg.FrameRate != "" || cfg.CFR {
+		p.prepareFrameRate(cfg)
+	}
+
+	if p.commandBuilder.OutputFormat(cfg.OutputPath) == "hls" {
+		if !cfg.CopyVideo {
+			p.alignHLSKeyframes(cfg)
+		}
+		if len(cfg.ABRLadder) > 0 && !cfg.NoAudio {
+			p.prepareABRAudio(cfg)
+		}
+	} else {
+		if len(cfg.ABRLadder) > 0 {
+			fmt.Println(style.Warn, "-abr-ladder only applies to HLS (.m3u8) output; encoding a single rendition")
+		}
+		if cfg.HLSSegmentType != "" || cfg.HLSSingleFile || cfg.HLSVOD {
+			fmt.Println(style.Warn, "-hls-segment-type, -hls-single-file and -hls-vod only apply to HLS (.m3u8) output and will be ignored")
+		}
+	}
+	if cfg.ClosedGOP {
+		if err := p.prepareClosedGOP(cfg); err != nil {
+			return err
+		}
+	}
+
+	if cfg.LimitRate != "" {
+		if err := p.prepareRateLimit(cfg); err != nil {
+			return err
+		}
+	}
+
+	if cfg.SoftwareDecode && isHardwareEncode(cfg) {
+		fmt.Printf("%s -sw-decode: decoding on the CPU and uploading frames to %s\n", style.Info, cfg.Codec)
+	}
+
+	if cfg.HQTuning && !cfg.CopyVideo && !cfg.Lossless {
+		if slices.Contains(encoder.HQTuningCodecs, cfg.Codec) {
+			fmt.Printf("%s -hq: using %s's slower, higher-quality settings\n", style.Slider, cfg.Codec)
+		} else {
+			fmt.Printf("%s -hq has no extra settings for %s and will be ignored\n", style.Warn, cfg.Codec)
+		}
+	}
+
+	if cfg.MaxRate != "" && !slices.Contains([]string{"libx264", "libx265", "h264_nvenc", "hevc_nvenc", "h264_qsv", "hevc_qsv"}, cfg.Codec) {
+		fmt.Printf("%s -maxrate is not supported with %s and will be ignored\n", style.Warn, cfg.Codec)
+	}
+
+	if cfg.AudioLang != "" || cfg.SubLang != "" {
+		p.resolveLanguageSelection(cfg)
+	}
+	if len(cfg.StreamTitles) > 0 || len(cfg.DefaultTracks) > 0 {
+		if err := p.resolveTrackTags(cfg); err != nil {
+			return err
+		}
+	}
+	if cfg.CopyData {
+		p.selectDataStreams(cfg)
+	}
+	if cfg.AudioFile != "" {
+		if err := p.prepareAudioFile(cfg); err != nil {
+			return err
+		}
+	}
+	if cfg.Watermark != "" {
+		if err := p.prepareWatermark(cfg); err != nil {
+			return err
+		}
+	}
+	if cfg.SilentAudio {
+		p.prepareSilentAudio(cfg)
+	}
+	if (cfg.AudioChannels > 0 || cfg.AudioPan != "") && !cfg.NoAudio {
+		p.prepareDownmix(cfg)
+	}
+	if cfg.PlaybackSpeed != 1 {
+		p.preparePlaybackSpeed(cfg)
+	}
+
+	p.prepareRTSP(cfg)
+	if cfg.CheckStream {
+		if err := p.checkStream(cfg); err != nil {
+			return err
+		}
+	}
+
+	if segmenting(cfg) {
+		if err := p.prepareSegments(cfg); err != nil {
+			return err
+		}
+	}
+
+	if cfg.CopyChapters {
+		if err := p.validator.ValidateChapters(p.commandBuilder.OutputFormat(cfg.OutputPath)); err != nil {
+			fmt.Printf("%s %v\n", style.Warn, err)
+		}
+	}
+
+	if cfg.Preview > 0 {
+		proceed, err := p.confirmWithPreview(cfg)
+		if err != nil {
+			return err
+		}
+		if !proceed {
+			fmt.Println(style.Skip, "Full encode cancelled after the preview")
+			p.job.Status = report.StatusSkipped
+			return nil
+		}
+	}
+
+	if !p.outputChecked {
+		skip, err := p.applyOverwritePolicy(cfg)
+		if err != nil {
+			return err
+		}
+		if skip {
+			fmt.Printf("%s Output %s already exists, skipping (overwrite policy: %s)\n", style.Skip, p.finalOutputPath(cfg), cfg.OverwritePolicy)
+			p.job.Status = report.StatusSkipped
+			return nil
+		}
+	}
+	if segmenting(cfg) {
+		p.clearSegments(cfg)
+	}
+
+	p.checkEncoderBusy(cfg)
 
 	args := p.commandBuilder.BuildFFmpegCommand(cfg)
-	fmt.Printf("Command: ffmpeg %s\n", strings.Join(args, " "))
+	fmt.Printf("Command: %s %s\n", cfg.FFmpegPath, encoder.FormatArgs(args))
 	fmt.Println(strings.Repeat("-", 50))
+	if cfg.SaveCommand {
+		p.saveCommand(cfg, args)
+	}
 
 	// Use context with timeout (optional, can use cancel context too)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Setup command
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
-
-	var stderr bytes.Buffer
-	cmd.Stderr = os.Stderr // FFmpeg logs (progress, errors)
-	cmd.Stdout = os.Stdout // Optional: capture output if needed
-
 	start := time.Now()
-	err := cmd.Run()
+	stderr, err := p.runFFmpeg(ctx, cfg, args)
 	duration := time.Since(start)
 
 	if err != nil {
-		fmt.Printf("❌ FFmpeg exited with error: %v\n", err)
+		fmt.Printf("%s FFmpeg exited with error: %v\n", style.Error, encoder.NewEncodeError(err, stderr))
 
-		// Log detailed FFmpeg error output
-		if stderr.Len() > 0 {
-			fmt.Println("🔍 FFmpeg stderr output:")
-			fmt.Println(stderr.String())
+		// A stall usually means a dead input, which the retries and software fallbacks
+		// would hang on too. The watchdog stops FFmpeg through its own context, so ctx
+		// doesn't show it.
+		if errors.Is(err, ErrEncodeStalled) {
+			return p.stalledEncode(cfg, err, stderr)
 		}
 
 		// Check if context was cancelled (e.g., timeout, manual cancel)
 		if ctx.Err() != nil {
-			fmt.Printf("⚠️  Command was cancelled: %v\n", ctx.Err())
+			fmt.Printf("%s Command was cancelled: %v\n", style.Warn, ctx.Err())
+		} else {
+			// Retry transient encoder start-up failures, then hardware-preserving recoveries,
+			// before dropping to software
+			stderr, err = p.retryTransientInit(ctx, cfg, args, stderr, err)
+			if err != nil {
+				stderr, err = p.tryRecoveries(ctx, cfg, stderr, err)
+			}
 		}
+		duration = time.Since(start)
+	}
+
+	if err == nil {
+		p.reconcileDevice(cfg, stderr)
+	}
+	if errors.Is(err, ErrEncodeStalled) {
+		// A retry stalled
+		return p.stalledEncode(cfg, err, stderr)
+	}
+
+	if err != nil {
+		encodeErr := encoder.NewEncodeError(err, stderr)
 
 		// Try fallbacks
-		if fallbackErr := p.fallbackManager.TryFallbacks(cfg); fallbackErr != nil {
-			return fmt.Errorf("all encoding methods failed: %w", fallbackErr)
+		fallback, fallbackErr := p.fallbackManager.TryFallbacks(cfg)
+		if fallbackErr != nil {
+			p.handlePartialOutput(cfg, p.expectedDuration(cfg))
+			return fmt.Errorf("all encoding methods failed: %w (primary: %w)", fallbackErr, encodeErr)
+		}
+		p.job.Fallback = fallback.Description
+		p.job.Codec = fallback.Codec
+	}
+
+	p.finishOutput(cfg, duration)
+	return nil
+}
+
+// stalledEncode handles an encode the watchdog stopped, keeping or removing its partial output
+func (p *Processor) stalledEncode(cfg *config.ProcessingConfig, err error, stderr []byte) error {
+	p.handlePartialOutput(cfg, p.expectedDuration(cfg))
+	return encoder.NewEncodeError(err, stderr)
+}
+
+// finishOutput reports a completed encode and applies the post-encode steps to its output:
+// -preserve-timestamps, and the size and bitrate checks
+func (p *Processor) finishOutput(cfg *config.ProcessingConfig, duration time.Duration) {
+	fmt.Printf("%s Video processing completed in %v\n", style.OK, duration.Round(time.Second))
+	if cfg.SegmentTime != "" {
+		fmt.Printf("%s Output saved to %d segments: %s\n", style.Folder, len(p.outputFiles(cfg)), encoder.SegmentPattern(cfg.OutputPath))
+	} else {
+		fmt.Printf("%s Output saved to: %s\n", style.Folder, cfg.OutputPath)
+	}
+
+	if cfg.PreserveTimestamps {
+		p.preserveTimestamps(cfg)
+	}
+
+	if size, ok := p.outputSize(cfg); ok {
+		fmt.Printf("%s Output file size: %.2f MB\n", style.Stats, float64(size)/(1024*1024))
+	}
+	p.checkOutputBitrate(cfg)
+}
+
+// applyOverwritePolicy handles an existing output file before encoding. It reports whether
+// the encode should be skipped.
+func (p *Processor) applyOverwritePolicy(cfg *config.ProcessingConfig) (bool, error) {
+	if !p.commandBuilder.IsFileOutput(cfg.OutputPath) {
+		return false, nil
+	}
+
+	// An -in-place encode replaces the input by design, so there is nothing to check
+	if p.replacesInput {
+		return false, nil
+	}
+	target := *cfg
+	target.OutputPath = p.finalOutputPath(cfg)
+
+	var existing []string
+	for _, path := range p.outputFiles(&target) {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	if len(existing) == 0 {
+		return false, nil
+	}
+
+	switch cfg.OverwritePolicy {
+	case config.OverwriteSkip:
+		return true, nil
+	case config.OverwriteError:
+		return false, fmt.Errorf("%w: %s", ErrOutputExists, existing[0])
+	case config.OverwriteBackup:
+		for _, path := range existing {
+			backup := path + ".bak"
+			if err := os.Rename(path, backup); err != nil {
+				return false, fmt.Errorf("could not back up existing output: %w", err)
+			}
+			fmt.Printf("%s Existing output moved to %s\n", style.Archive, backup)
 		}
 	}
+	return false, nil
+}
 
-	fmt.Printf("✅ Video processing completed in %v\n", duration.Round(time.Second))
-	fmt.Printf("📁 Output saved to: %s\n", cfg.OutputPath)
+// upToDate reports whether the output exists and no output file is older than the input;
+// equal times count as up to date, since -preserve-timestamps copies the input's. Inputs
+// without a modification time (streams, devices) are never up to date.
+func (p *Processor) upToDate(cfg *config.ProcessingConfig) bool {
+	if !p.commandBuilder.IsFileOutput(cfg.OutputPath) {
+		return false
+	}
+	input, err := os.Stat(cfg.InputPath)
+	if err != nil {
+		return false
+	}
 
-	if info, err := os.Stat(cfg.OutputPath); err == nil {
-		fmt.Printf("📊 Output file size: %.2f MB\n", float64(info.Size())/(1024*1024))
+	target := *cfg
+	target.OutputPath = p.finalOutputPath(cfg)
+	files := p.outputFiles(&target)
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Before(input.ModTime()) {
+			return false
+		}
 	}
+	return len(files) > 0
+}
+
+// validateStreamCopy probes the input and checks its video codec fits the output container
+func (p *Processor) validateStreamCopy(cfg *config.ProcessingConfig) error {
+	fmt.Println(style.List, "Video stream copy enabled; only audio will be re-encoded")
 
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		fmt.Printf("%s Could not probe input, skipping codec compatibility check: %v\n", style.Warn, err)
+		return nil
+	}
+
+	video := result.VideoStream()
+	if video == nil {
+		fmt.Println(style.Warn, "Input has no video stream to copy")
+		return nil
+	}
+
+	format := p.commandBuilder.OutputFormat(cfg.OutputPath)
+	if err := p.validator.ValidateStreamCopy(format, video.CodecName); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Source video (%s) is compatible with %s output\n", style.OK, video.CodecName, format)
 	return nil
 }
+
+// preserveTimestamps copies the input file's modification time onto the output file
+func (p *Processor) preserveTimestamps(cfg *config.ProcessingConfig) {
+	if !p.commandBuilder.IsFileOutput(cfg.OutputPath) {
+		fmt.Println(style.Info, "Skipping timestamp preservation for streaming/pipe output")
+		return
+	}
+
+	info, err := os.Stat(cfg.InputPath)
+	if err != nil {
+		fmt.Printf("%s Could not read input timestamps: %v\n", style.Warn, err)
+		return
+	}
+
+	// Access time isn't portably available from os.Stat, so both are set to the mtime
+	mtime := info.ModTime()
+	for _, path := range p.outputFiles(cfg) {
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			fmt.Printf("%s Could not preserve timestamps: %v\n", style.Warn, err)
+			return
+		}
+	}
+
+	fmt.Printf("%s Output modification time set to %s\n", style.Clock, mtime.Format(time.RFC3339))
+}
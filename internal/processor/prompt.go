@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// maxPromptAttempts is how many times an invalid answer is re-prompted before giving up
+const maxPromptAttempts = 3
+
+// promptValue asks a question and re-prompts with the validation error until the answer
+// is accepted or the attempts run out. An empty answer is passed to validate as-is. When
+// stdin is not a terminal nothing is asked and the empty answer (the default) is used.
+func (p *Processor) promptValue(question string, validate func(string) error) (string, error) {
+	if !p.interactive {
+		return "", validate("")
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxPromptAttempts; attempt++ {
+		fmt.Print(question)
+		answer, err := p.reader.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if errors.Is(err, io.EOF) && answer == "" {
+			fmt.Println()
+			return "", fmt.Errorf("stdin closed before an answer was given")
+		}
+		if err != nil && answer == "" {
+			return "", fmt.Errorf("reading input: %w", err)
+		}
+
+		if lastErr = validate(answer); lastErr == nil {
+			return answer, nil
+		}
+
+		if attempt < maxPromptAttempts {
+			fmt.Printf("%s %v. Please try again (%d/%d).\n", style.Warn, lastErr, attempt, maxPromptAttempts)
+		}
+	}
+	return "", lastErr
+}
+
+// promptInputPath asks for the input, offering the INPUT_PATH default. When stdin is not a
+// terminal it uses INPUT_PATH, or else the first line piped on stdin, and fails straight
+// away if neither provides one.
+func (p *Processor) promptInputPath(cfg *config.ProcessingConfig, question string, validate func(string) error) (string, error) {
+	if p.interactive {
+		if cfg.InputPath != "" {
+			question = fmt.Sprintf("%s (default: %s): ", strings.TrimSuffix(question, ": "), cfg.InputPath)
+		}
+		input, err := p.promptValue(question, func(s string) error {
+			if s == "" {
+				s = cfg.InputPath
+			}
+			return validate(s)
+		})
+		if input == "" {
+			input = cfg.InputPath
+		}
+		return input, err
+	}
+
+	input := cfg.InputPath
+	if input == "" {
+		line, _ := p.reader.ReadString('\n')
+		input = strings.TrimSpace(line)
+	}
+	if input == "" {
+		return "", fmt.Errorf("%w and no input was given: set INPUT_PATH or pipe the input path on stdin", ErrNotInteractive)
+	}
+	fmt.Printf("%s Input: %s\n", style.Folder, input)
+	return input, validate(input)
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal rather than a pipe or file
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// parseQuality parses a quality value and checks it is within the encoder's native range
+func parseQuality(s, codec string) (int, error) {
+	quality, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("quality %q is not a whole number", s)
+	}
+	if err := encoder.QualityScaleFor(codec).Check(quality); err != nil {
+		return 0, fmt.Errorf("%w for %s", err, codec)
+	}
+	return quality, nil
+}
+
+// useInput sets the input to process, passing any HTTP headers and cookies on to the
+// prober, and derives the output name from it when -output-dir is given
+func (p *Processor) useInput(cfg *config.ProcessingConfig, input string) error {
+	cfg.InputPath = input
+	p.prober.SetHTTPOptions(encoder.HTTPInputOptions(cfg))
+	if (len(cfg.InputHeaders) > 0 || len(cfg.InputCookies) > 0) && !encoder.IsHTTPInput(input) {
+		fmt.Printf("%s -header and -cookie only apply to http(s):// inputs and are ignored\n", style.Warn)
+	}
+	p.applyOutputDir(cfg)
+	return nil
+}
+
+// validateOutputPath checks that a file output's parent directory exists
+func (p *Processor) validateOutputPath(path string) error {
+	if path == "" || !p.commandBuilder.IsFileOutput(path) {
+		return nil
+	}
+	if err := p.validator.ValidateOutputDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package processor
+
+import (
+	"fmt"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// applyQuality puts cfg.Quality on the final encoder's native scale: -quality-level is
+// translated, and a value chosen for another encoder (before a switch to HEVC or VP9, say)
+// is converted to the same perceptual quality
+func (p *Processor) applyQuality(cfg *config.ProcessingConfig, qualityCodec string) {
+	scale := encoder.QualityScaleFor(cfg.Codec)
+
+	switch {
+	case cfg.QualityLevel != nil:
+		cfg.Quality = encoder.QualityForLevel(cfg.Codec, *cfg.QualityLevel)
+		fmt.Printf("%s Quality level %d is %s %d for %s\n", style.Slider, *cfg.QualityLevel, scale.Option, cfg.Quality, cfg.Codec)
+	case encoder.QualityScaleFor(qualityCodec) != scale:
+		converted := encoder.ConvertQuality(cfg.Quality, qualityCodec, cfg.Codec)
+		fmt.Printf("%s Quality %d for %s is %s %d for %s\n", style.Slider, cfg.Quality, qualityCodec, scale.Option, converted, cfg.Codec)
+		cfg.Quality = converted
+	}
+}
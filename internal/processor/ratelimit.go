@@ -0,0 +1,66 @@
+package processor
+
+import (
+	"fmt"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/estimate"
+	"video_processing/internal/style"
+)
+
+// limitRateOverhead is the share of -limit-rate reserved for container and protocol overhead
+const limitRateOverhead = 0.05
+
+// assumedAudioBitrate is the audio share used when the input's audio bitrate can't be probed
+const assumedAudioBitrate = 128_000
+
+// prepareRateLimit fits the stream into -limit-rate: the video is capped at what remains after
+// audio and overhead, and the builder paces the input in real time. It only applies to
+// network outputs, since a file write has no uplink to protect.
+func (p *Processor) prepareRateLimit(cfg *config.ProcessingConfig) error {
+	if !encoder.IsStreamingURL(cfg.OutputPath) {
+		fmt.Println(style.Warn, "-limit-rate only applies to streaming URLs; writing the file at full speed")
+		cfg.LimitRate = ""
+		return nil
+	}
+
+	limit := config.ParseBitrate(cfg.LimitRate)
+	if cfg.CopyVideo {
+		fmt.Printf("%s Pacing the stream in real time; copied video can't be capped to %s\n", style.Warn, cfg.LimitRate)
+		return nil
+	}
+
+	audio := p.streamAudioBitrate(cfg)
+	video := limit*(1-limitRateOverhead) - audio
+	if video <= 0 {
+		return fmt.Errorf("-limit-rate %s leaves no room for video after %.0f kbps of audio", cfg.LimitRate, audio/1e3)
+	}
+
+	if maxRate := config.ParseBitrate(cfg.MaxRate); maxRate > 0 && maxRate <= video {
+		fmt.Printf("%s Pacing the stream to %s; -maxrate %s already fits\n", style.Stream, cfg.LimitRate, cfg.MaxRate)
+		return nil
+	}
+	cfg.MaxRate = config.FormatBitrate(video)
+	// A one-second buffer keeps bursts close to the uplink's capacity
+	if cfg.BufSize == "" || config.ParseBitrate(cfg.BufSize) > video {
+		cfg.BufSize = cfg.MaxRate
+	}
+	fmt.Printf("%s Pacing the stream to %s: video capped at %s, %.0f kbps for audio\n", style.Stream, cfg.LimitRate, cfg.MaxRate, audio/1e3)
+	return nil
+}
+
+// streamAudioBitrate predicts the audio bitrate of the output, assuming a typical bitrate
+// when the input can't be probed
+func (p *Processor) streamAudioBitrate(cfg *config.ProcessingConfig) float64 {
+	if cfg.NoAudio {
+		return 0
+	}
+	if b := config.ParseBitrate(cfg.AudioBitrate); b > 0 && cfg.AudioCodec != "" && cfg.AudioCodec != "copy" {
+		return b
+	}
+	if result, err := p.prober.Probe(cfg.InputPath); err == nil {
+		return estimate.Compute(cfg, result).AudioBitrate
+	}
+	return assumedAudioBitrate
+}
@@ -0,0 +1,148 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"video_processing/internal/encoder"
+	"video_processing/internal/probe"
+	"video_processing/internal/style"
+)
+
+// Record captures a live network input to a file. Streams are copied when the output
+// container can hold them (falling back to a GPU re-encode otherwise) and MP4/MOV output
+// is fragmented, so an interrupted recording is still playable.
+func (p *Processor) Record() error {
+	fmt.Println(style.Record, "FFmpeg Live Recorder")
+	fmt.Println(strings.Repeat("=", 50))
+
+	cfg := p.cfg
+	cfg.FragmentedMP4 = true
+
+	input, err := p.promptInputPath(cfg, fmt.Sprintf("%s Enter stream URL to record (rtsp://, rtmp://, srt://, http://): ", style.Stream), func(s string) error {
+		if s == "" {
+			return ErrNoInput
+		}
+		if !strings.Contains(s, "://") {
+			return fmt.Errorf("recording needs a network stream URL, got %q", s)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("input failed: %w", err)
+	}
+	if err := p.useInput(cfg, input); err != nil {
+		return err
+	}
+
+	output, err := p.promptValue(fmt.Sprintf("%s Output file (default: %s): ", style.Save, cfg.OutputPath), p.validateOutputPath)
+	if err != nil {
+		return fmt.Errorf("input failed: %w", err)
+	}
+	if output != "" {
+		cfg.OutputPath = output
+	}
+	if err := p.createOutputDir(cfg, output); err != nil {
+		return err
+	}
+
+	cfg.CopyVideo = true
+	if err := p.validateStreamCopy(cfg); err != nil {
+		fmt.Printf("%s %v; re-encoding the video instead\n", style.Warn, err)
+		cfg.CopyVideo = false
+
+		gpus, err := p.detectAndDisplayGPUs()
+		if err != nil {
+			return fmt.Errorf("GPU detection failed: %w", err)
+		}
+		if _, err := p.configureProcessing(gpus); err != nil {
+			return fmt.Errorf("configuration failed: %w", err)
+		}
+	}
+
+	if cfg.AudioChannels > 0 || cfg.AudioPan != "" {
+		p.prepareDownmix(cfg)
+	} else if cfg.AudioCodec == "" {
+		p.transcodeIncompatibleAudio()
+	}
+	if cfg.CopyData {
+		p.selectDataStreams(cfg)
+	}
+	p.prepareRTSP(cfg)
+
+	skip, err := p.applyOverwritePolicy(cfg)
+	if err != nil {
+		return err
+	}
+	if skip {
+		fmt.Printf("%s Output %s already exists, skipping (overwrite policy: %s)\n", style.Skip, cfg.OutputPath, cfg.OverwritePolicy)
+		return nil
+	}
+
+	// Every recording input is a live stream
+	p.capLiveDuration(cfg, true)
+	if cfg.Duration != "" {
+		fmt.Printf("%s Recording for %ss\n", style.Timer, cfg.Duration)
+	} else if p.interactive {
+		fmt.Println(style.Timer, "Recording until the stream ends or you press q")
+	} else {
+		fmt.Println(style.Timer, "Recording until the stream ends")
+	}
+
+	args := p.commandBuilder.BuildFFmpegCommand(cfg)
+	fmt.Printf("Command: %s %s\n", cfg.FFmpegPath, encoder.FormatArgs(args))
+	fmt.Println(strings.Repeat("-", 50))
+	if cfg.SaveCommand {
+		p.saveCommand(cfg, args)
+	}
+
+	start := time.Now()
+	stderr, err := p.runFFmpeg(context.Background(), cfg, args)
+	if err != nil {
+		// Whatever was captured before the failure is still a valid fragmented file
+		if info, statErr := os.Stat(cfg.OutputPath); statErr == nil && info.Size() > 0 {
+			fmt.Printf("%s Recording stopped early; %.2f MB captured in %s\n", style.Warn, float64(info.Size())/(1024*1024), cfg.OutputPath)
+		}
+		// A live capture has no expected length, so only empty or unreadable files are removed
+		p.handlePartialOutput(cfg, 0)
+		return fmt.Errorf("recording failed: %w", encoder.NewEncodeError(err, stderr))
+	}
+
+	fmt.Printf("%s Recording completed in %v\n", style.OK, time.Since(start).Round(time.Second))
+	fmt.Printf("%s Output saved to: %s\n", style.Folder, cfg.OutputPath)
+	if info, err := os.Stat(cfg.OutputPath); err == nil {
+		fmt.Printf("%s Output file size: %.2f MB\n", style.Stats, float64(info.Size())/(1024*1024))
+	}
+	return nil
+}
+
+// mp4AudioCodecs are the audio codecs that can be stream-copied into an MP4/MOV recording
+var mp4AudioCodecs = map[string]bool{"aac": true, "mp3": true, "alac": true, "opus": true, "ac3": true, "eac3": true}
+
+// transcodeIncompatibleAudio switches to AAC when the source audio can't be copied into
+// an MP4/MOV recording, e.g. the G.711 (pcm_mulaw/pcm_alaw) audio many IP cameras send
+func (p *Processor) transcodeIncompatibleAudio() {
+	cfg := p.cfg
+	switch p.commandBuilder.OutputFormat(cfg.OutputPath) {
+	case "mp4", "mov":
+	default:
+		return
+	}
+
+	result, err := p.prober.Probe(cfg.InputPath)
+	if errors.Is(err, probe.ErrFFprobeNotFound) {
+		fmt.Printf("%s %v: can't check the stream's audio; pass -audio-codec aac if the recording fails\n", style.Warn, err)
+		return
+	}
+	if err != nil {
+		return
+	}
+	if audio := result.AudioStream(); audio != nil && !mp4AudioCodecs[audio.CodecName] {
+		fmt.Printf("%s %s audio can't be stored in MP4; encoding it to AAC\n", style.Audio, audio.CodecName)
+		cfg.AudioCodec = "aac"
+	}
+}
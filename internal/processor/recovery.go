@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// recovery is an intermediate retry that keeps hardware encoding, attempted after the primary
+// encode fails and before falling back to full software encoding
+type recovery struct {
+	description string
+	// applies reports whether the recovery fits the failed config and FFmpeg's stderr
+	applies func(cfg *config.ProcessingConfig, stderr string) bool
+	// adjust modifies a copy of the config for the retry
+	adjust func(cfg *config.ProcessingConfig)
+}
+
+// recoveries are tried in order; each one that applies gets a single retry
+var recoveries = []recovery{
+	{
+		description: "software decode + hardware encode",
+		applies: func(cfg *config.ProcessingConfig, stderr string) bool {
+			return isHardwareEncode(cfg) && !cfg.SoftwareDecode && encoder.IsHWDecodeFailure(stderr)
+		},
+		adjust: func(cfg *config.ProcessingConfig) {
+			cfg.SoftwareDecode = true
+		},
+	},
+	{
+		description: "hardware encode of 8-bit 4:2:0 video",
+		applies: func(cfg *config.ProcessingConfig, stderr string) bool {
+			// VAAPI already converts to NV12 before uploading, so there's nothing to retry
+			return isHardwareEncode(cfg) && cfg.PixFmt == "" && !strings.HasSuffix(cfg.Codec, "_vaapi") &&
+				encoder.IsPixelFormatFailure(stderr)
+		},
+		adjust: func(cfg *config.ProcessingConfig) {
+			// QSV takes 8-bit 4:2:0 as NV12; the other encoders accept planar yuv420p
+			cfg.PixFmt = "yuv420p"
+			if strings.HasSuffix(cfg.Codec, "_qsv") {
+				cfg.PixFmt = "nv12"
+			}
+			fmt.Printf("%s %s rejected the source pixel format; converting to %s\n", style.Switch, cfg.Codec, cfg.PixFmt)
+		},
+	},
+	{
+		description: "hardware encode downscaled after running out of GPU memory",
+		applies: func(cfg *config.ProcessingConfig, stderr string) bool {
+			return isHardwareEncode(cfg) && cfg.OOMDownscaleHeight > 0 && cfg.ScaleHeight == 0 && encoder.IsOutOfMemory(stderr)
+		},
+		adjust: func(cfg *config.ProcessingConfig) {
+			fmt.Printf("%s GPU ran out of memory; downscaling to at most %dp\n", style.Down, cfg.OOMDownscaleHeight)
+			cfg.ScaleHeight = cfg.OOMDownscaleHeight
+		},
+	},
+}
+
+// tryRecoveries retries a failed hardware encode with each applicable recovery. It returns
+// nil once a retry succeeds, otherwise the last error with its captured stderr.
+func (p *Processor) tryRecoveries(ctx context.Context, cfg *config.ProcessingConfig, stderr []byte, err error) ([]byte, error) {
+	for _, r := range recoveries {
+		if !r.applies(cfg, string(stderr)) {
+			continue
+		}
+
+		retryCfg := *cfg
+		r.adjust(&retryCfg)
+
+		fmt.Printf("\n%s Retrying with %s\n", style.Retry, r.description)
+		args := p.commandBuilder.BuildFFmpegCommand(&retryCfg)
+		fmt.Printf("Command: %s %s\n", retryCfg.FFmpegPath, encoder.FormatArgs(args))
+
+		stderr, err = p.runFFmpeg(ctx, &retryCfg, args)
+		if err == nil {
+			fmt.Printf("%s Recovered using %s\n", style.OK, r.description)
+			*cfg = retryCfg
+			return stderr, nil
+		}
+		fmt.Printf("%s Retry with %s failed: %v\n", style.Error, r.description, err)
+	}
+	return stderr, err
+}
+
+// retryTransientInit reruns a hardware encode whose encoder failed to initialize in a way that
+// usually clears up on its own, such as NVENC right after boot or a driver reload. Permanent
+// capability errors aren't retried; they go straight on to the recoveries and fallbacks.
+func (p *Processor) retryTransientInit(ctx context.Context, cfg *config.ProcessingConfig, args []string, stderr []byte, err error) ([]byte, error) {
+	for attempt := 1; attempt <= cfg.InitRetries; attempt++ {
+		if !isHardwareEncode(cfg) || !encoder.IsTransientInitFailure(string(stderr)) {
+			break
+		}
+
+		fmt.Printf("\n%s %s failed to initialize; retry %d/%d in %v\n", style.Retry, cfg.Codec, attempt, cfg.InitRetries, cfg.InitRetryDelay)
+		select {
+		case <-time.After(cfg.InitRetryDelay):
+		case <-ctx.Done():
+			return stderr, err
+		}
+
+		stderr, err = p.runFFmpeg(ctx, cfg, args)
+		if err == nil {
+			fmt.Printf("%s %s initialized on retry %d\n", style.OK, cfg.Codec, attempt)
+			return stderr, nil
+		}
+		fmt.Printf("%s Retry %d/%d failed: %v\n", style.Error, attempt, cfg.InitRetries, err)
+	}
+	return stderr, err
+}
+
+// isHardwareEncode reports whether the config uses a hardware encoder
+func isHardwareEncode(cfg *config.ProcessingConfig) bool {
+	return cfg.Acceleration != "" && cfg.Acceleration != "none" && !cfg.CopyVideo
+}
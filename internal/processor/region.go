@@ -0,0 +1,52 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// prepareRegion checks that -crop fits inside the source and, for -zoom, fills in the frame
+// count, frame rate and output size the zoompan filter needs from the probed input
+func (p *Processor) prepareRegion(cfg *config.ProcessingConfig) error {
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		if cfg.Zoom {
+			return fmt.Errorf("-zoom needs the input's frame rate and duration, but probing failed: %w", err)
+		}
+		fmt.Printf("%s Could not probe input, skipping crop region check: %v\n", style.Warn, err)
+		return nil
+	}
+	video := result.VideoStream()
+	if video == nil || video.Width <= 0 || video.Height <= 0 {
+		return fmt.Errorf("input has no video stream with a known size to crop")
+	}
+
+	if r := cfg.Crop; r != nil {
+		if r.X+r.Width > video.Width || r.Y+r.Height > video.Height {
+			return fmt.Errorf("crop region %s extends beyond the %dx%d source", r, video.Width, video.Height)
+		}
+		if cfg.OutputWidth == 0 && !cfg.Zoom && (r.Width%2 != 0 || r.Height%2 != 0) {
+			// 4:2:0 encoders need even dimensions
+			r.Width, r.Height = r.Width&^1, r.Height&^1
+			fmt.Printf("%s Crop region rounded down to even size %dx%d\n", style.Ruler, r.Width, r.Height)
+		}
+	}
+
+	if cfg.Zoom {
+		fps := video.FPS()
+		duration := p.expectedDuration(cfg)
+		if fps <= 0 || duration <= 0 {
+			return fmt.Errorf("-zoom needs the input's frame rate and duration, which ffprobe did not report")
+		}
+		cfg.ZoomFrames = int(math.Round(duration * fps))
+		cfg.ZoomFPS = video.FrameRate
+		if cfg.OutputWidth == 0 {
+			cfg.OutputWidth, cfg.OutputHeight = video.Width&^1, video.Height&^1
+		}
+		fmt.Printf("%s Zooming into %s over %d frames, output %dx%d\n", style.Search, cfg.Crop, cfg.ZoomFrames, cfg.OutputWidth, cfg.OutputHeight)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"video_processing/internal/config"
+	"video_processing/internal/report"
+	"video_processing/internal/style"
+)
+
+// renditionPath names a rendition's file after the output: movie.mp4 -> movie_720p.mp4
+func renditionPath(output string, r config.OutputRendition) string {
+	ext := filepath.Ext(output)
+	return strings.TrimSuffix(output, ext) + "_" + r.Name() + ext
+}
+
+// processRenditions encodes the input once per -renditions entry, each scaled to its height
+// and capped to its bitrate, into files named after the output. A failed rendition doesn't
+// stop the others; every one is reported, and the batch fails if any of them did.
+func (p *Processor) processRenditions(cfg *config.ProcessingConfig) error {
+	if !p.commandBuilder.IsFileOutput(cfg.OutputPath) {
+		return p.failEarly(cfg, fmt.Errorf("-renditions writes one file per rendition and needs a file output, not %s", cfg.OutputPath))
+	}
+
+	sourceHeight := 0
+	if result, err := p.prober.Probe(cfg.InputPath); err == nil {
+		if video := result.VideoStream(); video != nil {
+			sourceHeight = video.Height
+		}
+	}
+
+	var jobs []report.Job
+	var failed []string
+	for i, r := range cfg.Renditions {
+		fmt.Printf("\n%s Rendition %d/%d: %s\n", style.Video, i+1, len(cfg.Renditions), r.Name())
+		if sourceHeight > 0 && r.Height >= sourceHeight {
+			fmt.Printf("%s The source is only %dp; %s keeps the source size\n", style.Warn, sourceHeight, r.Name())
+		}
+
+		job, err := p.encodeRendition(cfg, r)
+		jobs = append(jobs, job)
+		if err != nil {
+			fmt.Printf("%s Rendition %s failed: %v\n", style.Error, r.Name(), err)
+			failed = append(failed, r.Name())
+		}
+	}
+
+	printRenditions(jobs, cfg.Renditions)
+	p.reportJobs(cfg, jobs)
+	if len(failed) > 0 {
+		return fmt.Errorf("video processing failed for rendition(s) %s", strings.Join(failed, ", "))
+	}
+
+	i := slices.IndexFunc(jobs, func(job report.Job) bool { return job.Status == report.StatusSuccess })
+	if i < 0 || !p.interactive {
+		return nil
+	}
+	return p.player.OfferPlayback(jobs[i].Output)
+}
+
+// encodeRendition runs the regular encode for one rendition on a copy of the configuration
+func (p *Processor) encodeRendition(base *config.ProcessingConfig, r config.OutputRendition) (report.Job, error) {
+	cfg := *base
+	// processVideo appends to these, which must not leak into the next rendition
+	cfg.Maps = slices.Clone(base.Maps)
+	cfg.Dispositions = slices.Clone(base.Dispositions)
+	cfg.OutputPath = renditionPath(base.OutputPath, r)
+	cfg.ScaleHeight = r.Height
+	if r.MaxRate != "" {
+		cfg.MaxRate, cfg.BufSize = r.MaxRate, ""
+	}
+
+	p.job = report.Job{Input: cfg.InputPath}
+	start := time.Now()
+
+	// The derived name may still be the input, which guardInPlace refuses
+	final, err := p.guardInPlace(&cfg)
+	if err != nil {
+		return p.completeJob(&cfg, 0, err), err
+	}
+	if final == "" {
+		final = p.useTempOutput(&cfg)
+	}
+	err = p.processVideo(&cfg)
+	if final != "" {
+		err = p.finishTempOutput(&cfg, final, err)
+	}
+	return p.completeJob(&cfg, time.Since(start), err), err
+}
+
+// printRenditions summarizes the outcome of each rendition
+func printRenditions(jobs []report.Job, renditions []config.OutputRendition) {
+	fmt.Printf("\n%s Renditions:\n", style.Stats)
+	for i, job := range jobs {
+		fmt.Printf("   %-7s %-8s %s", renditions[i].Name(), job.Status, job.Output)
+		if job.OutputBytes > 0 {
+			fmt.Printf(" (%.2f MB in %v)", float64(job.OutputBytes)/(1024*1024), time.Duration(job.DurationSeconds*float64(time.Second)).Round(time.Second))
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"video_processing/internal/config"
+	"video_processing/internal/report"
+	"video_processing/internal/style"
+)
+
+// reportJobs writes the finished jobs to the -report file and sends them to -notify. It runs
+// whether or not the encodes succeeded, so failures can be post-processed too.
+func (p *Processor) reportJobs(cfg *config.ProcessingConfig, jobs []report.Job) {
+	if cfg.ReportPath != "" {
+		p.writeJobs(cfg.ReportPath, jobs)
+	}
+	if cfg.Notify != "" {
+		p.notify(cfg.Notify, jobs)
+	}
+}
+
+// failEarly reports a job that failed before its encode could start, e.g. on GPU detection
+// or a missing input, and returns err
+func (p *Processor) failEarly(cfg *config.ProcessingConfig, err error) error {
+	if cfg.ReportPath != "" || cfg.Notify != "" {
+		p.job = report.Job{Input: cfg.InputPath}
+		p.reportJobs(cfg, []report.Job{p.completeJob(cfg, 0, err)})
+	}
+	return err
+}
+
+// completeJob fills in the current job's outcome from the finished encode
+func (p *Processor) completeJob(cfg *config.ProcessingConfig, elapsed time.Duration, err error) report.Job {
+	job := p.job
+	job.Output = cfg.OutputPath
+	if job.Codec == "" {
+		job.Codec = cfg.Codec
+		if cfg.CopyVideo {
+			job.Codec = "copy"
+		}
+	}
+	job.DurationSeconds = elapsed.Seconds()
+
+	switch {
+	case err != nil:
+		job.Status = report.StatusFailed
+		job.Error = report.NewJobError(err, job.Input, cfg.OutputPath)
+	case job.Status == "":
+		job.Status = report.StatusSuccess
+	}
+
+	if job.Status == report.StatusSuccess && p.commandBuilder.IsFileOutput(cfg.OutputPath) {
+		if size, ok := p.outputSize(cfg); ok {
+			job.OutputBytes = size
+		}
+		if !segmenting(cfg) {
+			job.FPS = p.encodeFPS(cfg.OutputPath, elapsed)
+		}
+	}
+	return job
+}
+
+// writeJobs writes the jobs to the report file at path
+func (p *Processor) writeJobs(path string, jobs []report.Job) {
+	if err := report.Write(path, jobs); err != nil {
+		fmt.Printf("%s Could not write report: %v\n", style.Warn, err)
+		return
+	}
+	fmt.Printf("%s Report written to %s\n", style.Receipt, path)
+}
+
+// encodeFPS estimates the average encoding speed in frames per second from the output's
+// duration and frame rate
+func (p *Processor) encodeFPS(outputPath string, elapsed time.Duration) float64 {
+	result, err := p.prober.Probe(outputPath)
+	if err != nil || elapsed <= 0 {
+		return 0
+	}
+	video := result.VideoStream()
+	if video == nil {
+		return 0
+	}
+	return result.DurationSeconds() * video.FPS() / elapsed.Seconds()
+}
@@ -0,0 +1,31 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// prepareRTSP detects the FFmpeg version when RTSP is involved, since the RTSP timeout
+// options were renamed in FFmpeg 5, and checks that -rtsp-listen has an RTSP input to serve
+func (p *Processor) prepareRTSP(cfg *config.ProcessingConfig) {
+	inputRTSP := strings.HasPrefix(strings.ToLower(cfg.InputPath), "rtsp")
+	outputRTSP := strings.HasPrefix(strings.ToLower(cfg.OutputPath), "rtsp")
+
+	if cfg.RTSPListen && !inputRTSP {
+		fmt.Println(style.Warn, "-rtsp-listen only applies to rtsp:// inputs and will be ignored")
+		cfg.RTSPListen = false
+	}
+	if !inputRTSP && !outputRTSP {
+		return
+	}
+
+	if major, err := p.validator.FFmpegMajorVersion(); err == nil {
+		cfg.FFmpegMajor = major
+	}
+	if cfg.RTSPListen {
+		fmt.Printf("%s Waiting for a client to publish to %s\n", style.Stream, cfg.InputPath)
+	}
+}
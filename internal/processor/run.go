@@ -0,0 +1,169 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/progress"
+	"video_processing/internal/style"
+)
+
+// runFFmpeg runs FFmpeg with the given arguments, mirroring its logs to the terminal while
+// keeping a copy for error reporting. When a progress writer is set, FFmpeg's -progress
+// output is parsed and re-emitted as one JSON object per line. With -stall-timeout the same
+// output feeds a watchdog that stops FFmpeg once progress stops advancing.
+func (p *Processor) runFFmpeg(ctx context.Context, cfg *config.ProcessingConfig, args []string) ([]byte, error) {
+	trackProgress := p.progressOut != nil
+	watch := cfg.StallTimeout > 0
+	// A capped stream's average bitrate is only known from FFmpeg's progress reports
+	measure := cfg.MaxRate != "" && encoder.IsStreamingURL(cfg.OutputPath)
+	p.streamBitrate = 0
+	if (trackProgress || watch) && writesToStdout(cfg.OutputPath) {
+		if trackProgress {
+			fmt.Println(style.Warn, "JSON progress disabled: the output is written to stdout")
+		}
+		if watch {
+			fmt.Println(style.Warn, "Stall watchdog disabled: the output is written to stdout")
+		}
+		trackProgress, watch = false, false
+	}
+	// Hardware encodes log at verbose level so the device FFmpeg opened can be found;
+	// the terminal and the returned log only get what FFmpeg shows by default
+	p.deviceLog = nil
+	verbose := isHardwareEncode(cfg)
+	if verbose {
+		args = append(append([]string{}, encoder.DeviceLogArgs...), args...)
+	}
+	switch {
+	case trackProgress:
+		args = append(append([]string{}, progress.Args...), args...)
+	case watch || measure:
+		// Keep FFmpeg's stats line on the terminal; only the watchdog and bitrate check read the progress
+		args = append([]string{"-progress", "pipe:1"}, args...)
+	}
+
+	var watchdog *stallWatchdog
+	if watch {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		watchdog = newStallWatchdog(cfg.StallTimeout, listensForInput(cfg))
+		go watchdog.run(ctx, cancel)
+	}
+
+	cmd := exec.CommandContext(ctx, cfg.FFmpegPath, args...)
+	if cfg.GPUDevice != "" {
+		// Number CUDA devices like nvidia-smi does, so device indexes are stable
+		cmd.Env = append(os.Environ(), "CUDA_DEVICE_ORDER=PCI_BUS_ID")
+	}
+
+	// Don't wait indefinitely on output pipes a killed FFmpeg's child processes keep open
+	cmd.WaitDelay = 5 * time.Second
+
+	// A recording runs until the stream ends or FFmpeg's q key, which finishes the file cleanly
+	if cfg.Record && p.interactive {
+		cmd.Stdin = os.Stdin
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	var levels *encoder.LevelFilter
+	if verbose {
+		levels = &encoder.LevelFilter{W: cmd.Stderr}
+		cmd.Stderr = levels
+	}
+
+	if !trackProgress && !watch && !measure {
+		cmd.Stdout = os.Stdout
+		err := cmd.Run()
+		p.keepDeviceLog(levels)
+		return stderr.Bytes(), err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	// Unblock the progress reader once FFmpeg is killed, even if a child process still holds its stdout
+	stop := context.AfterFunc(ctx, func() { stdout.Close() })
+	defer stop()
+
+	var total float64
+	var enc *json.Encoder
+	if trackProgress {
+		total = p.expectedDuration(cfg)
+		enc = json.NewEncoder(p.progressOut)
+	}
+	parseErr := progress.Parse(stdout, total, func(u progress.Update) {
+		if enc != nil {
+			enc.Encode(u)
+		}
+		if watchdog != nil {
+			watchdog.observe(u)
+		}
+		if bps := u.BitsPerSecond(); bps > 0 {
+			p.streamBitrate = bps
+		}
+	})
+
+	err = cmd.Wait()
+	p.keepDeviceLog(levels)
+	if watchdog != nil {
+		if stallErr := watchdog.err(); stallErr != nil {
+			return stderr.Bytes(), stallErr
+		}
+	}
+	if err != nil {
+		return stderr.Bytes(), err
+	}
+	return stderr.Bytes(), parseErr
+}
+
+// keepDeviceLog passes on the last line of a verbose run's log and keeps its verbose lines
+func (p *Processor) keepDeviceLog(levels *encoder.LevelFilter) {
+	if levels == nil {
+		return
+	}
+	levels.Flush()
+	p.deviceLog = levels.Verbose.Bytes()
+}
+
+// expectedDuration returns the output duration in seconds for percentage reporting, or 0 if unknown
+func (p *Processor) expectedDuration(cfg *config.ProcessingConfig) float64 {
+	if cfg.Duration != "" {
+		if d, err := strconv.ParseFloat(cfg.Duration, 64); err == nil {
+			return d
+		}
+	}
+
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		return 0
+	}
+	total := result.DurationSeconds()
+	if start, err := strconv.ParseFloat(cfg.StartTime, 64); err == nil && start < total {
+		total -= start
+	}
+	if cfg.PlaybackSpeed > 0 {
+		// -t already counts output time; the input's duration changes with the speed
+		total /= cfg.PlaybackSpeed
+	}
+	return total
+}
+
+// writesToStdout reports whether FFmpeg's output target is its own stdout
+func writesToStdout(outputPath string) bool {
+	return outputPath == "-" || outputPath == "pipe:" || outputPath == "pipe:1"
+}
@@ -0,0 +1,48 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// checkStartPosition validates -start against the input's duration and, for stream copy,
+// snaps it to the preceding keyframe. A copied stream can only begin on a keyframe, so
+// FFmpeg would silently start there anyway; snapping makes the real cut point explicit.
+func (p *Processor) checkStartPosition(cfg *config.ProcessingConfig) error {
+	start, err := strconv.ParseFloat(cfg.StartTime, 64)
+	if err != nil || strings.Contains(cfg.InputPath, "://") {
+		return nil
+	}
+
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		fmt.Printf("%s Could not probe input, skipping start position check: %v\n", style.Warn, err)
+		return nil
+	}
+	if total := result.DurationSeconds(); total > 0 && start >= total {
+		return fmt.Errorf("start position %.3fs is beyond the end of the input (%.3fs)", start, total)
+	}
+
+	if !cfg.CopyVideo {
+		return nil
+	}
+
+	keyframe, err := p.prober.KeyframeBefore(cfg.InputPath, start, result.StartSeconds())
+	if err != nil {
+		fmt.Printf("%s Could not locate keyframes; the copied video may start before %.3fs: %v\n", style.Warn, start, err)
+		return nil
+	}
+
+	if offset := start - keyframe; offset > 0.001 {
+		fmt.Printf("%s Stream copy can only cut on keyframes: starting at %.3fs, %.3fs before the requested %.3fs\n", style.Warn, keyframe, offset, start)
+		fmt.Println("   Re-encode (omit -copy-video) for a frame-accurate start")
+	}
+	// Rounded up: a position just before the keyframe would make FFmpeg start at the one before it
+	cfg.StartTime = strconv.FormatFloat(math.Ceil(keyframe*1000-1e-6)/1000, 'f', 3, 64)
+	return nil
+}
@@ -0,0 +1,108 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/estimate"
+	"video_processing/internal/style"
+)
+
+// prepareSegments checks the output can be segmented and, for -segment-size, derives the
+// segment duration from the predicted bitrate
+func (p *Processor) prepareSegments(cfg *config.ProcessingConfig) error {
+	format := p.commandBuilder.OutputFormat(cfg.OutputPath)
+	if !p.commandBuilder.IsFileOutput(cfg.OutputPath) || format == "hls" || format == "dash" {
+		return fmt.Errorf("segmented output needs a file output such as out.mp4 (HLS and DASH are already segmented), got %s", cfg.OutputPath)
+	}
+
+	if cfg.SegmentSize > 0 {
+		seconds, err := p.segmentSeconds(cfg)
+		if err != nil {
+			return err
+		}
+		cfg.SegmentTime = strconv.FormatFloat(seconds, 'f', 0, 64)
+	}
+
+	fmt.Printf("%s Splitting output into %ss segments: %s\n", style.Film, cfg.SegmentTime, encoder.SegmentPattern(cfg.OutputPath))
+	return nil
+}
+
+// segmentSeconds converts -segment-size into a duration. The bitrate is a prediction, so it
+// errs high (the -maxrate cap, or the upper end of the estimate) to keep segments under the size.
+func (p *Processor) segmentSeconds(cfg *config.ProcessingConfig) (float64, error) {
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		return 0, fmt.Errorf("-segment-size needs the input's bitrate, but probing failed: %w (use -segment-time instead)", err)
+	}
+
+	e := estimate.Compute(cfg, result)
+	videoBitrate := e.VideoBitrate * estimate.SizeHigh
+	if maxRate := config.ParseBitrate(cfg.MaxRate); maxRate > 0 {
+		videoBitrate = maxRate
+	}
+	bitrate := videoBitrate + e.AudioBitrate
+	if bitrate <= 0 {
+		return 0, fmt.Errorf("could not predict the output bitrate for -segment-size; use -segment-time instead")
+	}
+
+	seconds := math.Max(math.Floor(float64(cfg.SegmentSize)*8/bitrate), 1)
+	fmt.Printf("%s Segments of up to %.0f MB at ~%.1f Mbps last about %.0fs each (sizes are approximate)\n",
+		style.Ruler, float64(cfg.SegmentSize)/(1024*1024), bitrate/1e6, seconds)
+	return seconds, nil
+}
+
+// segmenting reports whether the output is split into segments. -segment-size only becomes a
+// segment time in prepareSegments, so it counts too, e.g. for the -skip-existing check before it.
+func segmenting(cfg *config.ProcessingConfig) bool {
+	return cfg.SegmentTime != "" || cfg.SegmentSize > 0
+}
+
+// outputFiles returns the files an encode writes: the numbered segments that exist when
+// segmenting, otherwise the output path itself
+func (p *Processor) outputFiles(cfg *config.ProcessingConfig) []string {
+	if !segmenting(cfg) {
+		return []string{cfg.OutputPath}
+	}
+
+	var files []string
+	for i := 0; ; i++ {
+		path := encoder.SegmentPath(cfg.OutputPath, i)
+		if _, err := os.Stat(path); err != nil {
+			return files
+		}
+		files = append(files, path)
+	}
+}
+
+// clearSegments removes the segments of an earlier run once the overwrite policy allows
+// replacing them. FFmpeg only overwrites as many as it writes, so a shorter encode would
+// otherwise leave stale segments after its last one.
+func (p *Processor) clearSegments(cfg *config.ProcessingConfig) {
+	var removed int
+	for _, path := range p.outputFiles(cfg) {
+		if err := os.Remove(path); err == nil {
+			removed++
+		}
+	}
+	if removed > 0 {
+		fmt.Printf("%s Removed %d segments left from an earlier run\n", style.Trash, removed)
+	}
+}
+
+// outputSize returns the total size in bytes of the files an encode wrote
+func (p *Processor) outputSize(cfg *config.ProcessingConfig) (int64, bool) {
+	var total int64
+	found := false
+	for _, path := range p.outputFiles(cfg) {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+			found = true
+		}
+	}
+	return total, found
+}
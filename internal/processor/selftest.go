@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"video_processing/internal/style"
+)
+
+// selfTestDuration is the length in seconds of the synthetic clip used by SelfTest
+const selfTestDuration = 3
+
+// SelfTest runs the whole pipeline (detection, command build, encode, fallback and
+// verification) against a synthetic clip and reports a pass/fail diagnostic
+func (p *Processor) SelfTest() error {
+	fmt.Println(style.Test, "FFmpeg Video Processor self-test")
+	fmt.Println(strings.Repeat("=", 50))
+
+	gpus, err := p.detectAndDisplayGPUs()
+	if err != nil {
+		return fmt.Errorf("GPU detection failed: %w", err)
+	}
+
+	cfg, err := p.configureProcessing(gpus)
+	if err != nil {
+		return fmt.Errorf("configuration failed: %w", err)
+	}
+
+	if err := p.validator.ValidateSetup(cfg); err != nil {
+		return fmt.Errorf("self-test FAILED: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "video_processing-selftest-")
+	if err != nil {
+		return fmt.Errorf("could not create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg.InputPath = filepath.Join(dir, "source.mp4")
+	cfg.OutputPath = filepath.Join(dir, "output.mp4")
+
+	fmt.Println(style.Film, "Generating synthetic test clip...")
+	if err := p.generateTestClip(cfg.FFmpegPath, cfg.InputPath); err != nil {
+		return fmt.Errorf("self-test FAILED: could not generate test clip: %w", err)
+	}
+
+	if err := p.processVideo(cfg); err != nil {
+		return fmt.Errorf("self-test FAILED: %w", err)
+	}
+
+	if !p.prober.Available() {
+		// Without ffprobe only the file itself can be checked
+		info, err := os.Stat(cfg.OutputPath)
+		if err != nil || info.Size() == 0 {
+			return fmt.Errorf("self-test FAILED: output %s is missing or empty", cfg.OutputPath)
+		}
+		fmt.Println(strings.Repeat("-", 50))
+		fmt.Printf("%s Self-test PASSED (%s, %s); output not verified: ffprobe not found (%s)\n", style.OK, cfg.Acceleration, cfg.Codec, cfg.FFprobePath())
+		return nil
+	}
+
+	fmt.Println(style.Inspect, "Verifying output with ffprobe...")
+	if err := p.verifyTestOutput(cfg.OutputPath); err != nil {
+		return fmt.Errorf("self-test FAILED: %w", err)
+	}
+
+	fmt.Println(strings.Repeat("-", 50))
+	fmt.Printf("%s Self-test PASSED (%s, %s)\n", style.OK, cfg.Acceleration, cfg.Codec)
+	return nil
+}
+
+// generateTestClip renders a short testsrc video with a sine tone using software encoding
+func (p *Processor) generateTestClip(ffmpegPath, path string) error {
+	duration := strconv.Itoa(selfTestDuration)
+	cmd := exec.Command(ffmpegPath,
+		"-hide_banner", "-loglevel", "error",
+		"-f", "lavfi", "-i", "testsrc=duration="+duration+":size=1280x720:rate=30",
+		"-f", "lavfi", "-i", "sine=frequency=1000:duration="+duration,
+		"-c:v", "libx264", "-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-shortest",
+		"-y", path,
+	)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// verifyTestOutput checks the encoded clip has a video stream of the expected length
+func (p *Processor) verifyTestOutput(path string) error {
+	result, err := p.prober.Probe(path)
+	if err != nil {
+		return err
+	}
+
+	video := result.VideoStream()
+	if video == nil {
+		return fmt.Errorf("output has no video stream")
+	}
+
+	duration, err := strconv.ParseFloat(result.Format.Duration, 64)
+	if err != nil || duration < selfTestDuration/2.0 {
+		return fmt.Errorf("output duration %q is shorter than expected", result.Format.Duration)
+	}
+
+	fmt.Printf("%s Output verified: %s %dx%d, %.1fs\n", style.OK, video.CodecName, video.Width, video.Height, duration)
+	return nil
+}
@@ -0,0 +1,21 @@
+package processor
+
+import (
+	"fmt"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+	"video_processing/utils"
+)
+
+// resolveSoftwarePreset replaces -software-preset auto with the x264/x265 preset suiting the
+// CPU and -speed, so software encodes and the fallbacks use more of a capable machine
+func (p *Processor) resolveSoftwarePreset(cfg *config.ProcessingConfig) {
+	if cfg.SoftwarePreset != config.SoftwarePresetAuto {
+		return
+	}
+	cpu := utils.DetectCPU()
+	cfg.SoftwarePreset = encoder.SoftwarePreset(cpu, cfg.Speed)
+	fmt.Printf("%s CPU: %s; software preset: %s (-speed %s)\n", style.Stats, cpu, cfg.SoftwarePreset, cfg.Speed)
+}
@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"video_processing/internal/config"
+	"video_processing/internal/encoder"
+	"video_processing/internal/style"
+)
+
+// streamCheckTimeout bounds the -check-stream publish, since FFmpeg may wait a long time
+// on an endpoint that silently drops the connection
+const streamCheckTimeout = 15 * time.Second
+
+// streamCheckSettle is how long to wait after a test publish before the encode connects, so
+// the server has closed the test session and doesn't refuse the stream as already publishing
+const streamCheckSettle = 3 * time.Second
+
+// rtmpHandshakeSize is the length of the C1/C2 and S1/S2 RTMP handshake packets
+const rtmpHandshakeSize = 1536
+
+// checkStream tests the streaming output before the encode, so a wrong URL or a rejected
+// stream key fails in seconds rather than once the encode is under way. RTMP outputs only
+// get a handshake, since a test publish would be seen by viewers and could leave the server
+// refusing the real stream as already publishing; other protocols get a short test pattern.
+// The URL's credentials and stream key are redacted in everything it prints.
+func (p *Processor) checkStream(cfg *config.ProcessingConfig) error {
+	target := encoder.RedactStreamURL(cfg.OutputPath)
+	if !encoder.StreamCheckable(cfg.OutputPath) {
+		fmt.Printf("%s -check-stream only tests rtmp://, srt:// and rtsp:// outputs; not checking %s\n", style.Warn, target)
+		return nil
+	}
+
+	if u, err := url.Parse(cfg.OutputPath); err == nil && (u.Scheme == "rtmp" || u.Scheme == "rtmps") {
+		fmt.Printf("%s Checking %s with an RTMP handshake...\n", style.Stream, target)
+		start := time.Now()
+		if err := rtmpHandshake(u, streamCheckTimeout); err != nil {
+			reason := encoder.StreamCheckFailure(err.Error())
+			if reason == "" {
+				reason = encoder.RedactStreamDiagnostics(err.Error(), cfg.OutputPath)
+			}
+			return fmt.Errorf("%w: %s: %s", ErrStreamUnreachable, target, reason)
+		}
+		fmt.Printf("%s %s completed the RTMP handshake (%.1fs); the stream key is only checked once the encode publishes\n",
+			style.OK, target, time.Since(start).Seconds())
+		return nil
+	}
+
+	fmt.Printf("%s Checking %s with a %ds test stream (viewers may briefly see it)...\n", style.Stream, target, encoder.StreamCheckSeconds)
+	ctx, cancel := context.WithTimeout(context.Background(), streamCheckTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cfg.FFmpegPath, p.commandBuilder.BuildStreamCheckCommand(cfg)...)
+	cmd.Stderr = &stderr
+	start := time.Now()
+	err := cmd.Run()
+	if err == nil {
+		fmt.Printf("%s %s accepted the test stream (%.1fs)\n", style.OK, target, time.Since(start).Seconds())
+		fmt.Printf("%s Waiting %s for the server to close the test session...\n", style.Info, streamCheckSettle)
+		time.Sleep(streamCheckSettle)
+		return nil
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return err
+	}
+
+	output := strings.TrimSpace(encoder.RedactStreamDiagnostics(stderr.String(), cfg.OutputPath))
+	reason := encoder.StreamCheckFailure(output)
+	switch {
+	case ctx.Err() != nil:
+		reason = fmt.Sprintf("no response within %s", streamCheckTimeout)
+	case reason == "" && output != "":
+		lines := strings.Split(output, "\n")
+		reason = lines[len(lines)-1]
+	case reason == "":
+		reason = err.Error()
+	}
+	if output != "" {
+		fmt.Printf("%s FFmpeg output:\n%s\n", style.Error, output)
+	}
+	return fmt.Errorf("%w: %s: %s", ErrStreamUnreachable, target, reason)
+}
+
+// rtmpHandshake connects to an rtmp:// or rtmps:// URL and completes the RTMP handshake,
+// which proves an RTMP server is listening there without publishing anything
+func rtmpHandshake(u *url.URL, timeout time.Duration) error {
+	port := u.Port()
+	switch {
+	case port != "":
+	case u.Scheme == "rtmps":
+		port = "443"
+	default:
+		port = "1935"
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if u.Scheme == "rtmps" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: u.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	// C0 is the protocol version; C1 is a zero time and version followed by random bytes
+	c01 := make([]byte, 1+rtmpHandshakeSize)
+	c01[0] = 3
+	rand.Read(c01[9:])
+	if _, err := conn.Write(c01); err != nil {
+		return err
+	}
+
+	// The server answers with S0, S1 and S2; C2 echoes S1 back
+	s012 := make([]byte, 1+2*rtmpHandshakeSize)
+	if _, err := io.ReadFull(conn, s012); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("the server closed the connection during the RTMP handshake")
+		}
+		return err
+	}
+	if s012[0] != 3 {
+		return fmt.Errorf("not an RTMP server (handshake version %d)", s012[0])
+	}
+	_, err = conn.Write(s012[1 : 1+rtmpHandshakeSize])
+	return err
+}
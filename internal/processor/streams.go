@@ -0,0 +1,192 @@
+package processor
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/probe"
+	"video_processing/internal/style"
+)
+
+// resolveLanguageSelection probes the input and turns -audio-lang/-sub-lang into explicit
+// -map specifiers. Audio falls back to the first track when no language matches; subtitles
+// are left out instead.
+func (p *Processor) resolveLanguageSelection(cfg *config.ProcessingConfig) {
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		fmt.Printf("%s Could not probe input streams, using FFmpeg's default selection: %v\n", style.Warn, err)
+		return
+	}
+
+	maps := []string{"0:v:0?"}
+
+	audio := streamsOfType(result, "audio")
+	if len(audio) > 0 && !cfg.NoAudio {
+		index := 0
+		if cfg.AudioLang != "" {
+			if i := findLanguage(audio, cfg.AudioLang); i >= 0 {
+				index = i
+				fmt.Printf("%s Selected audio stream #%d (%s)\n", style.Audio, index, cfg.AudioLang)
+			} else {
+				fmt.Printf("%s No audio stream tagged %q, using the first audio stream\n", style.Warn, cfg.AudioLang)
+			}
+		}
+		maps = append(maps, fmt.Sprintf("0:a:%d", index))
+	}
+
+	if cfg.SubLang != "" {
+		subs := streamsOfType(result, "subtitle")
+		if i := findLanguage(subs, cfg.SubLang); i >= 0 {
+			maps = append(maps, fmt.Sprintf("0:s:%d", i))
+			cfg.SubtitleCodec = p.subtitleCodecFor(cfg.OutputPath, subs[i])
+			fmt.Printf("%s Selected subtitle stream #%d (%s, %s)\n", style.Subtitle, i, cfg.SubLang, subs[i].CodecName)
+		} else {
+			fmt.Printf("%s No subtitle stream tagged %q, subtitles will be omitted\n", style.Warn, cfg.SubLang)
+		}
+	}
+
+	cfg.Maps = maps
+}
+
+// selectDataStreams adds the input's data streams to the -map selection for -copy-data. FFmpeg
+// drops them by default, and mapping them disables its automatic selection, so the video and
+// audio it would have picked are mapped explicitly when nothing else selected streams.
+func (p *Processor) selectDataStreams(cfg *config.ProcessingConfig) {
+	if err := p.validator.ValidateDataStreams(p.commandBuilder.OutputFormat(cfg.OutputPath)); err != nil {
+		fmt.Printf("%s %v; data streams will be dropped\n", style.Warn, err)
+		cfg.CopyData = false
+		return
+	}
+
+	if result, err := p.prober.Probe(cfg.InputPath); err == nil {
+		data := streamsOfType(result, "data")
+		if len(data) == 0 {
+			fmt.Println(style.Info, "Input has no data streams to copy")
+			cfg.CopyData = false
+			return
+		}
+		var codecs []string
+		for _, s := range data {
+			codecs = append(codecs, cmp.Or(s.CodecName, "unknown"))
+		}
+		fmt.Printf("%s Copying %d data stream(s) (%s)\n", style.Stream, len(data), strings.Join(codecs, ", "))
+	}
+
+	if len(cfg.Maps) == 0 {
+		cfg.Maps = []string{"0:v:0?"}
+		if !cfg.NoAudio {
+			cfg.Maps = append(cfg.Maps, "0:a:0?")
+		}
+	}
+	cfg.Maps = append(cfg.Maps, "0:d?")
+}
+
+// prepareAudioFile checks the -audio-file input exists and picks an audio encoder when mixing,
+// since amix output can't be stream-copied. An input without audio has nothing to mix into,
+// so the file replaces the (missing) audio instead.
+func (p *Processor) prepareAudioFile(cfg *config.ProcessingConfig) error {
+	if err := p.validator.ValidateInput(cfg.AudioFile); err != nil {
+		return fmt.Errorf("audio file: %w", err)
+	}
+
+	if cfg.AudioMix {
+		if result, err := p.prober.Probe(cfg.InputPath); err == nil && len(streamsOfType(result, "audio")) == 0 {
+			fmt.Printf("%s The input has no audio to mix into; using %s as the audio instead\n", style.Warn, cfg.AudioFile)
+			cfg.AudioMix = false
+		}
+	}
+
+	if !cfg.AudioMix {
+		fmt.Printf("%s Replacing the input's audio with %s\n", style.Audio, cfg.AudioFile)
+		return nil
+	}
+	p.requireAudioEncoder(cfg)
+	fmt.Printf("%s Mixing %s into the input's audio (%s)\n", style.Audio, cfg.AudioFile, cfg.AudioCodec)
+	return nil
+}
+
+// requireAudioEncoder picks an audio encoder for options that filter the audio, which
+// stream copy can't carry: AAC, or Opus for WebM
+func (p *Processor) requireAudioEncoder(cfg *config.ProcessingConfig) {
+	if cfg.AudioCodec != "" && cfg.AudioCodec != "copy" {
+		return
+	}
+	cfg.AudioCodec = "aac"
+	if p.commandBuilder.OutputFormat(cfg.OutputPath) == "webm" {
+		cfg.AudioCodec = "libopus"
+	}
+}
+
+// prepareDownmix re-encodes the audio for -audio-channels or -audio-pan
+func (p *Processor) prepareDownmix(cfg *config.ProcessingConfig) {
+	p.requireAudioEncoder(cfg)
+	if cfg.AudioPan != "" {
+		fmt.Printf("%s Remixing audio with pan=%s (%s)\n", style.Audio, cfg.AudioPan, cfg.AudioCodec)
+		return
+	}
+	fmt.Printf("%s Mixing audio to %d channel(s) (%s)\n", style.Audio, cfg.AudioChannels, cfg.AudioCodec)
+}
+
+// subtitleCodecFor picks a subtitle encoder the output container can hold
+func (p *Processor) subtitleCodecFor(outputPath string, sub probe.Stream) string {
+	switch p.commandBuilder.OutputFormat(outputPath) {
+	case "mp4", "mov":
+		if isBitmapSubtitle(sub.CodecName) {
+			fmt.Printf("%s %s subtitles are image-based and cannot be stored in MP4/MOV\n", style.Warn, sub.CodecName)
+		}
+		return "mov_text"
+	case "webm":
+		return "webvtt"
+	default:
+		return "copy"
+	}
+}
+
+func isBitmapSubtitle(codec string) bool {
+	switch codec {
+	case "hdmv_pgs_subtitle", "dvd_subtitle", "dvb_subtitle", "xsub":
+		return true
+	}
+	return false
+}
+
+// streamsOfType returns the input's streams of one type, in the order FFmpeg's 0:a:N / 0:s:N specifiers count them
+func streamsOfType(result *probe.Result, codecType string) []probe.Stream {
+	var streams []probe.Stream
+	for _, s := range result.Streams {
+		if s.CodecType == codecType {
+			streams = append(streams, s)
+		}
+	}
+	return streams
+}
+
+// findLanguage returns the index of the first stream whose language tag matches, or -1
+func findLanguage(streams []probe.Stream, lang string) int {
+	for i, s := range streams {
+		if strings.EqualFold(s.Tags["language"], lang) {
+			return i
+		}
+	}
+	return -1
+}
+
+// prepareSilentAudio adds the -replace-audio-silence track once the probe confirms the input
+// has no audio; inputs with audio, or that can't be probed, are left as they are
+func (p *Processor) prepareSilentAudio(cfg *config.ProcessingConfig) {
+	cfg.AddSilence = false
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		fmt.Printf("%s Could not probe the input for audio, not adding a silent track: %v\n", style.Warn, err)
+		return
+	}
+	if len(streamsOfType(result, "audio")) > 0 {
+		return
+	}
+
+	cfg.AddSilence = true
+	p.requireAudioEncoder(cfg)
+	fmt.Printf("%s Input has no audio; adding a silent %s track\n", style.Audio, cfg.AudioCodec)
+}
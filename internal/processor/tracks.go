@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"video_processing/internal/config"
+	"video_processing/internal/probe"
+	"video_processing/internal/style"
+)
+
+// streamKindNames maps stream specifier kinds to ffprobe codec types
+var streamKindNames = map[string]string{"v": "video", "a": "audio", "s": "subtitle"}
+
+// resolveTrackTags prepares -stream-title and -default-track. Without an explicit selection
+// every audio and subtitle track is mapped, since FFmpeg's default keeps only one of each
+// and the tracks being tagged would otherwise be dropped. Track indices are checked against
+// the probed output layout, and each default track is expanded into dispositions that also
+// clear the flag on the other tracks of its kind, so players don't see two defaults.
+func (p *Processor) resolveTrackTags(cfg *config.ProcessingConfig) error {
+	result, err := p.prober.Probe(cfg.InputPath)
+	if err != nil {
+		fmt.Printf("%s Could not probe input streams, track numbers are not checked: %v\n", style.Warn, err)
+	}
+
+	if len(cfg.Maps) == 0 {
+		cfg.Maps = []string{"0:v:0?"}
+		if !cfg.NoAudio {
+			cfg.Maps = append(cfg.Maps, "0:a?")
+		}
+		cfg.Maps = append(cfg.Maps, "0:s?")
+		if result != nil && cfg.SubtitleCodec == "" {
+			if subs := streamsOfType(result, "subtitle"); len(subs) > 0 {
+				cfg.SubtitleCodec = p.subtitleCodecFor(cfg.OutputPath, subs[0])
+			}
+		}
+	}
+
+	var counts map[string]int
+	if result != nil {
+		counts = outputTrackCounts(cfg.Maps, result)
+		for _, spec := range trackSpecifiers(cfg) {
+			kind, index, _ := config.ParseStreamSpecifier(spec)
+			if index >= counts[kind] {
+				return fmt.Errorf("stream %s is out of range: the output has %d %s track(s)", spec, counts[kind], streamKindNames[kind])
+			}
+		}
+	}
+
+	cfg.Dispositions = nil
+	for _, track := range cfg.DefaultTracks {
+		kind, index, _ := config.ParseStreamSpecifier(track)
+		for i := range max(counts[kind], index+1) {
+			value := "0"
+			if i == index {
+				value = "default"
+			}
+			cfg.Dispositions = append(cfg.Dispositions, fmt.Sprintf("%s:%d=%s", kind, i, value))
+		}
+		fmt.Printf("%s Default %s track: %s\n", style.Subtitle, streamKindNames[kind], track)
+	}
+	for _, title := range cfg.StreamTitles {
+		spec, value, _ := strings.Cut(title, "=")
+		fmt.Printf("%s Track %s titled %q\n", style.Subtitle, spec, value)
+	}
+	return nil
+}
+
+// trackSpecifiers returns the stream specifiers named by -stream-title and -default-track
+func trackSpecifiers(cfg *config.ProcessingConfig) []string {
+	specs := append([]string{}, cfg.DefaultTracks...)
+	for _, title := range cfg.StreamTitles {
+		spec, _, _ := strings.Cut(title, "=")
+		specs = append(specs, strings.TrimSpace(spec))
+	}
+	return specs
+}
+
+// outputTrackCounts counts the output tracks of each kind the -map selection produces from
+// the probed input: "0:a?" selects every audio track, "0:a:1" a single one
+func outputTrackCounts(maps []string, result *probe.Result) map[string]int {
+	counts := map[string]int{}
+	for _, m := range maps {
+		parts := strings.Split(strings.TrimSuffix(m, "?"), ":")
+		if len(parts) < 2 || parts[0] != "0" {
+			continue
+		}
+		kind := parts[1]
+		available := len(streamsOfType(result, streamKindNames[kind]))
+		if len(parts) == 2 {
+			counts[kind] += available
+			continue
+		}
+		if i, err := strconv.Atoi(parts[2]); err == nil && i < available {
+			counts[kind]++
+		}
+	}
+	return counts
+}
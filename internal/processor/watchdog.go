@@ -0,0 +1,87 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"video_processing/internal/config"
+	"video_processing/internal/progress"
+	"video_processing/internal/style"
+)
+
+// stallWatchdog cancels an encode whose progress stops advancing, such as FFmpeg blocked on
+// a dead network input, which would otherwise wait forever
+type stallWatchdog struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	last    time.Time // when progress last advanced; zero until the first update when waiting for input
+	frame   int64
+	outTime float64
+	stalled bool
+}
+
+// newStallWatchdog creates a watchdog. With waitForInput, the time before the first progress
+// update doesn't count, for inputs that listen for a sender that may connect much later.
+func newStallWatchdog(timeout time.Duration, waitForInput bool) *stallWatchdog {
+	w := &stallWatchdog{timeout: timeout}
+	if !waitForInput {
+		w.last = time.Now()
+	}
+	return w
+}
+
+// observe records a progress update; only a new frame or a later output time counts as progress
+func (w *stallWatchdog) observe(u progress.Update) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.last.IsZero() || u.Frame > w.frame || u.OutTimeSeconds > w.outTime || u.Done {
+		w.frame, w.outTime = max(w.frame, u.Frame), max(w.outTime, u.OutTimeSeconds)
+		w.last = time.Now()
+	}
+}
+
+// run checks for a stall until ctx is done, calling cancel to stop FFmpeg once one is found
+func (w *stallWatchdog) run(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(max(w.timeout/4, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			idle := time.Since(w.last)
+			stalled := !w.last.IsZero() && idle >= w.timeout
+			w.stalled = stalled
+			w.mu.Unlock()
+
+			if stalled {
+				fmt.Printf("\n%s No encoding progress for %v; stopping FFmpeg\n", style.Error, idle.Round(time.Second))
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// listensForInput reports whether FFmpeg waits for a sender to connect to the input rather
+// than opening it, as an RTSP server or an RTMP/SRT/TCP listener does
+func listensForInput(cfg *config.ProcessingConfig) bool {
+	lower := strings.ToLower(cfg.InputPath)
+	return cfg.RTSPListen || strings.Contains(lower, "listen=1") || strings.Contains(lower, "mode=listener")
+}
+
+// err returns ErrEncodeStalled if the watchdog stopped the encode
+func (w *stallWatchdog) err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stalled {
+		return fmt.Errorf("%w: no progress for %v", ErrEncodeStalled, w.timeout)
+	}
+	return nil
+}
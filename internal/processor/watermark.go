@@ -0,0 +1,29 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+
+	"video_processing/internal/config"
+	"video_processing/internal/style"
+)
+
+// prepareWatermark checks the -watermark image exists and describes where it goes
+func (p *Processor) prepareWatermark(cfg *config.ProcessingConfig) error {
+	if _, err := os.Stat(cfg.Watermark); err != nil {
+		return fmt.Errorf("-watermark: %w", err)
+	}
+
+	size := "at its own size"
+	switch {
+	case cfg.WatermarkSize.Percent > 0:
+		size = fmt.Sprintf("at %g%% of the output width", cfg.WatermarkSize.Percent)
+	case cfg.WatermarkSize.Pixels > 0:
+		size = fmt.Sprintf("%dpx wide", cfg.WatermarkSize.Pixels)
+	}
+	fmt.Printf("%s Watermark %s %s, %s\n", style.Film, cfg.Watermark, cfg.WatermarkPosition, size)
+	if cfg.Acceleration == "cuda" {
+		fmt.Println(style.Info, "The overlay runs on the CPU, so decoded frames leave GPU memory")
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+// Package profile saves and loads named encode profiles: sets of command-line options kept
+// in the user's config directory (e.g. ~/.config/videoprocessing/profiles/web.yaml) and
+// applied with -profile web.
+package profile
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Extension is the file extension of saved profiles
+const Extension = ".yaml"
+
+// ErrNotFound is returned when a named profile doesn't exist
+var ErrNotFound = errors.New("profile not found")
+
+// validName restricts profile names to something safe to use as a file name on every platform
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// Setting is one option of a profile: a flag name without the leading dash and its value
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// DefaultDir returns the profile directory in the user's config directory
+func DefaultDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "videoprocessing", "profiles")
+}
+
+// ValidateName reports whether name can be used as a profile name
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use up to 64 letters, digits, '-' or '_', starting with a letter or digit", name)
+	}
+	return nil
+}
+
+// List returns the names of the profiles saved in dir, sorted
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), Extension)
+		if !ok || entry.IsDir() || ValidateName(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// Load reads the named profile from dir
+func Load(dir, name string) ([]Setting, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, name+Extension)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		available, _ := List(dir)
+		if len(available) == 0 {
+			return nil, fmt.Errorf("%w: %q (no profiles saved in %s yet; create one with -save-profile %s)", ErrNotFound, name, dir, name)
+		}
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrNotFound, name, strings.Join(available, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	settings, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// Save writes settings to dir as the named profile, replacing any profile of the same name
+func Save(dir, name string, settings []Setting) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	if len(settings) == 0 {
+		return "", errors.New("no options to save; give the options the profile should set, e.g. -save-profile web -quality-level 70")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# video_processing profile %q, saved %s\n", name, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "# Each key is a command-line option; options given on the command line take precedence.\n")
+	for _, s := range settings {
+		fmt.Fprintf(&b, "%s: %s\n", s.Name, strconv.Quote(s.Value))
+	}
+
+	path := filepath.Join(dir, name+Extension)
+	return path, os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// Parse reads a profile: a flat YAML mapping of option names to values, one per line.
+// Values may be bare or single- or double-quoted; lines starting with # are comments.
+func Parse(r io.Reader) ([]Setting, error) {
+	var settings []Setting
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		setting, ok, err := parseLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if ok {
+			settings = append(settings, setting)
+		}
+	}
+	return settings, scanner.Err()
+}
+
+// parseLine parses one "name: value" line, reporting false for blank and comment lines
+func parseLine(line string) (Setting, bool, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+		return Setting{}, false, nil
+	}
+
+	name, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return Setting{}, false, fmt.Errorf("expected \"option: value\", got %q", line)
+	}
+	name = strings.TrimPrefix(strings.TrimSpace(name), "-")
+	if name == "" {
+		return Setting{}, false, fmt.Errorf("missing option name in %q", line)
+	}
+
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return Setting{}, false, fmt.Errorf("option %s: invalid quoted value %s", name, value)
+		}
+		value = unquoted
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return Setting{}, false, fmt.Errorf("option %s: unterminated quoted value %s", name, value)
+		}
+		value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	default:
+		// Bare values end at a trailing comment
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+	}
+	return Setting{Name: name, Value: value}, true, nil
+}
+
+// Tracker records the values given to a set of flags so they can be saved as a profile.
+// Flag values can't be read back in general (flag.Func reports ""), so the raw strings
+// passed to Set are kept instead.
+type Tracker struct {
+	fs       *flag.FlagSet
+	settings []Setting
+}
+
+// Track starts recording the flags currently registered on fs; flags registered later,
+// such as -profile itself, aren't part of a profile
+func Track(fs *flag.FlagSet) *Tracker {
+	t := &Tracker{fs: fs}
+	fs.VisitAll(func(f *flag.Flag) {
+		f.Value = &recordingValue{Value: f.Value, record: func(s string) {
+			// Every value is kept so repeatable options such as -metadata survive a save
+			t.settings = append(t.settings, Setting{Name: f.Name, Value: s})
+		}}
+	})
+	return t
+}
+
+// Settings returns the tracked flags that have been set, in the order they were set
+func (t *Tracker) Settings() []Setting {
+	return slices.Clone(t.settings)
+}
+
+// Apply sets the profile's options that weren't already given, so the command line
+// overrides the profile
+func (t *Tracker) Apply(settings []Setting) error {
+	given := map[string]bool{}
+	for _, s := range t.settings {
+		given[s.Name] = true
+	}
+
+	for _, s := range settings {
+		if given[s.Name] {
+			continue
+		}
+		if f := t.fs.Lookup(s.Name); f == nil || !t.tracks(f) {
+			return fmt.Errorf("unknown option -%s", s.Name)
+		}
+		if err := t.fs.Set(s.Name, s.Value); err != nil {
+			return fmt.Errorf("invalid value %q for -%s: %w", s.Value, s.Name, err)
+		}
+	}
+	return nil
+}
+
+// tracks reports whether f is one of the recorded flags
+func (t *Tracker) tracks(f *flag.Flag) bool {
+	_, ok := f.Value.(*recordingValue)
+	return ok
+}
+
+// recordingValue wraps a flag value to record what it was set to
+type recordingValue struct {
+	flag.Value
+	record func(string)
+}
+
+func (v *recordingValue) Set(s string) error {
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+	v.record(s)
+	return nil
+}
+
+// IsBoolFlag keeps boolean flags usable without a value
+func (v *recordingValue) IsBoolFlag() bool {
+	b, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
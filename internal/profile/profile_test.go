@@ -0,0 +1,121 @@
+package profile
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"web", true},
+		{"archive_4k-hevc", true},
+		{"", false},
+		{"-web", false},
+		{"../web", false},
+		{"web.yaml", false},
+		{"my profile", false},
+		{strings.Repeat("a", 65), false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateName(tt.name); (err == nil) != tt.valid {
+			t.Errorf("ValidateName(%q) = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	input := `# web profile
+---
+quality-level: 70
+-maxrate: "6M"  
+metadata: 'comment=it''s fine'
+metadata: "title=a \"b\""
+preset: fast # trailing comment
+
+`
+	want := []Setting{
+		{"quality-level", "70"},
+		{"maxrate", "6M"},
+		{"metadata", "comment=it's fine"},
+		{"metadata", `title=a "b"`},
+		{"preset", "fast"},
+	}
+
+	got, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"quality-level 70", ": 70", `maxrate: "6M`, "maxrate: '6M"} {
+		if _, err := Parse(strings.NewReader(bad)); err == nil {
+			t.Errorf("Parse(%q) expected an error", bad)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	settings := []Setting{{"quality-level", "70"}, {"metadata", `title=a "b"`}}
+
+	if _, err := Save(dir, "web", settings); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Load(dir, "web")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, settings) {
+		t.Errorf("Load() = %v, want %v", got, settings)
+	}
+
+	if names, _ := List(dir); !reflect.DeepEqual(names, []string{"web"}) {
+		t.Errorf("List() = %v, want [web]", names)
+	}
+	if _, err := Load(dir, "archive"); !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "available: web") {
+		t.Errorf("Load(missing) error = %v, want ErrNotFound listing web", err)
+	}
+	if _, err := Save(dir, "empty", nil); err == nil {
+		t.Error("Save() with no options expected an error")
+	}
+}
+
+func TestTrackerApply(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	quality := fs.Int("quality-level", 0, "")
+	preset := fs.String("preset", "", "")
+	hq := fs.Bool("hq", false, "")
+	tracker := Track(fs)
+	fs.String("profile", "", "")
+
+	if err := fs.Parse([]string{"-quality-level", "50", "-hq"}); err != nil {
+		t.Fatal(err)
+	}
+	err := tracker.Apply([]Setting{{"quality-level", "70"}, {"preset", "fast"}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if *quality != 50 || *preset != "fast" || !*hq {
+		t.Errorf("got quality %d preset %q hq %v, want the command line to override the profile", *quality, *preset, *hq)
+	}
+
+	want := []Setting{{"quality-level", "50"}, {"hq", "true"}, {"preset", "fast"}}
+	if got := tracker.Settings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Settings() = %v, want %v", got, want)
+	}
+
+	for _, bad := range []Setting{{"profile", "web"}, {"nope", "1"}, {"preset-x", ""}} {
+		if err := tracker.Apply([]Setting{bad}); err == nil {
+			t.Errorf("Apply(%v) expected an error", bad)
+		}
+	}
+}
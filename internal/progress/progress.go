@@ -0,0 +1,85 @@
+package progress
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Args makes FFmpeg write key=value progress blocks to stdout instead of the stats line
+var Args = []string{"-progress", "pipe:1", "-nostats"}
+
+// Update is one progress report from FFmpeg's -progress output
+type Update struct {
+	Frame     int64   `json:"frame"`
+	FPS       float64 `json:"fps"`
+	Bitrate   string  `json:"bitrate"`
+	TotalSize int64   `json:"total_size"`
+	OutTime   string  `json:"out_time"`
+	// OutTimeSeconds is the position reached in the output
+	OutTimeSeconds float64 `json:"out_time_seconds"`
+	Speed          string  `json:"speed"`
+	// Percent is only set when the total duration is known
+	Percent float64 `json:"percent,omitempty"`
+	Done    bool    `json:"done"`
+}
+
+// BitsPerSecond parses the average output bitrate (e.g. "4500.2kbits/s"), returning 0 when
+// FFmpeg reports N/A
+func (u Update) BitsPerSecond() float64 {
+	kbits, ok := strings.CutSuffix(u.Bitrate, "kbits/s")
+	if !ok {
+		return 0
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(kbits), 64)
+	if err != nil {
+		return 0
+	}
+	return v * 1e3
+}
+
+// Parse reads FFmpeg -progress output and calls fn once per completed block.
+// totalSeconds is the expected output duration, used to compute Percent; 0 if unknown.
+func Parse(r io.Reader, totalSeconds float64, fn func(Update)) error {
+	var u Update
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "frame":
+			u.Frame, _ = strconv.ParseInt(value, 10, 64)
+		case "fps":
+			u.FPS, _ = strconv.ParseFloat(value, 64)
+		case "bitrate":
+			u.Bitrate = value
+		case "total_size":
+			u.TotalSize, _ = strconv.ParseInt(value, 10, 64)
+		case "out_time_us", "out_time_ms":
+			// Both keys are in microseconds (out_time_ms is a historical misnomer)
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+				u.OutTimeSeconds = float64(us) / 1e6
+			}
+		case "out_time":
+			u.OutTime = value
+		case "speed":
+			u.Speed = value
+		case "progress":
+			u.Done = value == "end"
+			if totalSeconds > 0 {
+				u.Percent = min(100, u.OutTimeSeconds/totalSeconds*100)
+				if u.Done {
+					u.Percent = 100
+				}
+			}
+			fn(u)
+			u = Update{}
+		}
+	}
+	return scanner.Err()
+}
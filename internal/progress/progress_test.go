@@ -0,0 +1,72 @@
+package progress
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := `frame=120
+fps=59.94
+bitrate=4500.2kbits/s
+total_size=2621440
+out_time_us=5000000
+out_time=00:00:05.000000
+speed=2.01x
+progress=continue
+frame=240
+fps=60.00
+bitrate=4400.0kbits/s
+total_size=5242880
+out_time_us=10000000
+out_time=00:00:10.000000
+speed=2.00x
+progress=end
+`
+	var updates []Update
+	if err := Parse(strings.NewReader(input), 10, func(u Update) { updates = append(updates, u) }); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(updates) != 2 {
+		t.Fatalf("got %d updates, want 2", len(updates))
+	}
+
+	first := updates[0]
+	if first.Frame != 120 || first.TotalSize != 2621440 || first.Percent != 50 || first.Done {
+		t.Errorf("first update = %+v", first)
+	}
+	if first.OutTime != "00:00:05.000000" || first.Speed != "2.01x" || first.Bitrate != "4500.2kbits/s" {
+		t.Errorf("first update strings = %+v", first)
+	}
+
+	last := updates[1]
+	if !last.Done || last.Percent != 100 || last.OutTimeSeconds != 10 {
+		t.Errorf("last update = %+v", last)
+	}
+}
+
+func TestParseUnknownDuration(t *testing.T) {
+	input := "out_time_us=5000000\nprogress=continue\n"
+	var got Update
+	if err := Parse(strings.NewReader(input), 0, func(u Update) { got = u }); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got.Percent != 0 {
+		t.Errorf("Percent = %v, want 0 when duration unknown", got.Percent)
+	}
+}
+
+func TestBitsPerSecond(t *testing.T) {
+	tests := map[string]float64{
+		"4500.2kbits/s": 4500200,
+		" 812.0kbits/s": 812000,
+		"N/A":           0,
+		"":              0,
+	}
+	for bitrate, want := range tests {
+		if got := (Update{Bitrate: bitrate}).BitsPerSecond(); got != want {
+			t.Errorf("BitsPerSecond(%q) = %v, want %v", bitrate, got, want)
+		}
+	}
+}
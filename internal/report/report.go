@@ -0,0 +1,81 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"video_processing/internal/encoder"
+)
+
+// Job statuses
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// What happened to a failed encode's partial output
+const (
+	PartialKept    = "kept"
+	PartialRemoved = "removed"
+)
+
+// Job is the outcome of one encode
+type Job struct {
+	Input           string    `json:"input"`
+	Output          string    `json:"output"`
+	Status          string    `json:"status"`
+	Codec           string    `json:"codec"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	OutputBytes     int64     `json:"output_bytes,omitempty"`
+	FPS             float64   `json:"fps,omitempty"`
+	VideoBitrate    int64     `json:"video_bitrate,omitempty"`
+	AudioBitrate    int64     `json:"audio_bitrate,omitempty"`
+	OverBudget      bool      `json:"over_budget,omitempty"`
+	Fallback        string    `json:"fallback,omitempty"`
+	EncoderBusy     string    `json:"encoder_busy,omitempty"`
+	Device          string    `json:"device,omitempty"`
+	DeviceMismatch  bool      `json:"device_mismatch,omitempty"`
+	PartialOutput   string    `json:"partial_output,omitempty"`
+	Error           *JobError `json:"error,omitempty"`
+}
+
+// JobError describes why a job failed, including FFmpeg's exit code and stderr when available
+type JobError struct {
+	Message  string `json:"message"`
+	ExitCode int    `json:"exit_code,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+}
+
+// NewJobError builds a JobError, pulling FFmpeg details out of a wrapped EncodeError. The
+// credentials of urls, the job's input and output, are hidden wherever the message or
+// FFmpeg's stderr repeats them.
+func NewJobError(err error, urls ...string) *JobError {
+	redact := func(text string) string {
+		for _, u := range urls {
+			if strings.Contains(u, "://") {
+				text = encoder.RedactStreamDiagnostics(text, u)
+			}
+		}
+		return text
+	}
+
+	jobErr := &JobError{Message: redact(err.Error())}
+	var encodeErr *encoder.EncodeError
+	if errors.As(err, &encodeErr) {
+		jobErr.ExitCode = encodeErr.ExitCode
+		jobErr.Stderr = redact(encodeErr.Stderr)
+	}
+	return jobErr
+}
+
+// Write saves the jobs to path as an indented JSON array
+func Write(path string, jobs []Job) error {
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
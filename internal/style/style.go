@@ -0,0 +1,101 @@
+package style
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Symbol is a line marker shown as an emoji, or as plain ASCII on terminals that can't render emoji
+type Symbol struct {
+	emoji string
+	ascii string
+}
+
+// ascii selects the plain markers; set once at startup via SetASCII
+var ascii bool
+
+// SetASCII switches all symbols to their plain ASCII markers
+func SetASCII(enabled bool) {
+	ascii = enabled
+}
+
+// String returns the marker for the current mode. Emoji drawn narrower than two cells
+// (the ones with a variation selector) carry an extra space so the text lines up.
+func (s Symbol) String() string {
+	if ascii {
+		return s.ascii
+	}
+	return s.emoji
+}
+
+// DetectASCII reports whether the environment suggests emoji won't render: NO_EMOJI is set,
+// the terminal is dumb, output goes to a CI log, or the locale isn't UTF-8
+func DetectASCII() bool {
+	if os.Getenv("NO_EMOJI") != "" || os.Getenv("TERM") == "dumb" || os.Getenv("CI") != "" {
+		return true
+	}
+
+	if runtime.GOOS == "windows" {
+		// The legacy console host can't draw emoji; Windows Terminal and VS Code can
+		return os.Getenv("WT_SESSION") == "" && os.Getenv("TERM_PROGRAM") == ""
+	}
+
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			upper := strings.ToUpper(locale)
+			return !strings.Contains(upper, "UTF-8") && !strings.Contains(upper, "UTF8")
+		}
+	}
+	return false
+}
+
+// Status markers
+var (
+	OK    = Symbol{"✅", "[OK]"}
+	Error = Symbol{"❌", "[ERROR]"}
+	Warn  = Symbol{"⚠️ ", "[WARN]"}
+	Info  = Symbol{"ℹ️ ", "[INFO]"}
+	Retry = Symbol{"🔁", "[RETRY]"}
+	Skip  = Symbol{"⏭️ ", "[SKIP]"}
+)
+
+// Decorative markers for headings and details
+var (
+	Video    = Symbol{"🎬", "==>"}
+	Play     = Symbol{"🎥", "==>"}
+	Film     = Symbol{"🎞️ ", "*"}
+	Camera   = Symbol{"📷", "*"}
+	Record   = Symbol{"🔴", "[REC]"}
+	Search   = Symbol{"🔍", "*"}
+	Inspect  = Symbol{"🔎", "*"}
+	Target   = Symbol{"🎯", "*"}
+	Rocket   = Symbol{"🚀", "*"}
+	Stats    = Symbol{"📊", "*"}
+	Chart    = Symbol{"📈", "*"}
+	Down     = Symbol{"📉", "*"}
+	Folder   = Symbol{"📁", "*"}
+	Save     = Symbol{"💾", "*"}
+	Archive  = Symbol{"🗄️ ", "*"}
+	Trash    = Symbol{"🗑️ ", "*"}
+	Package  = Symbol{"📦", "*"}
+	Receipt  = Symbol{"🧾", "*"}
+	Note     = Symbol{"📝", "*"}
+	List     = Symbol{"📋", "*"}
+	Timer    = Symbol{"⏱️ ", "*"}
+	Clock    = Symbol{"🕒", "*"}
+	Stream   = Symbol{"📡", "*"}
+	Switch   = Symbol{"🔄", "*"}
+	Replace  = Symbol{"♻️ ", "*"}
+	Audio    = Symbol{"🔊", "*"}
+	Subtitle = Symbol{"💬", "*"}
+	Ruler    = Symbol{"📐", "*"}
+	Slider   = Symbol{"🎚️ ", "*"}
+	Test     = Symbol{"🧪", "*"}
+	Puzzle   = Symbol{"🧩", "*"}
+	Link     = Symbol{"🔗", "*"}
+	Bell     = Symbol{"🔔", "*"}
+	Tool     = Symbol{"🔧", "*"}
+	Controls = Symbol{"🎮", "*"}
+	Run      = Symbol{"▶️ ", ">"}
+)
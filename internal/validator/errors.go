@@ -0,0 +1,15 @@
+package validator
+
+import "errors"
+
+var (
+	// ErrFFmpegNotFound is returned when no ffmpeg binary can be located
+	ErrFFmpegNotFound = errors.New("ffmpeg not found in PATH")
+
+	// ErrHWAccelUnavailable is returned when the local FFmpeg build lacks the selected
+	// hardware acceleration method
+	ErrHWAccelUnavailable = errors.New("hardware acceleration method not supported by FFmpeg")
+
+	// ErrInputNotFound is returned when a local input file does not exist
+	ErrInputNotFound = errors.New("input file not found")
+)
@@ -4,36 +4,265 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 
+	"video_processing/internal/capture"
 	"video_processing/internal/config"
+	"video_processing/internal/style"
+	"video_processing/utils"
 )
 
 // Validator handles system validation
-type Validator struct{}
+type Validator struct {
+	ffmpegPath string
+}
 
-// New creates a new validator instance
-func New() *Validator {
-	return &Validator{}
+// New creates a new validator instance that checks the given ffmpeg binary
+func New(ffmpegPath string) *Validator {
+	return &Validator{
+		ffmpegPath: ffmpegPath,
+	}
 }
 
 // ValidateSetup validates the system setup for video processing
 func (v *Validator) ValidateSetup(config *config.ProcessingConfig) error {
 	// Check FFmpeg availability
-	if _, err := exec.LookPath("ffmpeg"); err != nil {
-		return fmt.Errorf("ffmpeg not found in PATH. Please install FFmpeg")
+	if _, err := exec.LookPath(v.ffmpegPath); err != nil {
+		if alt := v.findAlternativeFFmpeg(); alt != "" {
+			return fmt.Errorf("%w (%s). Found %s; pass it with -ffmpeg-path or FFMPEG_PATH", ErrFFmpegNotFound, v.ffmpegPath, alt)
+		}
+		return fmt.Errorf("%w (%s). Please install FFmpeg or set -ffmpeg-path/FFMPEG_PATH", ErrFFmpegNotFound, v.ffmpegPath)
+	}
+
+	version, err := v.FFmpegVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Using FFmpeg %s (%s)\n", style.OK, version, v.ffmpegPath)
+
+	// ffprobe is optional: probe-based features fall back to their defaults without it
+	if _, err := exec.LookPath(config.FFprobePath()); err != nil {
+		fmt.Printf("%s ffprobe not found (%s); progress percentages, stream checks and auto codec selection will be skipped\n", style.Warn, config.FFprobePath())
+	}
+
+	if utils.IsWSL() {
+		if err := validateWSLAcceleration(config.Acceleration); err != nil {
+			return err
+		}
+	}
+
+	// AMF on Linux isn't an FFmpeg hwaccel, so it is checked on its own below
+	if config.Acceleration != "" && config.Acceleration != "none" && config.Acceleration != "amf" {
+		if err := v.validateHWAccel(config.Acceleration); err != nil {
+			return err
+		}
+	}
+
+	if config.Tonemap {
+		if err := v.validateFilter("zscale"); err != nil {
+			return err
+		}
 	}
 
 	// VAAPI-specific checks
 	if config.Acceleration == "vaapi" {
 		return v.validateVAAPISetup()
 	}
+	if config.Acceleration == "amf" {
+		return v.validateAMFSetup(config.Codec)
+	}
 
 	return nil
 }
 
+// validateHWAccel checks that the local FFmpeg build lists the acceleration method in -hwaccels
+func (v *Validator) validateHWAccel(name string) error {
+	out, err := exec.Command(v.ffmpegPath, "-hide_banner", "-hwaccels").Output()
+	if err != nil {
+		return fmt.Errorf("could not list FFmpeg hardware accelerations: %w", err)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) == name {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrHWAccelUnavailable, name)
+}
+
+// validateFilter checks that the local FFmpeg build provides the named filter
+func (v *Validator) validateFilter(name string) error {
+	out, err := exec.Command(v.ffmpegPath, "-hide_banner", "-filters").Output()
+	if err != nil {
+		return fmt.Errorf("could not list FFmpeg filters: %w", err)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[1] == name {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("FFmpeg filter %q is not available; rebuild FFmpeg with the required library", name)
+}
+
+// alternativeFFmpegLocations are common install paths of FFmpeg builds and forks that aren't on PATH
+var alternativeFFmpegLocations = []string{
+	"/usr/lib/jellyfin-ffmpeg/ffmpeg",
+	"/usr/share/jellyfin-ffmpeg/ffmpeg",
+	"/opt/ffmpeg/bin/ffmpeg",
+	"/usr/local/bin/ffmpeg",
+	"/opt/homebrew/bin/ffmpeg",
+}
+
+// findAlternativeFFmpeg looks for an FFmpeg binary outside PATH. libav's avconv is reported
+// but not used, as its options differ from FFmpeg's.
+func (v *Validator) findAlternativeFFmpeg() string {
+	for _, path := range alternativeFFmpegLocations {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	if _, err := exec.LookPath("avconv"); err == nil {
+		fmt.Println(style.Warn, "Found libav's avconv, which is not option-compatible with FFmpeg and is not supported")
+	}
+	return ""
+}
+
+// FFmpegVersion returns the version string reported by `ffmpeg -version`
+func (v *Validator) FFmpegVersion() (string, error) {
+	out, err := exec.Command(v.ffmpegPath, "-version").Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrFFmpegNotFound, err)
+	}
+
+	// First line looks like: "ffmpeg version 6.1.1-3ubuntu5 Copyright (c) 2000-2023 ..."
+	firstLine, _, _ := strings.Cut(string(out), "\n")
+	fields := strings.Fields(firstLine)
+	if len(fields) < 3 || fields[1] != "version" {
+		return "", fmt.Errorf("unrecognised ffmpeg -version output: %q", firstLine)
+	}
+	return fields[2], nil
+}
+
+// FFmpegMajorVersion returns the major version of the FFmpeg release, or 0 for git builds
+// ("N-112345-g...") whose version string carries no release number
+func (v *Validator) FFmpegMajorVersion() (int, error) {
+	version, err := v.FFmpegVersion()
+	if err != nil {
+		return 0, err
+	}
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "n"), ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, nil
+	}
+	return n, nil
+}
+
+// Muxers returns the names of the output formats the local FFmpeg can write, from `ffmpeg -formats`
+func (v *Validator) Muxers() (map[string]bool, error) {
+	out, err := exec.Command(v.ffmpegPath, "-hide_banner", "-formats").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFFmpegNotFound, err)
+	}
+
+	// Lines look like: " DE matroska,webm   Matroska / WebM" after a "--" separator
+	muxers := make(map[string]bool)
+	listing := false
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) == "--" {
+			listing = true
+			continue
+		}
+		fields := strings.Fields(line)
+		if !listing || len(fields) < 2 || !strings.Contains(fields[0], "E") {
+			continue
+		}
+		for _, name := range strings.Split(fields[1], ",") {
+			muxers[name] = true
+		}
+	}
+	return muxers, nil
+}
+
+// Encoders returns the names of the encoders the local FFmpeg provides, from `ffmpeg -encoders`
+func (v *Validator) Encoders() (map[string]bool, error) {
+	out, err := exec.Command(v.ffmpegPath, "-hide_banner", "-encoders").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFFmpegNotFound, err)
+	}
+
+	// Lines look like: " V....D libx264   libx264 H.264 ..." after a " ------" separator
+	encoders := make(map[string]bool)
+	listing := false
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if strings.HasPrefix(fields[0], "---") {
+			listing = true
+			continue
+		}
+		if listing {
+			encoders[fields[1]] = true
+		}
+	}
+	return encoders, nil
+}
+
+// ValidateInput checks that a local input file or capture device exists. URLs are not checked.
+func (v *Validator) ValidateInput(inputPath string) error {
+	if strings.Contains(inputPath, "://") {
+		return nil
+	}
+	if capture.IsDevice(inputPath) {
+		device, err := capture.Parse(inputPath)
+		if err != nil {
+			return err
+		}
+		return device.Check()
+	}
+
+	if _, err := os.Stat(inputPath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrInputNotFound, inputPath)
+		}
+		return fmt.Errorf("cannot access input %s: %w", inputPath, err)
+	}
+
+	return nil
+}
+
+// ValidateOutputDir checks that the directory an output file will be written to exists
+func (v *Validator) ValidateOutputDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("output directory %s does not exist", dir)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("output directory %s is not a directory", dir)
+	}
+	return nil
+}
+
+// validateWSLAcceleration rejects acceleration methods that can't work under WSL, before the
+// render node and -hwaccels checks produce less helpful errors
+func validateWSLAcceleration(acceleration string) error {
+	switch acceleration {
+	case "vaapi", "amf", "d3d11va", "dxva2", "d3d12va":
+		return fmt.Errorf("%s is not available under WSL; use -hwaccel cuda with an NVIDIA GPU or -hwaccel none", acceleration)
+	}
+	return nil
+}
+
 func (v *Validator) validateVAAPISetup() error {
-	fmt.Println("🔧 Validating VAAPI setup...")
+	fmt.Println(style.Tool, "Validating VAAPI setup...")
 
 	// Check render nodes
 	renderNodes := []string{"/dev/dri/renderD128", "/dev/dri/renderD129"}
@@ -41,14 +270,14 @@ func (v *Validator) validateVAAPISetup() error {
 
 	for _, node := range renderNodes {
 		if _, err := os.Stat(node); err == nil {
-			fmt.Printf("✅ Found render node: %s\n", node)
+			fmt.Printf("%s Found render node: %s\n", style.OK, node)
 			foundNode = true
 			break
 		}
 	}
 
 	if !foundNode {
-		fmt.Println("⚠️  No render nodes found. VAAPI may not work properly.")
+		fmt.Println(style.Warn, "No render nodes found. VAAPI may not work properly.")
 		fmt.Println("   Install drivers: sudo apt install mesa-va-drivers intel-media-va-driver")
 		fmt.Println("   Add to video group: sudo usermod -a -G video $USER")
 	}
@@ -59,12 +288,133 @@ func (v *Validator) validateVAAPISetup() error {
 		if out, err := cmd.Output(); err == nil {
 			output := string(out)
 			if strings.Contains(strings.ToLower(output), "h264") {
-				fmt.Println("✅ VAAPI H.264 encoding support detected")
+				fmt.Println(style.OK, "VAAPI H.264 encoding support detected")
 			} else {
-				fmt.Println("⚠️  H.264 encoding may not be available")
+				fmt.Println(style.Warn, "H.264 encoding may not be available")
 			}
 		}
 	}
 
 	return nil
 }
+
+// amfRuntimeLibrary is AMD's AMF runtime, which FFmpeg's AMF encoders load on Linux
+const amfRuntimeLibrary = "libamfrt64.so.1"
+
+// amfLibraryDirs are where the AMF runtime is installed by AMD's amdgpu-pro and AMF
+// packages and by distributions that repackage it
+var amfLibraryDirs = []string{
+	"/opt/amdgpu-pro/lib64",
+	"/opt/amdgpu-pro/lib/x86_64-linux-gnu",
+	"/usr/lib/x86_64-linux-gnu",
+	"/usr/lib64",
+	"/usr/lib",
+}
+
+// AMFRuntime returns the path of the AMF runtime library, searching $LD_LIBRARY_PATH first,
+// or "" when it isn't installed
+func AMFRuntime() string {
+	dirs := append(filepath.SplitList(os.Getenv("LD_LIBRARY_PATH")), amfLibraryDirs...)
+	for _, dir := range dirs {
+		path := filepath.Join(dir, amfRuntimeLibrary)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// ValidateAMF checks that an AMF encode can run on Linux: FFmpeg must be built with the
+// encoder and AMD's AMF runtime must be installed
+func (v *Validator) ValidateAMF(codec string) error {
+	encoders, err := v.Encoders()
+	if err != nil {
+		return err
+	}
+	if !encoders[codec] {
+		return fmt.Errorf("this FFmpeg build has no %s encoder (it needs --enable-amf)", codec)
+	}
+	if AMFRuntime() == "" {
+		return fmt.Errorf("AMD's AMF runtime (%s) is not installed; it ships with the amdgpu-pro driver's AMF component", amfRuntimeLibrary)
+	}
+	return nil
+}
+
+func (v *Validator) validateAMFSetup(codec string) error {
+	fmt.Println(style.Tool, "Validating AMF setup...")
+	if err := v.ValidateAMF(codec); err != nil {
+		return err
+	}
+	fmt.Printf("%s AMF runtime found: %s\n", style.OK, AMFRuntime())
+	return nil
+}
+
+// streamCopyCodecs lists the video codecs each muxer accepts without re-encoding.
+// Muxers not listed (e.g. matroska, avi) accept practically any codec.
+var streamCopyCodecs = map[string][]string{
+	"mp4":    {"h264", "hevc", "av1", "vp9", "mpeg4", "mpeg2video", "mjpeg"},
+	"mov":    {"h264", "hevc", "prores", "mpeg4", "mpeg2video", "mjpeg", "av1"},
+	"webm":   {"vp8", "vp9", "av1"},
+	"flv":    {"h264", "flv1"},
+	"mpegts": {"h264", "hevc", "mpeg2video", "mpeg1video", "av1"},
+	"hls":    {"h264", "hevc"},
+	"dash":   {"h264", "hevc", "vp9", "av1"},
+	"rtsp":   {"h264", "hevc", "mpeg4", "mjpeg"},
+}
+
+// ValidateStreamCopy checks that the source video codec can be copied into the target muxer
+func (v *Validator) ValidateStreamCopy(format, videoCodec string) error {
+	allowed, ok := streamCopyCodecs[format]
+	if !ok {
+		return nil
+	}
+
+	for _, codec := range allowed {
+		if codec == videoCodec {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("video codec %s cannot be copied into %s output (supported: %s)",
+		videoCodec, format, strings.Join(allowed, ", "))
+}
+
+// chapterFormats lists the muxers that can store chapter markers
+var chapterFormats = []string{"mp4", "mov", "matroska", "webm", "ogg"}
+
+// ValidateChapters checks that the target muxer can store chapters
+func (v *Validator) ValidateChapters(format string) error {
+	for _, f := range chapterFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s output does not support chapters; chapter markers will be dropped", format)
+}
+
+// losslessFormats lists the file muxers lossless masters can be written to; streaming
+// protocols and segmenters are left out since no player or server expects lossless video
+var losslessFormats = []string{"matroska", "mp4", "mov", "mpegts", "avi", "webm"}
+
+// ValidateLossless checks that the target muxer suits a lossless encode
+func (v *Validator) ValidateLossless(format string) error {
+	for _, f := range losslessFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s output is not suitable for lossless video (supported: %s)", format, strings.Join(losslessFormats, ", "))
+}
+
+// dataFormats lists the muxers that can carry data streams such as KLV or timed ID3 metadata
+var dataFormats = []string{"mpegts"}
+
+// ValidateDataStreams checks that the target muxer can store copied data streams
+func (v *Validator) ValidateDataStreams(format string) error {
+	for _, f := range dataFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s output cannot carry data streams (supported: %s)", format, strings.Join(dataFormats, ", "))
+}
@@ -0,0 +1,91 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, injected at build time with:
+//
+//	go build -ldflags "-X video_processing/internal/version.Version=v1.2.0 \
+//	  -X video_processing/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X video_processing/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info describes the running build and the FFmpeg it will use
+type Info struct {
+	Version        string `json:"version"`
+	Commit         string `json:"commit"`
+	BuildDate      string `json:"build_date"`
+	GoVersion      string `json:"go_version"`
+	Platform       string `json:"platform"`
+	FFmpegVersion  string `json:"ffmpeg_version,omitempty"`
+	FFprobeVersion string `json:"ffprobe_version,omitempty"`
+}
+
+// Get returns the build info, falling back to VCS data embedded by the Go toolchain
+// when the ldflags variables were not set
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+// Print writes the info either as human-readable text or as JSON
+func (i Info) Print(w io.Writer, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(i)
+	}
+
+	fmt.Fprintf(w, "video_processing %s\n", i.Version)
+	fmt.Fprintf(w, "  commit:     %s\n", i.Commit)
+	fmt.Fprintf(w, "  built:      %s\n", i.BuildDate)
+	fmt.Fprintf(w, "  go:         %s (%s)\n", i.GoVersion, i.Platform)
+	ffmpeg := i.FFmpegVersion
+	if ffmpeg == "" {
+		ffmpeg = "not found"
+	}
+	fmt.Fprintf(w, "  ffmpeg:     %s\n", ffmpeg)
+	ffprobe := i.FFprobeVersion
+	if ffprobe == "" {
+		ffprobe = "not found (probe-based features disabled)"
+	}
+	fmt.Fprintf(w, "  ffprobe:    %s\n", ffprobe)
+	return nil
+}
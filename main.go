@@ -1,17 +1,147 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
+	"video_processing/internal/config"
 	"video_processing/internal/processor"
+	"video_processing/internal/profile"
+	"video_processing/internal/style"
 )
 
 func main() {
-	proc := processor.New()
+	cfg := config.NewDefault()
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	cfg.RegisterFlags(flag.CommandLine)
+	settings := profile.Track(flag.CommandLine)
+	profileName := flag.String("profile", "", "apply the named encode profile; options given on the command line override it")
+	saveProfile := flag.String("save-profile", "", "save the options given on the command line as a named profile and exit")
+	listProfiles := flag.Bool("list-profiles", false, "list the saved encode profiles and exit")
+	detectJSON := flag.Bool("detect-json", false, "print detected GPUs and encoder capabilities as JSON and exit")
+	showVersion := flag.Bool("version", false, "print version and build information and exit")
+	asJSON := flag.Bool("json", false, "print command output (e.g. -version) as JSON")
+	estimate := flag.Bool("estimate", false, "predict output size and encode time without encoding")
+	selfTest := flag.Bool("selftest", false, "encode a synthetic clip end-to-end and report pass/fail")
+	checkDeps := flag.Bool("check-deps", false, "check for ffmpeg and the optional tools (ffprobe, players, GPU utilities) and exit")
+	listFormats := flag.Bool("list-formats", false, "list the supported output containers and whether the local FFmpeg can write them")
 
-	if err := proc.Run(); err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
-		os.Exit(1)
+	// fail reports err and exits with code, also writing it as JSON for -error-json
+	fail := func(class string, err error, code int) {
+		fmt.Printf("%s Error: %v\n", style.Error, err)
+		if cfg.ErrorJSON {
+			if class == "" {
+				class = processor.ErrorClass(err)
+			}
+			if writeErr := processor.WriteErrorJSON(os.NewFile(uintptr(cfg.ErrorFD), "error"), class, err, code, cfg.InputPath, cfg.OutputPath); writeErr != nil {
+				fmt.Printf("%s Could not write -error-json output: %v\n", style.Warn, writeErr)
+			}
+		}
+		os.Exit(code)
 	}
+
+	// Parse errors are usage errors for -error-json too; flag has already printed the usage
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fail(processor.ErrorClassUsage, err, 2)
+	}
+
+	if *profileName != "" {
+		loaded, err := profile.Load(profile.DefaultDir(), *profileName)
+		if err == nil {
+			if err = settings.Apply(loaded); err != nil {
+				err = fmt.Errorf("profile %s: %w", *profileName, err)
+			}
+		}
+		if err != nil {
+			fail(processor.ErrorClassUsage, err, 2)
+		}
+	}
+	style.SetASCII(cfg.ASCII || style.DetectASCII())
+
+	if err := cfg.Validate(); err != nil {
+		fail(processor.ErrorClassUsage, err, 2)
+	}
+
+	switch {
+	case *listProfiles:
+		if err := printProfiles(profile.DefaultDir()); err != nil {
+			fail("", err, 1)
+		}
+		return
+	case *saveProfile != "":
+		path, err := profile.Save(profile.DefaultDir(), *saveProfile, settings.Settings())
+		if err != nil {
+			fail("", err, 1)
+		}
+		fmt.Printf("%s Saved profile %s (%d options) to %s\n", style.OK, *saveProfile, len(settings.Settings()), path)
+		return
+	}
+
+	proc := processor.New(cfg)
+
+	if cfg.ProgressJSON {
+		progressOut := os.NewFile(uintptr(cfg.ProgressFD), "progress")
+		if cfg.ProgressFD == 1 {
+			// Keep human-readable output off stdout so it doesn't interleave with the JSON
+			os.Stdout = os.Stderr
+		}
+		proc.SetProgressWriter(progressOut)
+	}
+
+	run := proc.Run
+	switch {
+	case *showVersion:
+		run = func() error { return proc.PrintVersion(*asJSON) }
+	case *detectJSON:
+		run = proc.PrintDetectionJSON
+	case *selfTest:
+		run = proc.SelfTest
+	case *checkDeps:
+		run = proc.CheckDeps
+	case *listFormats:
+		run = proc.ListFormats
+	case *estimate:
+		run = proc.Estimate
+	case cfg.Record:
+		run = proc.Record
+	case cfg.FrameAt != "":
+		run = proc.ExtractFrame
+	}
+
+	if err := run(); err != nil {
+		fail("", err, 1)
+	}
+}
+
+// printProfiles lists the saved profiles in dir with the options each one sets
+func printProfiles(dir string) error {
+	names, err := profile.List(dir)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Printf("No profiles saved in %s; create one with -save-profile <name>\n", dir)
+		return nil
+	}
+
+	fmt.Printf("Profiles in %s:\n", dir)
+	for _, name := range names {
+		loaded, err := profile.Load(dir, name)
+		if err != nil {
+			fmt.Printf("  %-16s %s %v\n", name, style.Warn, err)
+			continue
+		}
+		var options []string
+		for _, s := range loaded {
+			options = append(options, fmt.Sprintf("-%s %s", s.Name, s.Value))
+		}
+		fmt.Printf("  %-16s %s\n", name, strings.Join(options, " "))
+	}
+	return nil
 }
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// detectionCacheEntry is the on-disk form of a cached GPU detection
+type detectionCacheEntry struct {
+	DetectedAt time.Time `json:"detected_at"`
+	Signature  string    `json:"signature"`
+	GPUs       []GPUInfo `json:"gpus"`
+}
+
+// DetectionCache stores GPU detection results on disk for a limited time. Entries are
+// also invalidated when the set of display devices on the PCI bus changes, so hotplugged
+// (e.g. Thunderbolt eGPU) cards are picked up immediately.
+type DetectionCache struct {
+	path      string
+	ttl       time.Duration
+	signature func() string
+	now       func() time.Time
+}
+
+// NewDetectionCache creates a cache stored at path whose entries expire after ttl
+func NewDetectionCache(path string, ttl time.Duration) *DetectionCache {
+	return &DetectionCache{
+		path:      path,
+		ttl:       ttl,
+		signature: pciSignature,
+		now:       time.Now,
+	}
+}
+
+// DefaultCachePath returns the detection cache location in the user's cache directory
+func DefaultCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "video_processing", "gpus.json")
+}
+
+// Load returns the cached GPUs if the entry is within its TTL and the hardware signature still matches
+func (c *DetectionCache) Load() ([]GPUInfo, bool) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry detectionCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	if c.now().Sub(entry.DetectedAt) > c.ttl {
+		return nil, false
+	}
+	if entry.Signature != c.signature() {
+		return nil, false
+	}
+	return entry.GPUs, true
+}
+
+// Save stores a detection result together with the current hardware signature
+func (c *DetectionCache) Save(gpus []GPUInfo) error {
+	data, err := json.Marshal(detectionCacheEntry{
+		DetectedAt: c.now(),
+		Signature:  c.signature(),
+		GPUs:       gpus,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o644)
+}
+
+// pciSignature returns a cheap fingerprint of the display devices currently present,
+// or "" when the platform offers no fast way to list them
+func pciSignature() string {
+	var devices []string
+	switch runtime.GOOS {
+	case "linux":
+		devices = linuxDisplayDevices()
+	case "windows":
+		devices = windowsDisplayDevices()
+	}
+	slices.Sort(devices)
+	return strings.Join(devices, ",")
+}
+
+// linuxDisplayDevices lists the PCI addresses of display-class (0x03xxxx) devices from sysfs
+func linuxDisplayDevices() []string {
+	paths, _ := filepath.Glob("/sys/bus/pci/devices/*/class")
+	var devices []string
+	for _, path := range paths {
+		class, err := os.ReadFile(path)
+		if err != nil || !strings.HasPrefix(strings.TrimSpace(string(class)), "0x03") {
+			continue
+		}
+		devices = append(devices, filepath.Base(filepath.Dir(path)))
+	}
+	return devices
+}
+
+// windowsDisplayDevices lists the instance IDs of connected display adapters via pnputil
+func windowsDisplayDevices() []string {
+	d := NewGPUDetector()
+	out, err := d.runCommandWithTimeout("pnputil", "/enum-devices", "/class", "Display", "/connected")
+	if err != nil {
+		return nil
+	}
+
+	var devices []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(key) == "Instance ID" {
+			devices = append(devices, strings.TrimSpace(value))
+		}
+	}
+	return devices
+}
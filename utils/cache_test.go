@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, signature *string, now *time.Time) *DetectionCache {
+	c := NewDetectionCache(filepath.Join(t.TempDir(), "gpus.json"), time.Hour)
+	c.signature = func() string { return *signature }
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestDetectionCache(t *testing.T) {
+	gpus := []GPUInfo{{Vendor: "nvidia", Model: "GeForce RTX 3080", PCIAddress: "01:00.0"}}
+
+	t.Run("hit within ttl", func(t *testing.T) {
+		signature, now := "0000:00:02.0,0000:01:00.0", time.Now()
+		c := newTestCache(t, &signature, &now)
+		if err := c.Save(gpus); err != nil {
+			t.Fatal(err)
+		}
+
+		now = now.Add(30 * time.Minute)
+		got, ok := c.Load()
+		if !ok || len(got) != 1 || got[0].Model != gpus[0].Model {
+			t.Errorf("Load() = %v, %v; want cached GPUs", got, ok)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		signature, now := "0000:01:00.0", time.Now()
+		c := newTestCache(t, &signature, &now)
+		if err := c.Save(gpus); err != nil {
+			t.Fatal(err)
+		}
+
+		now = now.Add(2 * time.Hour)
+		if _, ok := c.Load(); ok {
+			t.Error("Load() hit after the TTL expired")
+		}
+	})
+
+	t.Run("hotplugged gpu invalidates", func(t *testing.T) {
+		signature, now := "0000:00:02.0", time.Now()
+		c := newTestCache(t, &signature, &now)
+		if err := c.Save(gpus); err != nil {
+			t.Fatal(err)
+		}
+
+		// An eGPU appears on the bus within the TTL
+		signature = "0000:00:02.0,0000:3c:00.0"
+		if _, ok := c.Load(); ok {
+			t.Error("Load() hit after the PCI signature changed")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		signature, now := "", time.Now()
+		c := newTestCache(t, &signature, &now)
+		if _, ok := c.Load(); ok {
+			t.Error("Load() hit with no cache file")
+		}
+	})
+}
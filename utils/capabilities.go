@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EncoderCapabilities describes the practical H.264 encode limits of a GPU
+type EncoderCapabilities struct {
+	MaxWidth  int `json:"max_width"`
+	MaxHeight int `json:"max_height"`
+	// MaxSessions is the concurrent encode session limit; 0 means unlimited or unknown
+	MaxSessions int    `json:"max_sessions,omitempty"`
+	Source      string `json:"source"`
+}
+
+var (
+	// nvidiaKeplerModel matches Kepler and first-generation Maxwell GeForce cards (GTX 600/700)
+	nvidiaKeplerModel = regexp.MustCompile(`\bgtx\s*[67]\d{2}\b`)
+
+	// nvidiaProfessionalModel matches the NVIDIA lines without the consumer NVENC session cap:
+	// Quadro, Tesla, NVS and GRID, the RTX A/Ada/Pro workstation cards and A10/A16/A40/L4/L40
+	nvidiaProfessionalModel = regexp.MustCompile(`\b(?:quadro|tesla|nvs|grid|rtx a\d{3,4}|rtx \d{4} ada|rtx pro|a(?:10|16|40)g?|l4|l40s?)\b`)
+
+	// amdVCE1Model matches the Radeon HD 7000 series, whose VCE 1.x encoder is 1080p-only
+	amdVCE1Model = regexp.MustCompile(`\bhd\s*7\d{3}\b`)
+
+	// intelPreSkylakeModel matches the four-digit HD Graphics names of Ivy Bridge to Broadwell
+	intelPreSkylakeModel = regexp.MustCompile(`\bhd graphics\s*[2-5]\d{3}\b`)
+)
+
+// ProbeCapabilities reports encode limits for NVIDIA, AMD and Intel GPUs.
+// Driver queries are used where available, otherwise known per-generation defaults
+// keyed off the model string.
+func (d *GPUDetector) ProbeCapabilities(gpu GPUInfo) *EncoderCapabilities {
+	switch gpu.Vendor {
+	case "nvidia":
+		return d.probeNvidiaCapabilities(gpu)
+	case "amd":
+		return d.amdCapabilities(gpu.Model)
+	case "intel":
+		return d.intelCapabilities(gpu.Model)
+	default:
+		return nil
+	}
+}
+
+func (d *GPUDetector) probeNvidiaCapabilities(gpu GPUInfo) *EncoderCapabilities {
+	caps := &EncoderCapabilities{
+		MaxWidth:  4096,
+		MaxHeight: 4096,
+		Source:    "generation-default",
+	}
+
+	model := gpu.Model
+	driver := gpu.DriverVersion
+
+	// nvidia-smi gives the exact product name and driver, which drive the session limit
+	if out, err := d.runCommandWithTimeout("nvidia-smi", "--query-gpu=name,driver_version", "--format=csv,noheader"); err == nil {
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			parts := strings.SplitN(line, ",", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			name := strings.TrimSpace(parts[0])
+			if d.areGPUsSimilar(gpu, GPUInfo{Vendor: "nvidia", Model: name}) || model == "NVIDIA GPU" {
+				model = name
+				driver = strings.TrimSpace(parts[1])
+				caps.Source = "nvidia-smi"
+				break
+			}
+		}
+	}
+
+	lower := strings.ToLower(model)
+
+	// Kepler and first-generation Maxwell top out below 4K DCI height
+	if nvidiaKeplerModel.MatchString(lower) {
+		caps.MaxWidth, caps.MaxHeight = 4096, 2304
+	}
+
+	if d.isNvidiaProfessional(lower) {
+		caps.MaxSessions = 0
+	} else {
+		caps.MaxSessions = nvidiaConsumerSessionLimit(driver)
+	}
+
+	return caps
+}
+
+// isNvidiaProfessional reports whether the model belongs to a line without the consumer NVENC session cap
+func (d *GPUDetector) isNvidiaProfessional(lowerModel string) bool {
+	return nvidiaProfessionalModel.MatchString(lowerModel)
+}
+
+// nvidiaConsumerSessionLimit returns the driver-imposed NVENC session cap for GeForce cards
+func nvidiaConsumerSessionLimit(driver string) int {
+	major := 0
+	if fields := strings.SplitN(driver, ".", 2); len(fields) > 0 {
+		major, _ = strconv.Atoi(fields[0])
+	}
+
+	switch {
+	case major >= 551:
+		return 8
+	case major >= 530:
+		return 5
+	default:
+		return 3
+	}
+}
+
+func (d *GPUDetector) amdCapabilities(model string) *EncoderCapabilities {
+	lower := strings.ToLower(model)
+	caps := &EncoderCapabilities{
+		MaxWidth:  4096,
+		MaxHeight: 2160,
+		Source:    "generation-default",
+	}
+
+	// VCE 1.x parts (Radeon HD 7000 series) are limited to 1080p H.264 encodes
+	if amdVCE1Model.MatchString(lower) {
+		caps.MaxWidth, caps.MaxHeight = 1920, 1088
+	}
+
+	return caps
+}
+
+func (d *GPUDetector) intelCapabilities(model string) *EncoderCapabilities {
+	lower := strings.ToLower(model)
+	caps := &EncoderCapabilities{
+		MaxWidth:  4096,
+		MaxHeight: 4096,
+		Source:    "generation-default",
+	}
+
+	// Pre-Skylake HD Graphics (e.g. HD 4000, HD 4600) only encode up to 4096x2304
+	if intelPreSkylakeModel.MatchString(lower) {
+		caps.MaxWidth, caps.MaxHeight = 4096, 2304
+	}
+
+	return caps
+}
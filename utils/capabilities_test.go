@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsNvidiaProfessional(t *testing.T) {
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{"NVIDIA Corporation TU106GL [Quadro RTX 5000]", true},
+		{"NVIDIA Corporation GA102GL [A10]", true},
+		{"NVIDIA A10G", true},
+		{"NVIDIA L40S", true},
+		{"NVIDIA RTX A4000", true},
+		{"NVIDIA RTX 6000 Ada Generation", true},
+		{"NVIDIA GeForce RTX 4090", false},
+		{"NVIDIA GeForce GTX 1650", false},
+		{"NVIDIA GeForce RTX 4070 Laptop GPU", false},
+	}
+
+	d := NewGPUDetector()
+	for _, tt := range tests {
+		if got := d.isNvidiaProfessional(strings.ToLower(tt.model)); got != tt.want {
+			t.Errorf("isNvidiaProfessional(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestGenerationLimits(t *testing.T) {
+	tests := []struct {
+		vendor, model string
+		wantHeight    int
+	}{
+		{"amd", "AMD Radeon HD 7970", 1088},
+		{"amd", "AMD Radeon RX 7900 XTX", 2160},
+		{"intel", "Intel HD Graphics 4600", 2304},
+		{"intel", "Intel HD Graphics 530", 4096},
+		{"intel", "Intel UHD Graphics 630", 4096},
+	}
+
+	d := NewGPUDetector()
+	for _, tt := range tests {
+		if got := d.ProbeCapabilities(GPUInfo{Vendor: tt.vendor, Model: tt.model}); got.MaxHeight != tt.wantHeight {
+			t.Errorf("ProbeCapabilities(%s %q).MaxHeight = %d, want %d", tt.vendor, tt.model, got.MaxHeight, tt.wantHeight)
+		}
+	}
+}
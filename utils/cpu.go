@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// CPUInfo describes the host CPU's capacity for software encoding. The AVX flags are only
+// known on Linux and Intel Macs; elsewhere they read false.
+type CPUInfo struct {
+	Cores  int
+	AVX2   bool
+	AVX512 bool
+}
+
+// String summarises the CPU, e.g. "16 cores, AVX2"
+func (c CPUInfo) String() string {
+	s := fmt.Sprintf("%d cores", c.Cores)
+	switch {
+	case c.AVX512:
+		s += ", AVX-512"
+	case c.AVX2:
+		s += ", AVX2"
+	}
+	return s
+}
+
+// DetectCPU reads the logical core count and the AVX2/AVX-512 support x264 and x265 make
+// use of: from /proc/cpuinfo on Linux and sysctl on macOS
+var DetectCPU = sync.OnceValue(func() CPUInfo {
+	info := CPUInfo{Cores: runtime.NumCPU()}
+
+	var features string
+	switch runtime.GOOS {
+	case "linux":
+		if data, err := os.ReadFile("/proc/cpuinfo"); err == nil {
+			features = cpuinfoFlags(string(data))
+		}
+	case "darwin":
+		// Apple Silicon has neither key, so AVX stays false there
+		if out, err := exec.Command("sysctl", "-n", "machdep.cpu.features", "machdep.cpu.leaf7_features").Output(); err == nil {
+			features = string(out)
+		}
+	}
+	info.AVX2, info.AVX512 = parseAVXFlags(features)
+	return info
+})
+
+// cpuinfoFlags returns the feature list of the first processor in /proc/cpuinfo
+func cpuinfoFlags(cpuinfo string) string {
+	for _, line := range strings.Split(cpuinfo, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "flags" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseAVXFlags reports AVX2 and AVX-512 (foundation) support from a whitespace-separated
+// feature list such as /proc/cpuinfo's "avx avx2 avx512f" or sysctl's "AVX2 AVX512F"
+func parseAVXFlags(features string) (avx2, avx512 bool) {
+	for _, flag := range strings.Fields(strings.ToLower(features)) {
+		switch flag {
+		case "avx2":
+			avx2 = true
+		case "avx512f":
+			avx512 = true
+		}
+	}
+	return avx2, avx512
+}
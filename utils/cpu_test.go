@@ -0,0 +1,30 @@
+package utils
+
+import "testing"
+
+func TestCPUInfoFlags(t *testing.T) {
+	cpuinfo := "processor\t: 0\nvendor_id\t: AuthenticAMD\nmodel name\t: AMD Ryzen 9 7950X 16-Core Processor\n" +
+		"flags\t\t: fpu vme sse sse2 avx avx2 avx512f avx512dq\n\nprocessor\t: 1\nflags\t\t: fpu\n"
+
+	tests := []struct {
+		name       string
+		features   string
+		wantAVX2   bool
+		wantAVX512 bool
+	}{
+		{"linux cpuinfo", cpuinfoFlags(cpuinfo), true, true},
+		{"avx only", "fpu sse4_2 avx", false, false},
+		{"avx2 without avx-512", "sse4_2 avx avx2 fma", true, false},
+		{"sysctl", "FPU VME SSE4.2 AVX1.0\nSMEP BMI1 AVX2 BMI2 ERMS\n", true, false},
+		{"apple silicon", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			avx2, avx512 := parseAVXFlags(tt.features)
+			if avx2 != tt.wantAVX2 || avx512 != tt.wantAVX512 {
+				t.Errorf("parseAVXFlags(%q) = %v, %v, want %v, %v", tt.features, avx2, avx512, tt.wantAVX2, tt.wantAVX512)
+			}
+		})
+	}
+}
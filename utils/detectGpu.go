@@ -18,12 +18,19 @@ type GPUInfo struct {
 	Memory       string `json:"memory,omitempty"`
 	DriverVersion string `json:"driver_version,omitempty"`
 	PCIAddress   string `json:"pci_address,omitempty"`
+	DeviceID     string `json:"device_id,omitempty"` // PCI device ID as lowercase hex, e.g. 56a0
+	UUID         string `json:"uuid,omitempty"`
+	DeviceIndex  string `json:"device_index,omitempty"`
+	Cores        int    `json:"cores,omitempty"`
+	Integrated   bool   `json:"integrated"`
+	Capabilities *EncoderCapabilities `json:"capabilities,omitempty"`
 	RawOutput    string `json:"raw_output,omitempty"`
 	Error        string `json:"error,omitempty"`
 }
 
 type GPUDetector struct {
 	timeout time.Duration
+	cache   *DetectionCache
 }
 
 func NewGPUDetector() *GPUDetector {
@@ -32,14 +39,38 @@ func NewGPUDetector() *GPUDetector {
 	}
 }
 
+// EnableCache makes DetectGPUs reuse results stored at path for up to ttl
+func (d *GPUDetector) EnableCache(path string, ttl time.Duration) {
+	d.cache = NewDetectionCache(path, ttl)
+}
+
 func (d *GPUDetector) DetectGPUs() ([]GPUInfo, error) {
+	if d.cache == nil {
+		return d.detectGPUs()
+	}
+	if gpus, ok := d.cache.Load(); ok {
+		return gpus, nil
+	}
+
+	gpus, err := d.detectGPUs()
+	if err == nil {
+		// A failed cache write only costs the next run a fresh detection
+		_ = d.cache.Save(gpus)
+	}
+	return gpus, err
+}
+
+func (d *GPUDetector) detectGPUs() ([]GPUInfo, error) {
+	var gpus []GPUInfo
+	var err error
+
 	switch runtime.GOOS {
 	case "windows":
-		return d.detectWindowsGPUs()
+		gpus, err = d.detectWindowsGPUs()
 	case "linux":
-		return d.detectLinuxGPUs()
+		gpus, err = d.detectLinuxGPUs()
 	case "darwin":
-		return d.detectMacGPUs()
+		gpus, err = d.detectMacGPUs()
 	default:
 		return []GPUInfo{{
 			Vendor: "unknown",
@@ -47,6 +78,55 @@ func (d *GPUDetector) DetectGPUs() ([]GPUInfo, error) {
 			Error:  fmt.Sprintf("Unsupported operating system: %s", runtime.GOOS),
 		}}, nil
 	}
+
+	for i := range gpus {
+		if gpus[i].DeviceID == "" {
+			gpus[i].DeviceID = PCIDeviceID(gpus[i].PCIAddress)
+		}
+		gpus[i].Integrated = d.isIntegratedGPU(gpus[i])
+		gpus[i].Capabilities = d.ProbeCapabilities(gpus[i])
+	}
+	d.addNvidiaIdentifiers(gpus)
+	return gpus, err
+}
+
+// vendorNames are the display names of the GPU vendors
+var vendorNames = map[string]string{
+	"nvidia": "NVIDIA",
+	"amd":    "AMD",
+	"intel":  "Intel",
+	"apple":  "Apple",
+}
+
+// VendorName returns the display name of a GPU vendor, e.g. NVIDIA for "nvidia"
+func VendorName(vendor string) string {
+	if name, ok := vendorNames[vendor]; ok {
+		return name
+	}
+	if vendor == "" {
+		return ""
+	}
+	return strings.ToUpper(vendor[:1]) + vendor[1:]
+}
+
+// PreferredGPU picks the GPU to use for encoding, favouring discrete GPUs
+// over integrated ones and GPUs of a known vendor over the rest. It returns the
+// first GPU when no vendor is known.
+func PreferredGPU(gpus []GPUInfo) (GPUInfo, bool) {
+	if len(gpus) == 0 {
+		return GPUInfo{}, false
+	}
+	for _, gpu := range gpus {
+		if gpu.Vendor != "unknown" && !gpu.Integrated {
+			return gpu, true
+		}
+	}
+	for _, gpu := range gpus {
+		if gpu.Vendor != "unknown" {
+			return gpu, true
+		}
+	}
+	return gpus[0], true
 }
 
 // DetectGPUVendor maintains backward compatibility
@@ -128,6 +208,17 @@ func (d *GPUDetector) detectWindowsGPUsWMIC() ([]GPUInfo, error) {
 func (d *GPUDetector) detectLinuxGPUs() ([]GPUInfo, error) {
 	var gpus []GPUInfo
 
+	if IsWSL() {
+		if wslGPUs := d.detectWSLGPUs(); len(wslGPUs) > 0 {
+			return wslGPUs, nil
+		}
+		return []GPUInfo{{
+			Vendor: "unknown",
+			Model:  "WSL virtual GPU",
+			Error:  "WSL only supports NVIDIA GPUs for encoding (CUDA/NVENC via the Windows driver); encoding in software",
+		}}, nil
+	}
+
 	// Try lspci first (most reliable)
 	if lspciGPUs := d.tryLinuxLspci(); len(lspciGPUs) > 0 {
 		gpus = append(gpus, lspciGPUs...)
@@ -159,15 +250,19 @@ func (d *GPUDetector) detectLinuxGPUs() ([]GPUInfo, error) {
 	return gpus, nil
 }
 
+// tryLinuxLspci prefers lspci's machine-readable format, falling back to parsing the
+// human-readable listing for lspci builds without -mm
 func (d *GPUDetector) tryLinuxLspci() []GPUInfo {
-	out, err := d.runCommandWithTimeout("lspci", "-v", "-s", "$(lspci | grep -i vga | cut -d' ' -f1)")
-	if err != nil {
-		// Fallback to simple lspci
-		if out, err = d.runCommandWithTimeout("lspci"); err != nil {
-			return nil
+	if out, err := d.runCommandWithTimeout("lspci", "-mm"); err == nil {
+		if gpus := d.parseLinuxLspciMachineOutput(string(out)); len(gpus) > 0 {
+			return gpus
 		}
 	}
 
+	out, err := d.runCommandWithTimeout("lspci")
+	if err != nil {
+		return nil
+	}
 	return d.parseLinuxLspciOutput(string(out))
 }
 
@@ -325,32 +420,66 @@ func (d *GPUDetector) parseLinuxLshwOutput(output string) []GPUInfo {
 	return gpus
 }
 
+// parseMacGPUOutput parses `system_profiler SPDisplaysDataType`. Each GPU's properties start
+// at its "Chipset Model" line; the monitors it drives are nested deeper under "Displays:", so
+// only lines at the GPU's own indentation are attributed to it and displays never become GPUs.
 func (d *GPUDetector) parseMacGPUOutput(output string) []GPUInfo {
 	var gpus []GPUInfo
-	blocks := d.splitIntoBlocks(output)
-	
-	for _, block := range blocks {
-		gpu := GPUInfo{RawOutput: block}
-		
-		for _, line := range strings.Split(block, "\n") {
-			line = strings.TrimSpace(line)
-			if strings.Contains(line, "Chipset Model:") {
-				if parts := strings.SplitN(line, ":", 2); len(parts) == 2 {
-					gpu.Model = strings.TrimSpace(parts[1])
-				}
-			} else if strings.Contains(line, "VRAM") && strings.Contains(line, ":") {
-				if parts := strings.SplitN(line, ":", 2); len(parts) == 2 {
-					gpu.Memory = strings.TrimSpace(parts[1])
-				}
+	var gpu *GPUInfo
+	var raw []string
+	propIndent := 0
+
+	flush := func() {
+		if gpu != nil {
+			gpu.RawOutput = strings.Join(raw, "\n")
+			gpu.Vendor = d.determineVendorFromOutput(gpu.Model)
+			gpus = append(gpus, *gpu)
+		}
+		gpu, raw = nil, nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		key, value, _ := strings.Cut(trimmed, ":")
+		value = strings.TrimSpace(value)
+
+		if key == "Chipset Model" {
+			flush()
+			if value != "" {
+				gpu = &GPUInfo{Model: value}
+				raw = []string{line}
+				propIndent = indent
 			}
+			continue
 		}
-		
-		if gpu.Model != "" {
-			gpu.Vendor = d.determineVendorFromOutput(gpu.Model)
-			gpus = append(gpus, gpu)
+		if gpu == nil {
+			continue
+		}
+		if indent < propIndent {
+			// The next GPU's heading or the end of the section
+			flush()
+			continue
+		}
+
+		raw = append(raw, line)
+		if indent > propIndent {
+			continue // Attached display details
+		}
+		switch {
+		case strings.HasPrefix(key, "VRAM"):
+			gpu.Memory = value
+		case key == "Total Number of Cores":
+			gpu.Cores, _ = strconv.Atoi(value)
+		case key == "Device ID":
+			gpu.DeviceID = strings.TrimPrefix(strings.ToLower(value), "0x")
 		}
 	}
-	
+	flush()
+
 	return gpus
 }
 
@@ -401,6 +530,37 @@ func (d *GPUDetector) determineVendorFromOutput(output string) string {
 	return "unknown"
 }
 
+// isIntegratedGPU classifies a GPU as integrated or discrete, from its PCI device ID when
+// known and otherwise from its model name. Names alone are ambiguous: Meteor Lake's iGPU is
+// "Intel Arc Graphics" and Raven Ridge's is "Radeon RX Vega 11 Graphics".
+func (d *GPUDetector) isIntegratedGPU(gpu GPUInfo) bool {
+	lower := strings.ToLower(gpu.Model)
+	id, hasID := parsePCIDeviceID(gpu.DeviceID)
+
+	switch gpu.Vendor {
+	case "apple":
+		return true
+	case "intel":
+		if hasID {
+			return !isIntelDiscreteID(id)
+		}
+		return !intelDiscreteModel.MatchString(lower)
+	case "amd":
+		// Unlisted IDs may be newer APUs, so they fall back to the name
+		if hasID && amdAPUDeviceIDs[id] {
+			return true
+		}
+		if amdIntegratedModel.MatchString(lower) {
+			return true
+		}
+		return false
+	case "nvidia":
+		return strings.Contains(lower, "tegra")
+	default:
+		return false
+	}
+}
+
 // Helper functions
 func (d *GPUDetector) splitIntoBlocks(output string) []string {
 	var blocks []string
@@ -521,6 +681,10 @@ func (d *GPUDetector) parseWindowsJSONOutput(output string) []GPUInfo {
 		if ramMatch := regexp.MustCompile(`"AdapterRAM":\s*(\d+)`).FindStringSubmatch(block); len(ramMatch) > 1 {
 			gpu.Memory = d.formatMemory(ramMatch[1])
 		}
+
+		if devMatch := pnpDeviceID.FindStringSubmatch(block); len(devMatch) > 1 {
+			gpu.DeviceID = strings.ToLower(devMatch[1])
+		}
 		
 		if gpu.Model != "" && !d.isGenericGPU(gpu.Model) {
 			gpu.Vendor = d.determineVendorFromOutput(gpu.Model)
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseMacGPUOutput(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []GPUInfo
+	}{
+		{
+			fixture: "system_profiler_m1_two_displays.txt",
+			want: []GPUInfo{
+				{Vendor: "apple", Model: "Apple M1 Pro", Cores: 16},
+			},
+		},
+		{
+			fixture: "system_profiler_dual_gpu.txt",
+			want: []GPUInfo{
+				{Vendor: "intel", Model: "Intel UHD Graphics 630", Memory: "1536 MB", DeviceID: "3e9b"},
+				{Vendor: "amd", Model: "AMD Radeon Pro 5500M", Memory: "4 GB", DeviceID: "7340"},
+			},
+		},
+	}
+
+	d := NewGPUDetector()
+	for _, tt := range tests {
+		data, err := os.ReadFile("testdata/" + tt.fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := d.parseMacGPUOutput(string(data))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: parsed %d GPUs, want %d", tt.fixture, len(got), len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			g := got[i]
+			if g.Vendor != want.Vendor || g.Model != want.Model || g.Memory != want.Memory || g.Cores != want.Cores || g.DeviceID != want.DeviceID {
+				t.Errorf("%s: GPU %d = %s %q (memory %q, %d cores, device %q), want %s %q (memory %q, %d cores, device %q)",
+					tt.fixture, i, g.Vendor, g.Model, g.Memory, g.Cores, g.DeviceID, want.Vendor, want.Model, want.Memory, want.Cores, want.DeviceID)
+			}
+		}
+	}
+}
+
+func TestParseLinuxLspciMachineOutput(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []GPUInfo
+	}{
+		{
+			fixture: "lspci_mm_desktop.txt",
+			want: []GPUInfo{
+				{Vendor: "intel", Model: "Intel Corporation CoffeeLake-S GT2 [UHD Graphics 630]", PCIAddress: "00:02.0"},
+				{Vendor: "nvidia", Model: "NVIDIA Corporation TU106 [GeForce RTX 2060 SUPER]", PCIAddress: "01:00.0"},
+				{Vendor: "nvidia", Model: "NVIDIA Corporation GA102GL [A10]", PCIAddress: "02:00.0"},
+			},
+		},
+		{
+			fixture: "lspci_mm_amd_laptop.txt",
+			want: []GPUInfo{
+				{Vendor: "amd", Model: "Advanced Micro Devices, Inc. [AMD/ATI] Navi 23 [Radeon RX 6600M]", PCIAddress: "0000:03:00.0"},
+				{Vendor: "amd", Model: "Advanced Micro Devices, Inc. [AMD/ATI] Cezanne [Radeon Vega Series / Radeon Vega Mobile Series]", PCIAddress: "0000:06:00.0"},
+				{Vendor: "unknown", Model: "ASPEED Technology, Inc. ASPEED Graphics Family", PCIAddress: "0000:07:00.0"},
+			},
+		},
+		{
+			fixture: "lspci_mm_vm.txt",
+			want:    nil,
+		},
+	}
+
+	d := NewGPUDetector()
+	for _, tt := range tests {
+		data, err := os.ReadFile("testdata/" + tt.fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := d.parseLinuxLspciMachineOutput(string(data))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: parsed %d GPUs, want %d", tt.fixture, len(got), len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			g := got[i]
+			if g.Vendor != want.Vendor || g.Model != want.Model || g.PCIAddress != want.PCIAddress {
+				t.Errorf("%s: GPU %d = %s %q at %q, want %s %q at %q",
+					tt.fixture, i, g.Vendor, g.Model, g.PCIAddress, want.Vendor, want.Model, want.PCIAddress)
+			}
+		}
+	}
+}
+
+func TestIsIntegratedGPU(t *testing.T) {
+	tests := []struct {
+		gpu  GPUInfo
+		want bool
+	}{
+		{GPUInfo{Vendor: "intel", Model: "Intel(R) Arc(TM) Graphics", DeviceID: "7d55"}, true},
+		{GPUInfo{Vendor: "intel", Model: "Intel(R) Arc(TM) Graphics"}, true},
+		{GPUInfo{Vendor: "intel", Model: "Intel(R) Arc(TM) A770 Graphics", DeviceID: "56a0"}, false},
+		{GPUInfo{Vendor: "intel", Model: "Intel(R) Arc(TM) A770 Graphics"}, false},
+		{GPUInfo{Vendor: "intel", Model: "Intel Corporation Device e20b", DeviceID: "e20b"}, false},
+		{GPUInfo{Vendor: "intel", Model: "Intel UHD Graphics 630", DeviceID: "3e9b"}, true},
+		{GPUInfo{Vendor: "amd", Model: "AMD Radeon(TM) RX Vega 11 Graphics"}, true},
+		{GPUInfo{Vendor: "amd", Model: "Advanced Micro Devices, Inc. [AMD/ATI] Device 15bf", DeviceID: "15bf"}, true},
+		{GPUInfo{Vendor: "amd", Model: "AMD Radeon 780M"}, true},
+		{GPUInfo{Vendor: "amd", Model: "Advanced Micro Devices, Inc. [AMD/ATI] Cezanne [Radeon Vega Series / Radeon Vega Mobile Series]"}, true},
+		{GPUInfo{Vendor: "amd", Model: "Advanced Micro Devices, Inc. [AMD/ATI] Navi 23 [Radeon RX 6600M]", DeviceID: "73ff"}, false},
+		{GPUInfo{Vendor: "amd", Model: "Advanced Micro Devices, Inc. [AMD/ATI] Vega 10 XL/XT [Radeon RX Vega 56/64]"}, false},
+		{GPUInfo{Vendor: "amd", Model: "AMD Radeon Pro 5500M", DeviceID: "7340"}, false},
+		{GPUInfo{Vendor: "nvidia", Model: "NVIDIA GeForce RTX 4090"}, false},
+		{GPUInfo{Vendor: "apple", Model: "Apple M1 Pro"}, true},
+	}
+
+	d := NewGPUDetector()
+	for _, tt := range tests {
+		if got := d.isIntegratedGPU(tt.gpu); got != tt.want {
+			t.Errorf("isIntegratedGPU(%q, device %q) = %v, want %v", tt.gpu.Model, tt.gpu.DeviceID, got, tt.want)
+		}
+	}
+}
+
+func TestPreferredGPU(t *testing.T) {
+	aspeed := GPUInfo{Vendor: "unknown", Model: "ASPEED Graphics Family"}
+	intel := GPUInfo{Vendor: "intel", Model: "Intel UHD Graphics 630", Integrated: true}
+	amd := GPUInfo{Vendor: "amd", Model: "AMD Radeon Graphics", Integrated: true}
+	nvidia := GPUInfo{Vendor: "nvidia", Model: "NVIDIA GeForce RTX 4070"}
+
+	tests := []struct {
+		name  string
+		gpus  []GPUInfo
+		want  string
+		found bool
+	}{
+		{"discrete preferred", []GPUInfo{intel, nvidia}, nvidia.Model, true},
+		{"known integrated over unknown", []GPUInfo{aspeed, intel}, intel.Model, true},
+		{"first known integrated", []GPUInfo{aspeed, amd, intel}, amd.Model, true},
+		{"only unknown", []GPUInfo{aspeed}, aspeed.Model, true},
+		{"none", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := PreferredGPU(tt.gpus)
+		if got.Model != tt.want || ok != tt.found {
+			t.Errorf("%s: PreferredGPU() = %q, %v, want %q, %v", tt.name, got.Model, ok, tt.want, tt.found)
+		}
+	}
+}
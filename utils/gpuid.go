@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// addNvidiaIdentifiers fills in the UUID, PCI address and nvidia-smi index of NVIDIA GPUs.
+// GPUs are matched by PCI address when the detector found one, otherwise in listing order.
+func (d *GPUDetector) addNvidiaIdentifiers(gpus []GPUInfo) {
+	out, err := d.runCommandWithTimeout("nvidia-smi", "--query-gpu=index,uuid,pci.bus_id", "--format=csv,noheader")
+	if err != nil {
+		return
+	}
+
+	type smiGPU struct{ index, uuid, pci string }
+	var devices []smiGPU
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) != 3 {
+			continue
+		}
+		devices = append(devices, smiGPU{
+			index: strings.TrimSpace(fields[0]),
+			uuid:  strings.TrimSpace(fields[1]),
+			pci:   NormalizePCIAddress(fields[2]),
+		})
+	}
+
+	next := 0
+	for i := range gpus {
+		if gpus[i].Vendor != "nvidia" {
+			continue
+		}
+		match := -1
+		for j, dev := range devices {
+			if gpus[i].PCIAddress != "" && NormalizePCIAddress(gpus[i].PCIAddress) == dev.pci {
+				match = j
+				break
+			}
+		}
+		if match < 0 && gpus[i].PCIAddress == "" && next < len(devices) {
+			match = next
+		}
+		if match < 0 {
+			continue
+		}
+		next = match + 1
+
+		gpus[i].UUID = devices[match].uuid
+		gpus[i].DeviceIndex = devices[match].index
+		if gpus[i].PCIAddress == "" {
+			gpus[i].PCIAddress = devices[match].pci
+		}
+	}
+}
+
+// NormalizePCIAddress reduces a PCI address to lowercase bus:device.function form, dropping
+// the domain prefix that nvidia-smi and sysfs include (00000000:01:00.0 -> 01:00.0)
+func NormalizePCIAddress(addr string) string {
+	addr = strings.ToLower(strings.TrimSpace(addr))
+	if parts := strings.Split(addr, ":"); len(parts) == 3 {
+		addr = parts[1] + ":" + parts[2]
+	}
+	return addr
+}
+
+// FindGPU returns the detected GPU whose PCI address or NVIDIA UUID (with or without the
+// "GPU-" prefix) matches id
+func FindGPU(gpus []GPUInfo, id string) (GPUInfo, bool) {
+	id = strings.TrimSpace(id)
+	uuid := strings.TrimPrefix(strings.ToLower(id), "gpu-")
+	for _, gpu := range gpus {
+		if gpu.PCIAddress != "" && NormalizePCIAddress(gpu.PCIAddress) == NormalizePCIAddress(id) {
+			return gpu, true
+		}
+		if gpu.UUID != "" && strings.TrimPrefix(strings.ToLower(gpu.UUID), "gpu-") == uuid {
+			return gpu, true
+		}
+	}
+	return GPUInfo{}, false
+}
+
+// sysfsPCIDevices is where Linux lists PCI devices by full domain:bus:device.function address
+var sysfsPCIDevices = "/sys/bus/pci/devices"
+
+// RenderNode returns the DRM render node of the GPU at a PCI address, e.g. /dev/dri/renderD129,
+// or "" when sysfs lists none (not Linux, or no DRM driver bound to the device)
+func RenderNode(pciAddress string) string {
+	if pciAddress == "" {
+		return ""
+	}
+	nodes, _ := filepath.Glob(filepath.Join(sysfsPCIDevice(pciAddress), "drm", "renderD*"))
+	if len(nodes) == 0 {
+		return ""
+	}
+	return "/dev/dri/" + filepath.Base(nodes[0])
+}
+
+// PCIDeviceID returns the PCI device ID of the GPU at a PCI address as lowercase hex, e.g.
+// 56a0, or "" when sysfs doesn't list the device
+func PCIDeviceID(pciAddress string) string {
+	if pciAddress == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(sysfsPCIDevice(pciAddress), "device"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(string(data))), "0x")
+}
+
+// sysfsPCIDevice returns the sysfs directory of the device at a PCI address
+func sysfsPCIDevice(pciAddress string) string {
+	// sysfs names devices with a four-digit domain; nvidia-smi prints eight, lspci none
+	domain := "0000"
+	if parts := strings.Split(strings.ToLower(strings.TrimSpace(pciAddress)), ":"); len(parts) == 3 {
+		domain = parts[0][max(len(parts[0])-4, 0):]
+	}
+	return filepath.Join(sysfsPCIDevices, domain+":"+NormalizePCIAddress(pciAddress))
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindGPU(t *testing.T) {
+	gpus := []GPUInfo{
+		{Vendor: "intel", Model: "UHD Graphics 770", PCIAddress: "00:02.0"},
+		{Vendor: "nvidia", Model: "GeForce RTX 4090", PCIAddress: "01:00.0", UUID: "GPU-3f2a9c1e-0000-1111-2222-333344445555", DeviceIndex: "0"},
+		{Vendor: "nvidia", Model: "GeForce RTX 3060", PCIAddress: "0A:00.0", UUID: "GPU-77aa0000-0000-1111-2222-333344445555", DeviceIndex: "1"},
+	}
+
+	tests := []struct {
+		id        string
+		wantModel string
+	}{
+		{"01:00.0", "GeForce RTX 4090"},
+		{"0000:0a:00.0", "GeForce RTX 3060"},
+		{"00000000:00:02.0", "UHD Graphics 770"},
+		{"GPU-77aa0000-0000-1111-2222-333344445555", "GeForce RTX 3060"},
+		{"3f2a9c1e-0000-1111-2222-333344445555", "GeForce RTX 4090"},
+		{"02:00.0", ""},
+		{"1", ""},
+	}
+
+	for _, tt := range tests {
+		gpu, ok := FindGPU(gpus, tt.id)
+		if tt.wantModel == "" {
+			if ok {
+				t.Errorf("FindGPU(%q) matched %s, want no match", tt.id, gpu.Model)
+			}
+			continue
+		}
+		if !ok || gpu.Model != tt.wantModel {
+			t.Errorf("FindGPU(%q) = %q, %v; want %q", tt.id, gpu.Model, ok, tt.wantModel)
+		}
+	}
+}
+
+func TestRenderNode(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"0000:00:02.0/drm/card0", "0000:00:02.0/drm/renderD128", "0000:03:00.0/drm/card1", "0000:03:00.0/drm/renderD129", "0000:01:00.0/drm/card2"} {
+		if err := os.MkdirAll(filepath.Join(dir, path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(old string) { sysfsPCIDevices = old }(sysfsPCIDevices)
+	sysfsPCIDevices = dir
+
+	tests := []struct {
+		addr, want string
+	}{
+		{"00:02.0", "/dev/dri/renderD128"},
+		{"03:00.0", "/dev/dri/renderD129"},
+		{"00000000:03:00.0", "/dev/dri/renderD129"},
+		{"0000:03:00.0", "/dev/dri/renderD129"},
+		{"01:00.0", ""}, // no render node bound
+		{"05:00.0", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := RenderNode(tt.addr); got != tt.want {
+			t.Errorf("RenderNode(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestPCIDeviceID(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "0000:03:00.0"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "0000:03:00.0", "device"), []byte("0x56A0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(old string) { sysfsPCIDevices = old }(sysfsPCIDevices)
+	sysfsPCIDevices = dir
+
+	tests := []struct {
+		addr, want string
+	}{
+		{"03:00.0", "56a0"},
+		{"00000000:03:00.0", "56a0"},
+		{"01:00.0", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := PCIDeviceID(tt.addr); got != tt.want {
+			t.Errorf("PCIDeviceID(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package utils
+
+import "strings"
+
+// lspciDisplayClasses are the PCI classes of GPUs as lspci names them
+var lspciDisplayClasses = []string{"vga compatible controller", "3d controller", "display controller"}
+
+// parseLinuxLspciMachineOutput parses `lspci -mm`, which prints one device per line with
+// quoted fields that don't depend on the distro's formatting or the locale:
+//
+//	01:00.0 "VGA compatible controller" "NVIDIA Corporation" "TU106 [GeForce RTX 2060 SUPER]" -ra1 -p00 "Micro-Star International Co., Ltd. [MSI]" "Device c758"
+func (d *GPUDetector) parseLinuxLspciMachineOutput(output string) []GPUInfo {
+	var gpus []GPUInfo
+	for _, line := range strings.Split(output, "\n") {
+		fields := splitLspciFields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		slot, class, vendor, device := fields[0], fields[1], fields[2], fields[3]
+		if !isLspciDisplayClass(class) {
+			continue
+		}
+
+		model := strings.TrimSpace(vendor + " " + device)
+		if d.isGenericGPU(model) {
+			continue
+		}
+
+		gpus = append(gpus, GPUInfo{
+			Vendor:     lspciVendor(vendor),
+			Model:      model,
+			PCIAddress: slot,
+			RawOutput:  line,
+		})
+	}
+	return gpus
+}
+
+// splitLspciFields splits an `lspci -mm` line into its fields, unquoting the quoted ones.
+// Empty quoted fields are kept so the positions of the later fields don't shift.
+func splitLspciFields(line string) []string {
+	var fields []string
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+		if rest, quoted := strings.CutPrefix(line, `"`); quoted {
+			field, after, _ := strings.Cut(rest, `"`)
+			fields = append(fields, field)
+			line = after
+			continue
+		}
+		field, after, _ := strings.Cut(line, " ")
+		fields = append(fields, field)
+		line = after
+	}
+	return fields
+}
+
+// isLspciDisplayClass reports whether an lspci device class is a GPU
+func isLspciDisplayClass(class string) bool {
+	lower := strings.ToLower(class)
+	for _, c := range lspciDisplayClasses {
+		if lower == c {
+			return true
+		}
+	}
+	return false
+}
+
+// lspciVendor maps a PCI vendor name to the GPU vendor. The names are matched as a whole
+// rather than searched for model keywords, which misfire on words such as "Corporation".
+func lspciVendor(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(lower, "nvidia"):
+		return "nvidia"
+	case strings.HasPrefix(lower, "advanced micro devices"), strings.HasPrefix(lower, "ati technologies"):
+		return "amd"
+	case strings.HasPrefix(lower, "intel"):
+		return "intel"
+	case strings.HasPrefix(lower, "apple"):
+		return "apple"
+	}
+	return "unknown"
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// busyUtilization is the encoder utilization, in percent, above which another encode would crawl
+const busyUtilization = 90
+
+// EncoderUsage is the NVENC load of one NVIDIA GPU as reported by nvidia-smi
+type EncoderUsage struct {
+	Name        string
+	Sessions    int // active encoder sessions across all processes
+	Utilization int // encoder engine utilization in percent
+	// SessionLimit is the driver's concurrent session cap; 0 means unlimited
+	SessionLimit int
+}
+
+// QueryEncoderUsage reads the NVENC session count and utilization of the GPU with the given
+// nvidia-smi index
+func (d *GPUDetector) QueryEncoderUsage(index string) (EncoderUsage, error) {
+	out, err := d.runCommandWithTimeout("nvidia-smi", "-i", index,
+		"--query-gpu=name,driver_version,encoder.stats.sessionCount,utilization.encoder", "--format=csv,noheader,nounits")
+	if err != nil {
+		return EncoderUsage{}, fmt.Errorf("nvidia-smi encoder query failed: %w", err)
+	}
+	return d.parseEncoderUsage(string(out))
+}
+
+// parseEncoderUsage parses one "name, driver, sessions, utilization" line of nvidia-smi CSV output
+func (d *GPUDetector) parseEncoderUsage(out string) (EncoderUsage, error) {
+	line, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	fields := strings.Split(line, ",")
+	if len(fields) != 4 {
+		return EncoderUsage{}, fmt.Errorf("unexpected nvidia-smi output %q", line)
+	}
+
+	sessions, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+	if err != nil {
+		return EncoderUsage{}, fmt.Errorf("unexpected encoder session count %q", fields[2])
+	}
+	// Utilization reads "[N/A]" on GPUs that don't report it
+	utilization, _ := strconv.Atoi(strings.TrimSpace(fields[3]))
+
+	usage := EncoderUsage{
+		Name:        strings.TrimSpace(fields[0]),
+		Sessions:    sessions,
+		Utilization: utilization,
+	}
+	if !d.isNvidiaProfessional(strings.ToLower(usage.Name)) {
+		usage.SessionLimit = nvidiaConsumerSessionLimit(strings.TrimSpace(fields[1]))
+	}
+	return usage, nil
+}
+
+// Busy describes why a new encode is likely to fail or stall on this GPU, or returns "" if it isn't busy
+func (u EncoderUsage) Busy() string {
+	if u.SessionLimit > 0 && u.Sessions >= u.SessionLimit {
+		return fmt.Sprintf("all %d NVENC sessions on %s are in use by other processes", u.SessionLimit, u.Name)
+	}
+	if u.Utilization >= busyUtilization {
+		return fmt.Sprintf("the NVENC engine on %s is %d%% utilized (%d active sessions)", u.Name, u.Utilization, u.Sessions)
+	}
+	return ""
+}
@@ -0,0 +1,33 @@
+package utils
+
+import "testing"
+
+func TestEncoderUsageBusy(t *testing.T) {
+	tests := []struct {
+		out      string
+		wantBusy bool
+	}{
+		{"NVIDIA GeForce RTX 3060, 555.42.02, 2, 35\n", false},
+		{"NVIDIA GeForce RTX 3060, 555.42.02, 8, 60\n", true},
+		{"NVIDIA GeForce GTX 1080, 525.60.11, 3, 20\n", true},
+		{"NVIDIA RTX A4000, 555.42.02, 12, 40\n", false},
+		{"NVIDIA RTX A4000, 555.42.02, 12, 97\n", true},
+		{"Tesla T4, 535.104.05, 0, [N/A]\n", false},
+	}
+
+	d := NewGPUDetector()
+	for _, tt := range tests {
+		usage, err := d.parseEncoderUsage(tt.out)
+		if err != nil {
+			t.Errorf("parseEncoderUsage(%q) error: %v", tt.out, err)
+			continue
+		}
+		if got := usage.Busy() != ""; got != tt.wantBusy {
+			t.Errorf("parseEncoderUsage(%q).Busy() = %q, want busy=%v", tt.out, usage.Busy(), tt.wantBusy)
+		}
+	}
+
+	if _, err := d.parseEncoderUsage("No devices were found\n"); err == nil {
+		t.Error("parseEncoderUsage() accepted output without encoder stats")
+	}
+}
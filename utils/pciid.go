@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// pnpDeviceID extracts the PCI device ID from a Windows PNPDeviceID such as
+// PCI\VEN_8086&DEV_56A0&SUBSYS_...
+var pnpDeviceID = regexp.MustCompile(`(?i)\bDEV_([0-9a-f]{4})`)
+
+// amdAPUDeviceIDs are the PCI device IDs of the GPUs built into AMD APUs: Carrizo, Stoney,
+// Raven, Picasso, Renoir, Lucienne, Cezanne, Barcelo, Van Gogh, Rembrandt, Mendocino, Raphael,
+// Granite Ridge, Phoenix, Hawk Point, Strix Point and Strix Halo
+var amdAPUDeviceIDs = map[uint16]bool{
+	0x9874: true, 0x98e4: true, 0x15dd: true, 0x15d8: true, 0x1636: true, 0x164c: true,
+	0x1638: true, 0x15e7: true, 0x163f: true, 0x1681: true, 0x1506: true, 0x164e: true,
+	0x13c0: true, 0x15bf: true, 0x15c8: true, 0x1900: true, 0x1901: true, 0x150e: true,
+	0x1586: true,
+}
+
+// intelDiscreteModel matches the names of Intel's discrete GPUs: Arc A- and B-series cards
+// (not the Arc-branded iGPUs of Meteor Lake and later), Iris Xe MAX and the data center GPUs
+var intelDiscreteModel = regexp.MustCompile(`\barc(?:\(tm\))? (?:pro )?[ab]\d{2,3}|iris(?:\(r\))? xe max|data center gpu`)
+
+// amdIntegratedModel matches the names AMD gives the GPUs of its APUs: the generic "Radeon
+// Graphics", "Radeon (RX) Vega N Graphics", the 680M-style mobile names, and the chip codenames
+// lspci reports
+var amdIntegratedModel = regexp.MustCompile(`radeon(?:\(tm\))? graphics|vega \d+ graphics|radeon(?:\(tm\))? \d{3}m\b|` +
+	`\b(?:carrizo|stoney|raven|picasso|renoir|lucienne|cezanne|barcelo|van gogh|rembrandt|mendocino|raphael|phoenix|hawk point|strix)\b`)
+
+// parsePCIDeviceID parses a hexadecimal PCI device ID, reporting whether there was one
+func parsePCIDeviceID(s string) (uint16, bool) {
+	id, err := strconv.ParseUint(s, 16, 16)
+	return uint16(id), err == nil
+}
+
+// isIntelDiscreteID reports whether an Intel PCI device ID belongs to a discrete GPU: DG1
+// (4905-4909), Ponte Vecchio (0bdx), Alchemist and the Flex series (56xx) or Battlemage
+// (e2xx). Intel's other display IDs are all iGPUs.
+func isIntelDiscreteID(id uint16) bool {
+	return id>>8 == 0x56 || id>>8 == 0xe2 || id>>4 == 0x0bd || (id >= 0x4905 && id <= 0x4909)
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// IsWSL reports whether the process runs under the Windows Subsystem for Linux. WSL2 exposes
+// GPUs through /dev/dxg rather than DRM render nodes, so only NVIDIA's CUDA/NVENC path (via
+// the Windows driver) works; VAAPI and the Windows DirectX decoders are unavailable.
+var IsWSL = sync.OnceValue(func() bool {
+	for _, path := range []string{"/proc/sys/kernel/osrelease", "/proc/version"} {
+		if data, err := os.ReadFile(path); err == nil && isWSLKernel(string(data)) {
+			return true
+		}
+	}
+	return false
+})
+
+// isWSLKernel reports whether a kernel release or version string is a WSL kernel,
+// e.g. 5.15.153.1-microsoft-standard-WSL2
+func isWSLKernel(release string) bool {
+	return strings.Contains(strings.ToLower(release), "microsoft")
+}
+
+// detectWSLGPUs lists the NVIDIA GPUs shared with WSL via nvidia-smi, which the WSL driver
+// provides. lspci only shows a virtual "Microsoft Basic Render Driver" device there.
+func (d *GPUDetector) detectWSLGPUs() []GPUInfo {
+	out, err := d.runCommandWithTimeout("nvidia-smi", "--query-gpu=name,driver_version,memory.total,pci.bus_id", "--format=csv,noheader")
+	if err != nil {
+		return nil
+	}
+	return d.parseWSLNvidiaSMIOutput(string(out))
+}
+
+// parseWSLNvidiaSMIOutput parses name, driver, memory and PCI address lines from nvidia-smi
+func (d *GPUDetector) parseWSLNvidiaSMIOutput(output string) []GPUInfo {
+	var gpus []GPUInfo
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) != 4 {
+			continue
+		}
+		gpus = append(gpus, GPUInfo{
+			Vendor:        "nvidia",
+			Model:         strings.TrimSpace(fields[0]),
+			DriverVersion: strings.TrimSpace(fields[1]),
+			Memory:        strings.TrimSpace(fields[2]),
+			PCIAddress:    NormalizePCIAddress(fields[3]),
+			RawOutput:     line,
+		})
+	}
+	return gpus
+}
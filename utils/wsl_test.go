@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsWSLKernel(t *testing.T) {
+	tests := []struct {
+		release string
+		want    bool
+	}{
+		{"5.15.153.1-microsoft-standard-WSL2\n", true},
+		{"4.4.0-19041-Microsoft\n", true},
+		{"Linux version 5.15.153.1-microsoft-standard-WSL2 (root@941d701f84f1) (gcc (GCC) 11.2.0)\n", true},
+		{"6.8.0-45-generic\n", false},
+	}
+
+	for _, tt := range tests {
+		if got := isWSLKernel(tt.release); got != tt.want {
+			t.Errorf("isWSLKernel(%q) = %v, want %v", tt.release, got, tt.want)
+		}
+	}
+}
+
+func TestParseWSLNvidiaSMIOutput(t *testing.T) {
+	out := "NVIDIA GeForce RTX 4070, 560.94, 12282 MiB, 00000000:01:00.0\n"
+	want := []GPUInfo{{
+		Vendor:        "nvidia",
+		Model:         "NVIDIA GeForce RTX 4070",
+		DriverVersion: "560.94",
+		Memory:        "12282 MiB",
+		PCIAddress:    "01:00.0",
+		RawOutput:     "NVIDIA GeForce RTX 4070, 560.94, 12282 MiB, 00000000:01:00.0",
+	}}
+
+	got := NewGPUDetector().parseWSLNvidiaSMIOutput(out)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseWSLNvidiaSMIOutput() = %+v, want %+v", got, want)
+	}
+	if gpus := NewGPUDetector().parseWSLNvidiaSMIOutput("No devices were found\n"); len(gpus) != 0 {
+		t.Errorf("parseWSLNvidiaSMIOutput() found GPUs in %q", "No devices were found")
+	}
+}